@@ -0,0 +1,97 @@
+package sparkgap
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+const defaultAdaptiveWindow = time.Minute
+
+/*
+Adaptive tunes FailureThreshold and RetryInterval from how often the breaker trips, using
+additive-increase/multiplicative-decrease. Every trip halves the failure threshold and adds
+RetryInterval to the open period, so a flapping dependency is cut off sooner and for longer;
+every Window without a trip raises the threshold by one and halves the open period again.
+Both start at the configured FailureThreshold and RetryInterval. When RetryBackoff is set it
+still decides the open period and only the threshold adapts.
+*/
+type Adaptive struct {
+	// MinFailureThreshold and MaxFailureThreshold bound the threshold. Zero means 1 and
+	// twice FailureThreshold.
+	MinFailureThreshold uint32
+	MaxFailureThreshold uint32
+	// MinRetryInterval and MaxRetryInterval bound the open period. Zero means half and
+	// eight times RetryInterval.
+	MinRetryInterval time.Duration
+	MaxRetryInterval time.Duration
+	// Window is the quiet period after which the breaker relaxes one step. Zero means one minute.
+	Window time.Duration
+}
+
+func (a *Adaptive) applyDefaults(threshold uint32, retry time.Duration) {
+	if a.MinFailureThreshold == 0 {
+		a.MinFailureThreshold = 1
+	}
+	if a.MaxFailureThreshold == 0 {
+		a.MaxFailureThreshold = max(2*threshold, a.MinFailureThreshold)
+	}
+	if a.MinRetryInterval <= 0 {
+		a.MinRetryInterval = retry / 2
+	}
+	if a.MaxRetryInterval <= 0 {
+		a.MaxRetryInterval = max(8*retry, a.MinRetryInterval)
+	}
+	if a.Window <= 0 {
+		a.Window = defaultAdaptiveWindow
+	}
+}
+
+// adaptiveState is the running state of Adaptive: the step size and when it last adjusted.
+type adaptiveState struct {
+	Adaptive
+	step       time.Duration
+	lastAdjust time.Time
+}
+
+func newAdaptiveState(a *Adaptive, retry time.Duration, now time.Time) *adaptiveState {
+	if a == nil {
+		return nil
+	}
+	return &adaptiveState{Adaptive: *a, step: retry, lastAdjust: now}
+}
+
+/*
+adaptLocked applies one relax step per quiet Window since the last adjustment and, if tripped,
+tightens for the current trip.
+*/
+func (br *CircuitBreaker) adaptLocked(now time.Time, tripped bool) {
+	a := br.adaptive
+	if a == nil {
+		return
+	}
+	threshold := atomic.LoadUint32(&br.counter.failureThreshold)
+	retry := br.counter.retryInterval
+	if quiet := now.Sub(a.lastAdjust) / a.Window; quiet > 0 {
+		n := min(quiet, 64)
+		threshold = uint32(min(uint64(threshold)+uint64(n), uint64(a.MaxFailureThreshold)))
+		retry = max(retry>>n, a.MinRetryInterval)
+		a.lastAdjust = a.lastAdjust.Add(quiet * a.Window)
+	}
+	if tripped {
+		threshold = max(threshold/2, a.MinFailureThreshold)
+		retry = min(retry+a.step, a.MaxRetryInterval)
+		a.lastAdjust = now
+	}
+	atomic.StoreUint32(&br.counter.failureThreshold, threshold)
+	br.counter.retryInterval = retry
+}
+
+// relaxAdaptive lets quiet windows raise the threshold before a Closed-state failure is judged against it.
+func (br *CircuitBreaker) relaxAdaptive() {
+	if br.adaptive == nil {
+		return
+	}
+	br.mu.Lock()
+	br.adaptLocked(br.clock.Now(), false)
+	br.mu.Unlock()
+}
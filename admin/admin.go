@@ -0,0 +1,125 @@
+/*
+Package admin exposes the breakers of a sparkgap.Registry over HTTP for operational control.
+
+Routes, all speaking JSON:
+
+	GET  /breakers               snapshots of every breaker; ?label=key:value keeps matching ones
+	GET  /breakers/stream        Server-Sent Events of state changes and counters
+	GET  /breakers/{name}        snapshot of one breaker
+	POST /breakers/{name}/trip   force the breaker open
+	POST /breakers/{name}/reset  force the breaker closed
+	POST /breakers/{name}/probe  move an open breaker to half-open now
+	GET  /events/stream          alias of /breakers/stream
+
+	POST   /breakers/{name}/force/{mode}  pin the breaker: mode is open, closed or disabled
+	DELETE /breakers/{name}/force         hand the breaker back to its state machine
+
+Mount it under a prefix with http.StripPrefix.
+*/
+package admin
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/afk-ankit/sparkgap"
+)
+
+// Handler returns an http.Handler serving the admin API for reg.
+func Handler(reg *sparkgap.Registry) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /breakers", func(w http.ResponseWriter, r *http.Request) {
+		selector, err := ParseSelector(r.URL.Query()["label"])
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		snaps := reg.Snapshots()
+		if len(selector) > 0 {
+			snaps = slices.DeleteFunc(snaps, func(s sparkgap.BreakerSnapshot) bool {
+				return !sparkgap.MatchLabels(s.Labels, selector)
+			})
+		}
+		writeJSON(w, http.StatusOK, snaps)
+	})
+	// The literal pattern takes precedence over /breakers/{name}.
+	mux.HandleFunc("GET /breakers/stream", stream(reg))
+	mux.HandleFunc("GET /events/stream", stream(reg))
+	mux.HandleFunc("GET /breakers/{name}", withBreaker(reg, func(w http.ResponseWriter, r *http.Request, b sparkgap.Managed) {
+		writeJSON(w, http.StatusOK, b.Snapshot())
+	}))
+	mux.HandleFunc("POST /breakers/{name}/trip", withBreaker(reg, func(w http.ResponseWriter, r *http.Request, b sparkgap.Managed) {
+		b.Trip()
+		writeJSON(w, http.StatusOK, b.Snapshot())
+	}))
+	mux.HandleFunc("POST /breakers/{name}/reset", withBreaker(reg, func(w http.ResponseWriter, r *http.Request, b sparkgap.Managed) {
+		b.Reset()
+		writeJSON(w, http.StatusOK, b.Snapshot())
+	}))
+	mux.HandleFunc("POST /breakers/{name}/probe", withBreaker(reg, func(w http.ResponseWriter, r *http.Request, b sparkgap.Managed) {
+		if !b.ProbeNow() {
+			writeError(w, http.StatusConflict, "breaker is not open")
+			return
+		}
+		writeJSON(w, http.StatusOK, b.Snapshot())
+	}))
+	mux.HandleFunc("POST /breakers/{name}/force/{mode}", withBreaker(reg, func(w http.ResponseWriter, r *http.Request, b sparkgap.Managed) {
+		switch r.PathValue("mode") {
+		case "open":
+			b.ForceOpen()
+		case "closed":
+			b.ForceClosed()
+		case "disabled":
+			b.Disable()
+		default:
+			writeError(w, http.StatusBadRequest, "mode must be open, closed or disabled")
+			return
+		}
+		writeJSON(w, http.StatusOK, b.Snapshot())
+	}))
+	mux.HandleFunc("DELETE /breakers/{name}/force", withBreaker(reg, func(w http.ResponseWriter, r *http.Request, b sparkgap.Managed) {
+		b.ClearForced()
+		writeJSON(w, http.StatusOK, b.Snapshot())
+	}))
+	return mux
+}
+
+func withBreaker(reg *sparkgap.Registry, fn func(http.ResponseWriter, *http.Request, sparkgap.Managed)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		b, ok := reg.Get(r.PathValue("name"))
+		if !ok {
+			writeError(w, http.StatusNotFound, "breaker not found")
+			return
+		}
+		fn(w, r, b)
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, msg string) {
+	writeJSON(w, status, map[string]string{"error": msg})
+}
+
+// ParseSelector parses label selectors of the form "key:value" into a map, as for ?label=.
+func ParseSelector(selectors []string) (map[string]string, error) {
+	if len(selectors) == 0 {
+		return nil, nil
+	}
+	out := make(map[string]string, len(selectors))
+	for _, sel := range selectors {
+		k, v, ok := strings.Cut(sel, ":")
+		if !ok || k == "" {
+			return nil, fmt.Errorf("invalid label selector %q, want key:value", sel)
+		}
+		out[k] = v
+	}
+	return out, nil
+}
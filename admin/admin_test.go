@@ -0,0 +1,121 @@
+package admin_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/afk-ankit/sparkgap"
+	"github.com/afk-ankit/sparkgap/admin"
+)
+
+// newRegistry returns a registry holding a breaker per name, labeled team:<name>.
+func newRegistry(t *testing.T, names ...string) *sparkgap.Registry {
+	t.Helper()
+	reg := sparkgap.NewRegistry()
+	for _, name := range names {
+		br, err := sparkgap.NewCircuitBreaker(name, &sparkgap.BreakerConfig{Labels: map[string]string{"team": name}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { br.Close() })
+		if err := reg.Register(br); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return reg
+}
+
+func serve(t *testing.T, h http.Handler, method, path string) *httptest.ResponseRecorder {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(method, path, nil))
+	return rec
+}
+
+func TestHandler(t *testing.T) {
+	cases := []struct {
+		name   string
+		method string
+		path   string
+		status int
+		state  sparkgap.State
+		forced sparkgap.ForcedMode
+	}{
+		{"snapshot", http.MethodGet, "/breakers/db", http.StatusOK, sparkgap.StateClosed, sparkgap.NotForced},
+		{"trip", http.MethodPost, "/breakers/db/trip", http.StatusOK, sparkgap.StateOpen, sparkgap.NotForced},
+		{"probe while closed", http.MethodPost, "/breakers/db/probe", http.StatusConflict, 0, 0},
+		{"force open", http.MethodPost, "/breakers/db/force/open", http.StatusOK, sparkgap.StateClosed, sparkgap.ForcedOpen},
+		{"bad force mode", http.MethodPost, "/breakers/db/force/sideways", http.StatusBadRequest, 0, 0},
+		{"unknown breaker", http.MethodGet, "/breakers/nope", http.StatusNotFound, 0, 0},
+		{"wrong method", http.MethodGet, "/breakers/db/trip", http.StatusMethodNotAllowed, 0, 0},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			h := admin.Handler(newRegistry(t, "db"))
+			rec := serve(t, h, tc.method, tc.path)
+			if rec.Code != tc.status {
+				t.Fatalf("%s %s = %d %s, want %d", tc.method, tc.path, rec.Code, rec.Body, tc.status)
+			}
+			if tc.status != http.StatusOK {
+				return
+			}
+			var s sparkgap.BreakerSnapshot
+			if err := json.NewDecoder(rec.Body).Decode(&s); err != nil {
+				t.Fatal(err)
+			}
+			if s.Name != "db" || s.State != tc.state || s.Forced != tc.forced {
+				t.Fatalf("snapshot %s is %s forced %s, want db %s forced %s", s.Name, s.State, s.Forced, tc.state, tc.forced)
+			}
+		})
+	}
+}
+
+func TestHandlerResetAndProbe(t *testing.T) {
+	reg := newRegistry(t, "db")
+	h := admin.Handler(reg)
+	br, _ := reg.CircuitBreaker("db")
+	serve(t, h, http.MethodPost, "/breakers/db/trip")
+	if rec := serve(t, h, http.MethodPost, "/breakers/db/probe"); rec.Code != http.StatusOK || br.State() != sparkgap.StateHalfOpen {
+		t.Fatalf("probe = %d, state %s; want 200 and Half-Open", rec.Code, br.State())
+	}
+	if rec := serve(t, h, http.MethodPost, "/breakers/db/reset"); rec.Code != http.StatusOK || br.State() != sparkgap.StateClosed {
+		t.Fatalf("reset = %d, state %s; want 200 and Closed", rec.Code, br.State())
+	}
+	serve(t, h, http.MethodPost, "/breakers/db/force/disabled")
+	if rec := serve(t, h, http.MethodDelete, "/breakers/db/force"); rec.Code != http.StatusOK || br.Snapshot().Forced != sparkgap.NotForced {
+		t.Fatalf("clear force = %d, forced %s; want 200 and not forced", rec.Code, br.Snapshot().Forced)
+	}
+}
+
+func TestHandlerList(t *testing.T) {
+	h := admin.Handler(newRegistry(t, "db", "cache"))
+	cases := []struct {
+		path   string
+		status int
+		want   int
+	}{
+		{"/breakers", http.StatusOK, 2},
+		{"/breakers?label=team:db", http.StatusOK, 1},
+		{"/breakers?label=team:none", http.StatusOK, 0},
+		{"/breakers?label=team", http.StatusBadRequest, 0},
+	}
+	for _, tc := range cases {
+		rec := serve(t, h, http.MethodGet, tc.path)
+		if rec.Code != tc.status {
+			t.Errorf("GET %s = %d, want %d", tc.path, rec.Code, tc.status)
+			continue
+		}
+		if tc.status != http.StatusOK {
+			continue
+		}
+		var snaps []sparkgap.BreakerSnapshot
+		if err := json.NewDecoder(rec.Body).Decode(&snaps); err != nil {
+			t.Fatal(err)
+		}
+		if len(snaps) != tc.want {
+			t.Errorf("GET %s returned %d breakers, want %d", tc.path, len(snaps), tc.want)
+		}
+	}
+}
@@ -0,0 +1,210 @@
+package admin
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/afk-ankit/sparkgap"
+)
+
+/*
+Client talks to an admin Handler served by another process. BaseURL is the URL the handler is
+mounted at, e.g. "http://localhost:8080/admin".
+*/
+type Client struct {
+	BaseURL string
+	// HTTPClient is used for requests; nil means http.DefaultClient.
+	HTTPClient *http.Client
+}
+
+// Snapshots returns the snapshots of every breaker in the remote registry.
+func (c *Client) Snapshots(ctx context.Context) ([]sparkgap.BreakerSnapshot, error) {
+	var snaps []sparkgap.BreakerSnapshot
+	err := c.do(ctx, http.MethodGet, "/breakers", &snaps)
+	return snaps, err
+}
+
+// SnapshotsMatching returns the snapshots of the remote breakers having every label of selector.
+func (c *Client) SnapshotsMatching(ctx context.Context, selector map[string]string) ([]sparkgap.BreakerSnapshot, error) {
+	q := url.Values{}
+	for _, k := range slices.Sorted(maps.Keys(selector)) {
+		q.Add("label", k+":"+selector[k])
+	}
+	path := "/breakers"
+	if len(q) > 0 {
+		path += "?" + q.Encode()
+	}
+	var snaps []sparkgap.BreakerSnapshot
+	err := c.do(ctx, http.MethodGet, path, &snaps)
+	return snaps, err
+}
+
+// Snapshot returns the snapshot of the remote breaker called name.
+func (c *Client) Snapshot(ctx context.Context, name string) (sparkgap.BreakerSnapshot, error) {
+	var snap sparkgap.BreakerSnapshot
+	err := c.do(ctx, http.MethodGet, "/breakers/"+url.PathEscape(name), &snap)
+	return snap, err
+}
+
+// Trip forces the remote breaker called name open and returns its new snapshot.
+func (c *Client) Trip(ctx context.Context, name string) (sparkgap.BreakerSnapshot, error) {
+	return c.act(ctx, http.MethodPost, name, "/trip")
+}
+
+// Reset forces the remote breaker called name closed and returns its new snapshot.
+func (c *Client) Reset(ctx context.Context, name string) (sparkgap.BreakerSnapshot, error) {
+	return c.act(ctx, http.MethodPost, name, "/reset")
+}
+
+// Probe moves the remote breaker called name from open to half-open and returns its new snapshot.
+func (c *Client) Probe(ctx context.Context, name string) (sparkgap.BreakerSnapshot, error) {
+	return c.act(ctx, http.MethodPost, name, "/probe")
+}
+
+// Force pins the remote breaker called name in mode, which must not be NotForced.
+func (c *Client) Force(ctx context.Context, name string, mode sparkgap.ForcedMode) (sparkgap.BreakerSnapshot, error) {
+	if mode == sparkgap.NotForced {
+		return c.ClearForced(ctx, name)
+	}
+	return c.act(ctx, http.MethodPost, name, "/force/"+mode.String())
+}
+
+// ClearForced hands the remote breaker called name back to its state machine.
+func (c *Client) ClearForced(ctx context.Context, name string) (sparkgap.BreakerSnapshot, error) {
+	return c.act(ctx, http.MethodDelete, name, "/force")
+}
+
+// Change is a "state" event of the stream: a state change, snooze reminder or saturation warning.
+type Change struct {
+	// Kind is the sparkgap.EventKind by name, e.g. "StateChange".
+	Kind     string
+	Breaker  string
+	Time     time.Time
+	From     sparkgap.State
+	To       sparkgap.State
+	Cause    sparkgap.Cause
+	InFlight int64
+	Labels   map[string]string
+}
+
+// StreamEvent is one event of the stream; exactly one of its fields is set.
+type StreamEvent struct {
+	Change    *Change
+	Snapshots []sparkgap.BreakerSnapshot
+}
+
+/*
+Stream follows the Server-Sent Events stream of the remote registry, calling fn for every event,
+with snapshots every interval (zero leaves it to the server). It returns when ctx is done, the
+server ends the stream or fn returns an error, which is then returned.
+*/
+func (c *Client) Stream(ctx context.Context, interval time.Duration, fn func(StreamEvent) error) error {
+	path := "/breakers/stream"
+	if interval > 0 {
+		path += "?interval=" + url.QueryEscape(interval.String())
+	}
+	resp, err := c.send(ctx, http.MethodGet, path)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	sc := bufio.NewScanner(resp.Body)
+	sc.Buffer(nil, 4<<20)
+	var name, data string
+	for sc.Scan() {
+		line := sc.Text()
+		switch {
+		case strings.HasPrefix(line, "event: "):
+			name = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			data = strings.TrimPrefix(line, "data: ")
+		case line == "":
+			ev, err := decodeStreamEvent(name, data)
+			name, data = "", ""
+			if err != nil {
+				return err
+			}
+			if ev == nil {
+				continue
+			}
+			if err := fn(*ev); err != nil {
+				return err
+			}
+		}
+	}
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	return sc.Err()
+}
+
+func decodeStreamEvent(name, data string) (*StreamEvent, error) {
+	var ev StreamEvent
+	switch name {
+	case "state":
+		ev.Change = new(Change)
+		if err := json.Unmarshal([]byte(data), ev.Change); err != nil {
+			return nil, fmt.Errorf("decoding state event: %w", err)
+		}
+	case "snapshot":
+		if err := json.Unmarshal([]byte(data), &ev.Snapshots); err != nil {
+			return nil, fmt.Errorf("decoding snapshot event: %w", err)
+		}
+	default:
+		// Ignore events added after this client was written.
+		return nil, nil
+	}
+	return &ev, nil
+}
+
+func (c *Client) act(ctx context.Context, method, name, action string) (sparkgap.BreakerSnapshot, error) {
+	var snap sparkgap.BreakerSnapshot
+	err := c.do(ctx, method, "/breakers/"+url.PathEscape(name)+action, &snap)
+	return snap, err
+}
+
+func (c *Client) do(ctx context.Context, method, path string, out any) error {
+	resp, err := c.send(ctx, method, path)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// send makes a request and turns error responses into errors.
+func (c *Client) send(ctx context.Context, method, path string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, strings.TrimSuffix(c.BaseURL, "/")+path, nil)
+	if err != nil {
+		return nil, err
+	}
+	hc := c.HTTPClient
+	if hc == nil {
+		hc = http.DefaultClient
+	}
+	resp, err := hc.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= 300 {
+		defer resp.Body.Close()
+		var e struct {
+			Error string `json:"error"`
+		}
+		_ = json.NewDecoder(resp.Body).Decode(&e)
+		if e.Error == "" {
+			e.Error = resp.Status
+		}
+		return nil, fmt.Errorf("%s %s: %s", method, path, e.Error)
+	}
+	return resp, nil
+}
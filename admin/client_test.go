@@ -0,0 +1,98 @@
+package admin_test
+
+import (
+	"context"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/afk-ankit/sparkgap"
+	"github.com/afk-ankit/sparkgap/admin"
+)
+
+func TestClient(t *testing.T) {
+	reg := newRegistry(t, "db", "cache")
+	srv := httptest.NewServer(admin.Handler(reg))
+	defer srv.Close()
+	c := &admin.Client{BaseURL: srv.URL + "/"}
+	ctx := context.Background()
+	br, _ := reg.CircuitBreaker("db")
+
+	steps := []struct {
+		name   string
+		do     func() (sparkgap.BreakerSnapshot, error)
+		state  sparkgap.State
+		forced sparkgap.ForcedMode
+	}{
+		{"Snapshot", func() (sparkgap.BreakerSnapshot, error) { return c.Snapshot(ctx, "db") }, sparkgap.StateClosed, sparkgap.NotForced},
+		{"Trip", func() (sparkgap.BreakerSnapshot, error) { return c.Trip(ctx, "db") }, sparkgap.StateOpen, sparkgap.NotForced},
+		{"Probe", func() (sparkgap.BreakerSnapshot, error) { return c.Probe(ctx, "db") }, sparkgap.StateHalfOpen, sparkgap.NotForced},
+		{"Reset", func() (sparkgap.BreakerSnapshot, error) { return c.Reset(ctx, "db") }, sparkgap.StateClosed, sparkgap.NotForced},
+		{"Force", func() (sparkgap.BreakerSnapshot, error) { return c.Force(ctx, "db", sparkgap.Disabled) }, sparkgap.StateClosed, sparkgap.Disabled},
+		{"Force NotForced", func() (sparkgap.BreakerSnapshot, error) { return c.Force(ctx, "db", sparkgap.NotForced) }, sparkgap.StateClosed, sparkgap.NotForced},
+	}
+	for _, s := range steps {
+		snap, err := s.do()
+		if err != nil {
+			t.Fatalf("%s: %v", s.name, err)
+		}
+		if snap.State != s.state || snap.Forced != s.forced || br.State() != s.state {
+			t.Fatalf("%s: snapshot %s forced %s, want %s forced %s", s.name, snap.State, snap.Forced, s.state, s.forced)
+		}
+	}
+
+	if snaps, err := c.SnapshotsMatching(ctx, map[string]string{"team": "cache"}); err != nil || len(snaps) != 1 || snaps[0].Name != "cache" {
+		t.Fatalf("SnapshotsMatching = %v, %v; want only cache", snaps, err)
+	}
+	if snaps, err := c.Snapshots(ctx); err != nil || len(snaps) != 2 {
+		t.Fatalf("Snapshots = %d breakers, %v; want 2", len(snaps), err)
+	}
+	if _, err := c.Probe(ctx, "db"); err == nil || !strings.Contains(err.Error(), "breaker is not open") {
+		t.Fatalf("Probe of a closed breaker = %v, want the server's error", err)
+	}
+	if _, err := c.Snapshot(ctx, "nope"); err == nil || !strings.Contains(err.Error(), "breaker not found") {
+		t.Fatalf("Snapshot of an unknown breaker = %v, want the server's error", err)
+	}
+}
+
+func TestDiagnose(t *testing.T) {
+	healthy := sparkgap.BreakerSnapshot{Name: "db", FailureThreshold: 5, RetryInterval: time.Second, TotalCalls: 10}
+	cases := []struct {
+		name  string
+		snaps []sparkgap.BreakerSnapshot
+		want  []string
+	}{
+		{"healthy", []sparkgap.BreakerSnapshot{healthy}, nil},
+		{"never fires", []sparkgap.BreakerSnapshot{{Name: "db", FailureThreshold: 1_000_000, TotalCalls: 1}}, []string{"never-fires"}},
+		{
+			"percentage never fires",
+			[]sparkgap.BreakerSnapshot{{Name: "db", HalfOpenMode: sparkgap.HalfOpenPercentage, HalfOpenMaxFailurePercent: 100, TotalCalls: 1}},
+			[]string{"never-fires"},
+		},
+		{
+			"short SLI window",
+			[]sparkgap.BreakerSnapshot{{Name: "db", RetryInterval: time.Minute, SLI: []sparkgap.SLI{{Window: time.Second}}, TotalCalls: 1}},
+			[]string{"window-shorter-than-retry"},
+		},
+		{"zero traffic", []sparkgap.BreakerSnapshot{{Name: "db"}}, []string{"zero-traffic"}},
+		{"rejections are traffic", []sparkgap.BreakerSnapshot{{Name: "db", Rejections: map[sparkgap.ReasonCode]uint64{sparkgap.ReasonOpen: 1}}}, nil},
+		{
+			"duplicate",
+			[]sparkgap.BreakerSnapshot{healthy, {Name: "DB", TotalCalls: 1}, {Name: "d-b", TotalCalls: 1}},
+			[]string{"duplicate-dependency", "duplicate-dependency"},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			findings := admin.Diagnose(tc.snaps)
+			var got []string
+			for _, f := range findings {
+				got = append(got, f.Check)
+			}
+			if strings.Join(got, ",") != strings.Join(tc.want, ",") {
+				t.Fatalf("Diagnose found %v, want %v", findings, tc.want)
+			}
+		})
+	}
+}
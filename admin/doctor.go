@@ -0,0 +1,87 @@
+package admin
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/afk-ankit/sparkgap"
+)
+
+// Severity ranks how urgent a Finding is.
+type Severity string
+
+const (
+	SeverityInfo    Severity = "info"
+	SeverityWarning Severity = "warning"
+)
+
+// Finding is one problem reported by Diagnose.
+type Finding struct {
+	Breaker  string   `json:"breaker"`
+	Check    string   `json:"check"`
+	Severity Severity `json:"severity"`
+	Message  string   `json:"message"`
+}
+
+// practicallyNever is a failure threshold no real dependency reaches between two successes.
+const practicallyNever = 1_000_000
+
+/*
+Diagnose inspects breaker snapshots, typically fetched with Client.Snapshots, and reports
+likely misconfigurations:
+
+  - never-fires: thresholds that can practically never be reached
+  - window-shorter-than-retry: SLI windows shorter than the open period they should cover
+  - zero-traffic: breakers that have never seen a call
+  - duplicate-dependency: breakers whose names only differ in case or punctuation
+*/
+func Diagnose(snaps []sparkgap.BreakerSnapshot) []Finding {
+	var findings []Finding
+	add := func(name, check string, sev Severity, format string, args ...any) {
+		findings = append(findings, Finding{Breaker: name, Check: check, Severity: sev, Message: fmt.Sprintf(format, args...)})
+	}
+
+	seen := make(map[string]string)
+	for _, s := range snaps {
+		if s.FailureThreshold >= practicallyNever {
+			add(s.Name, "never-fires", SeverityWarning,
+				"failure threshold %d requires that many consecutive failures and will practically never trip", s.FailureThreshold)
+		}
+		if s.HalfOpenMode == sparkgap.HalfOpenPercentage && s.HalfOpenMaxFailurePercent >= 100 {
+			add(s.Name, "never-fires", SeverityWarning,
+				"half-open max failure %d%% only reopens when every one of %d probes fails", s.HalfOpenMaxFailurePercent, s.HalfOpenMaxProbes)
+		}
+		for _, sli := range s.SLI {
+			if sli.Window < s.RetryInterval {
+				add(s.Name, "window-shorter-than-retry", SeverityWarning,
+					"SLI window %s is shorter than the retry interval %s, so a single open period can fill it", sli.Window, s.RetryInterval)
+			}
+		}
+		var rejected uint64
+		for _, n := range s.Rejections {
+			rejected += n
+		}
+		if s.TotalCalls == 0 && rejected == 0 {
+			add(s.Name, "zero-traffic", SeverityInfo, "breaker has not seen any calls")
+		}
+
+		key := normalizeName(s.Name)
+		if other, ok := seen[key]; ok {
+			add(s.Name, "duplicate-dependency", SeverityWarning,
+				"breaker looks like a duplicate of %q; both accumulate state for the same dependency separately", other)
+		} else {
+			seen[key] = s.Name
+		}
+	}
+	return findings
+}
+
+func normalizeName(name string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return unicode.ToLower(r)
+		}
+		return -1
+	}, name)
+}
@@ -0,0 +1,333 @@
+package admin
+
+import (
+	"encoding/json"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/afk-ankit/sparkgap"
+)
+
+const (
+	defaultStatsInterval = 10 * time.Second
+	defaultStatsBuckets  = 360
+)
+
+// The series a StatsRecorder keeps for every breaker.
+const (
+	SeriesCalls       = "calls"
+	SeriesFailures    = "failures"
+	SeriesRejections  = "rejections"
+	SeriesTransitions = "transitions"
+)
+
+var seriesNames = []string{SeriesCalls, SeriesFailures, SeriesRejections, SeriesTransitions}
+
+// StatsBucket holds what happened to one breaker during one interval.
+type StatsBucket struct {
+	Start       time.Time `json:"start"`
+	Calls       uint64    `json:"calls"`
+	Failures    uint64    `json:"failures"`
+	Rejections  uint64    `json:"rejections"`
+	Transitions uint64    `json:"transitions"`
+}
+
+func (b *StatsBucket) value(series string) uint64 {
+	switch series {
+	case SeriesCalls:
+		return b.Calls
+	case SeriesFailures:
+		return b.Failures
+	case SeriesRejections:
+		return b.Rejections
+	default:
+		return b.Transitions
+	}
+}
+
+// totals is the running counters of a breaker when it was last sampled.
+type totals struct {
+	calls, failures, rejections uint64
+}
+
+type breakerSeries struct {
+	last    totals
+	buckets []StatsBucket // oldest first
+}
+
+/*
+StatsRecorder samples the breakers of a registry every interval into time buckets of calls,
+failures, rejections and state transitions, which GrafanaHandler serves to Grafana. Close
+stops it.
+*/
+type StatsRecorder struct {
+	reg      *sparkgap.Registry
+	interval time.Duration
+	keep     int
+
+	mu          sync.Mutex
+	series      map[string]*breakerSeries
+	transitions map[string]uint64
+	changes     []sparkgap.Event
+
+	unsubscribe func()
+	stop        chan struct{}
+	done        chan struct{}
+}
+
+/*
+NewStatsRecorder starts recording reg every interval (default 10s), keeping the last buckets
+buckets (default 360, an hour at the default interval) per breaker.
+*/
+func NewStatsRecorder(reg *sparkgap.Registry, interval time.Duration, buckets int) *StatsRecorder {
+	if interval <= 0 {
+		interval = defaultStatsInterval
+	}
+	if buckets <= 0 {
+		buckets = defaultStatsBuckets
+	}
+	r := &StatsRecorder{
+		reg:         reg,
+		interval:    interval,
+		keep:        buckets,
+		series:      make(map[string]*breakerSeries),
+		transitions: make(map[string]uint64),
+		stop:        make(chan struct{}),
+		done:        make(chan struct{}),
+	}
+	r.unsubscribe = reg.Subscribe(func(ev sparkgap.Event) {
+		if ev.Kind != sparkgap.EventStateChange {
+			return
+		}
+		r.mu.Lock()
+		r.transitions[ev.Breaker]++
+		r.changes = append(r.changes, ev)
+		if len(r.changes) > r.keep {
+			r.changes = slices.Delete(r.changes, 0, len(r.changes)-r.keep)
+		}
+		r.mu.Unlock()
+	})
+	r.sample(time.Now(), true)
+	go r.run()
+	return r
+}
+
+// Close stops recording; the buckets recorded so far are still served.
+func (r *StatsRecorder) Close() {
+	select {
+	case <-r.stop:
+		return
+	default:
+	}
+	close(r.stop)
+	<-r.done
+	r.unsubscribe()
+}
+
+func (r *StatsRecorder) run() {
+	defer close(r.done)
+	tick := time.NewTicker(r.interval)
+	defer tick.Stop()
+	for {
+		select {
+		case <-r.stop:
+			return
+		case now := <-tick.C:
+			r.sample(now, false)
+		}
+	}
+}
+
+// sample closes the current interval; the first sample only sets the baseline.
+func (r *StatsRecorder) sample(now time.Time, baseline bool) {
+	snaps := r.reg.Snapshots()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	start := now.Add(-r.interval)
+	for _, s := range snaps {
+		cur := totals{calls: s.TotalCalls, failures: s.TotalFailures}
+		for _, n := range s.Rejections {
+			cur.rejections += n
+		}
+		bs, ok := r.series[s.Name]
+		if !ok {
+			bs = &breakerSeries{}
+			r.series[s.Name] = bs
+		} else if !baseline {
+			bs.buckets = append(bs.buckets, StatsBucket{
+				Start:       start,
+				Calls:       delta(cur.calls, bs.last.calls),
+				Failures:    delta(cur.failures, bs.last.failures),
+				Rejections:  delta(cur.rejections, bs.last.rejections),
+				Transitions: r.transitions[s.Name],
+			})
+			if len(bs.buckets) > r.keep {
+				bs.buckets = slices.Delete(bs.buckets, 0, len(bs.buckets)-r.keep)
+			}
+		}
+		bs.last = cur
+		delete(r.transitions, s.Name)
+	}
+}
+
+// delta tolerates a breaker that was replaced by a new one with fresh counters.
+func delta(cur, prev uint64) uint64 {
+	if cur < prev {
+		return cur
+	}
+	return cur - prev
+}
+
+// Buckets returns the recorded buckets of the breaker called name, oldest first.
+func (r *StatsRecorder) Buckets(name string) []StatsBucket {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if bs, ok := r.series[name]; ok {
+		return slices.Clone(bs.buckets)
+	}
+	return nil
+}
+
+/*
+GrafanaHandler serves the recorded stats in the format of Grafana's JSON datasource
+(SimpleJSON), so teams without Prometheus can chart their breakers. Point the datasource at
+wherever it is mounted. Routes:
+
+	GET  /             health check
+	POST /search       the available targets
+	POST /query        time series for the requested targets and range
+	POST /annotations  state changes in the requested range
+
+Targets are "<series>:<breaker>", e.g. "failures:payments", where series is calls, failures,
+rejections or transitions; a bare series sums it across every breaker.
+*/
+func (r *StatsRecorder) GrafanaHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /{$}", func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	mux.HandleFunc("POST /search", func(w http.ResponseWriter, _ *http.Request) {
+		writeJSON(w, http.StatusOK, r.targets())
+	})
+	mux.HandleFunc("POST /query", r.query)
+	mux.HandleFunc("POST /annotations", r.annotations)
+	return mux
+}
+
+func (r *StatsRecorder) targets() []string {
+	r.mu.Lock()
+	names := make([]string, 0, len(r.series))
+	for name := range r.series {
+		names = append(names, name)
+	}
+	r.mu.Unlock()
+	slices.Sort(names)
+	out := slices.Clone(seriesNames)
+	for _, series := range seriesNames {
+		for _, name := range names {
+			out = append(out, series+":"+name)
+		}
+	}
+	return out
+}
+
+type grafanaRange struct {
+	From time.Time `json:"from"`
+	To   time.Time `json:"to"`
+}
+
+func (g grafanaRange) contains(t time.Time) bool {
+	return (g.From.IsZero() || !t.Before(g.From)) && (g.To.IsZero() || !t.After(g.To))
+}
+
+type grafanaQuery struct {
+	Range   grafanaRange `json:"range"`
+	Targets []struct {
+		Target string `json:"target"`
+	} `json:"targets"`
+}
+
+type grafanaSeries struct {
+	Target string `json:"target"`
+	// Datapoints are [value, unix milliseconds] pairs.
+	Datapoints [][2]float64 `json:"datapoints"`
+}
+
+func (r *StatsRecorder) query(w http.ResponseWriter, req *http.Request) {
+	var q grafanaQuery
+	if err := json.NewDecoder(req.Body).Decode(&q); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid query: "+err.Error())
+		return
+	}
+	out := make([]grafanaSeries, 0, len(q.Targets))
+	r.mu.Lock()
+	for _, t := range q.Targets {
+		series, name, _ := strings.Cut(t.Target, ":")
+		if !slices.Contains(seriesNames, series) {
+			continue
+		}
+		out = append(out, grafanaSeries{Target: t.Target, Datapoints: r.datapointsLocked(series, name, q.Range)})
+	}
+	r.mu.Unlock()
+	writeJSON(w, http.StatusOK, out)
+}
+
+// datapointsLocked returns one series of the breaker called name, or summed over every breaker if name is empty.
+func (r *StatsRecorder) datapointsLocked(series, name string, rng grafanaRange) [][2]float64 {
+	sums := make(map[time.Time]uint64)
+	for n, bs := range r.series {
+		if name != "" && n != name {
+			continue
+		}
+		for i := range bs.buckets {
+			if b := &bs.buckets[i]; rng.contains(b.Start) {
+				sums[b.Start] += b.value(series)
+			}
+		}
+	}
+	times := make([]time.Time, 0, len(sums))
+	for t := range sums {
+		times = append(times, t)
+	}
+	slices.SortFunc(times, time.Time.Compare)
+	points := make([][2]float64, len(times))
+	for i, t := range times {
+		points[i] = [2]float64{float64(sums[t]), float64(t.UnixMilli())}
+	}
+	return points
+}
+
+type grafanaAnnotation struct {
+	Time  int64    `json:"time"`
+	Title string   `json:"title"`
+	Text  string   `json:"text"`
+	Tags  []string `json:"tags"`
+}
+
+func (r *StatsRecorder) annotations(w http.ResponseWriter, req *http.Request) {
+	var q struct {
+		Range grafanaRange `json:"range"`
+	}
+	if err := json.NewDecoder(req.Body).Decode(&q); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid query: "+err.Error())
+		return
+	}
+	r.mu.Lock()
+	out := make([]grafanaAnnotation, 0, len(r.changes))
+	for _, ev := range r.changes {
+		if !q.Range.contains(ev.Time) {
+			continue
+		}
+		out = append(out, grafanaAnnotation{
+			Time:  ev.Time.UnixMilli(),
+			Title: ev.Breaker + ": " + ev.From.String() + " → " + ev.To.String(),
+			Text:  string(ev.Cause),
+			Tags:  []string{ev.Breaker, ev.To.String()},
+		})
+	}
+	r.mu.Unlock()
+	writeJSON(w, http.StatusOK, out)
+}
@@ -0,0 +1,112 @@
+package admin_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/afk-ankit/sparkgap/admin"
+)
+
+// recordOnce returns a recorder that has sampled two calls, a trip and a rejection of the breaker called db.
+func recordOnce(t *testing.T) *admin.StatsRecorder {
+	t.Helper()
+	reg := newRegistry(t, "db", "cache")
+	r := admin.NewStatsRecorder(reg, 5*time.Millisecond, 10)
+	t.Cleanup(r.Close)
+	br, _ := reg.CircuitBreaker("db")
+	_ = br.Do(func() error { return nil })
+	_ = br.Do(func() error { return errors.New("down") })
+	br.Trip()
+	_ = br.Do(func() error { return nil })
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		var rejections uint64
+		for _, b := range r.Buckets("db") {
+			rejections += b.Rejections
+		}
+		if rejections > 0 {
+			return r
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("the rejection was never sampled")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestStatsRecorder(t *testing.T) {
+	r := recordOnce(t)
+	var sum admin.StatsBucket
+	for _, b := range r.Buckets("db") {
+		sum.Calls += b.Calls
+		sum.Failures += b.Failures
+		sum.Rejections += b.Rejections
+		sum.Transitions += b.Transitions
+	}
+	if sum.Calls != 2 || sum.Failures != 1 || sum.Rejections != 1 || sum.Transitions != 1 {
+		t.Fatalf("recorded %+v, want 2 calls, 1 failure, 1 rejection and 1 transition", sum)
+	}
+	if got := len(r.Buckets("cache")); got == 0 {
+		t.Fatal("no buckets for an idle breaker")
+	}
+	if r.Buckets("nope") != nil {
+		t.Fatal("buckets for an unknown breaker")
+	}
+}
+
+func TestGrafanaHandler(t *testing.T) {
+	r := recordOnce(t)
+	r.Close()
+	h := r.GrafanaHandler()
+	post := func(path, body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, path, strings.NewReader(body)))
+		return rec
+	}
+
+	var targets []string
+	if err := json.NewDecoder(post("/search", "{}").Body).Decode(&targets); err != nil {
+		t.Fatal(err)
+	}
+	if len(targets) != 12 || targets[0] != admin.SeriesCalls || targets[4] != "calls:cache" {
+		t.Fatalf("targets = %v, want the bare series then series:breaker", targets)
+	}
+
+	var series []struct {
+		Target     string
+		Datapoints [][2]float64
+	}
+	rec := post("/query", `{"targets":[{"target":"failures:db"},{"target":"calls"},{"target":"bogus"}]}`)
+	if err := json.NewDecoder(rec.Body).Decode(&series); err != nil {
+		t.Fatal(err)
+	}
+	if len(series) != 2 || series[0].Target != "failures:db" || series[1].Target != "calls" {
+		t.Fatalf("query = %+v, want failures:db and calls", series)
+	}
+	for _, s := range series {
+		var total float64
+		for _, p := range s.Datapoints {
+			total += p[0]
+		}
+		if want := map[string]float64{"failures:db": 1, "calls": 2}[s.Target]; total != want {
+			t.Errorf("%s sums to %v, want %v", s.Target, total, want)
+		}
+	}
+
+	var notes []struct{ Title string }
+	if err := json.NewDecoder(post("/annotations", `{}`).Body).Decode(&notes); err != nil {
+		t.Fatal(err)
+	}
+	if len(notes) != 1 || notes[0].Title != "db: Closed → Open" {
+		t.Fatalf("annotations = %+v, want the trip of db", notes)
+	}
+	if rec := post("/query", "not json"); rec.Code != http.StatusBadRequest {
+		t.Fatalf("invalid query = %d, want 400", rec.Code)
+	}
+}
@@ -0,0 +1,117 @@
+package admin
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/afk-ankit/sparkgap"
+)
+
+// HealthOption configures a HealthReporter.
+type HealthOption func(*HealthReporter)
+
+/*
+Critical makes the reporter unhealthy as soon as any of the named breakers is Open. Here and in
+MaxOpenPercent a breaker forced open counts as Open, and one forced closed or disabled does not.
+*/
+func Critical(names ...string) HealthOption {
+	return func(h *HealthReporter) {
+		for _, n := range names {
+			h.critical[n] = true
+		}
+	}
+}
+
+/*
+MaxOpenPercent makes the reporter unhealthy once more than percent of all registered breakers
+are Open, e.g. to fail readiness when most dependencies are unreachable. Zero disables it.
+*/
+func MaxOpenPercent(percent int) HealthOption {
+	return func(h *HealthReporter) { h.maxOpenPercent = percent }
+}
+
+/*
+HealthReporter aggregates the breakers of a registry into a single health signal for readiness
+probes. Without options it is unhealthy whenever any breaker is Open; with Critical only the
+named breakers count, and MaxOpenPercent adds a threshold over all breakers.
+*/
+type HealthReporter struct {
+	reg            *sparkgap.Registry
+	critical       map[string]bool
+	maxOpenPercent int
+}
+
+// NewHealthReporter returns a HealthReporter for reg.
+func NewHealthReporter(reg *sparkgap.Registry, opts ...HealthOption) *HealthReporter {
+	h := &HealthReporter{reg: reg, critical: make(map[string]bool)}
+	for _, opt := range opts {
+		opt(h)
+	}
+	return h
+}
+
+// HealthError reports why a HealthReporter is unhealthy.
+type HealthError struct {
+	// Open lists the breakers that are Open, ordered by name.
+	Open []string
+	// Reason says which policy failed.
+	Reason string
+}
+
+func (e *HealthError) Error() string {
+	return fmt.Sprintf("unhealthy: %s: %s", e.Reason, strings.Join(e.Open, ", "))
+}
+
+// Check returns nil if the registry is healthy under the reporter's policy and a *HealthError otherwise.
+func (h *HealthReporter) Check() error {
+	snaps := h.reg.Snapshots()
+	var open, criticalOpen []string
+	for _, s := range snaps {
+		if !blocking(s) {
+			continue
+		}
+		open = append(open, s.Name)
+		if h.critical[s.Name] {
+			criticalOpen = append(criticalOpen, s.Name)
+		}
+	}
+	switch {
+	case len(h.critical) == 0 && h.maxOpenPercent == 0 && len(open) > 0:
+		return &HealthError{Open: open, Reason: "breakers open"}
+	case len(criticalOpen) > 0:
+		return &HealthError{Open: criticalOpen, Reason: "critical breakers open"}
+	case h.maxOpenPercent > 0 && len(open)*100 > h.maxOpenPercent*len(snaps):
+		return &HealthError{Open: open, Reason: fmt.Sprintf("more than %d%% of breakers open", h.maxOpenPercent)}
+	}
+	return nil
+}
+
+/*
+ServeHTTP answers 200 with {"status":"ok"} while healthy and 503 with the status, reason and
+open breakers otherwise, for use as a Kubernetes readiness endpoint.
+*/
+func (h *HealthReporter) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	err := h.Check()
+	if err == nil {
+		writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+		return
+	}
+	he := err.(*HealthError)
+	writeJSON(w, http.StatusServiceUnavailable, map[string]any{
+		"status": "unhealthy",
+		"reason": he.Reason,
+		"open":   he.Open,
+	})
+}
+
+// blocking reports whether s rejects calls: Open and not overridden, or forced open.
+func blocking(s sparkgap.BreakerSnapshot) bool {
+	switch s.Forced {
+	case sparkgap.ForcedOpen:
+		return true
+	case sparkgap.NotForced:
+		return s.State == sparkgap.StateOpen
+	}
+	return false
+}
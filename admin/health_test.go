@@ -0,0 +1,64 @@
+package admin_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"slices"
+	"testing"
+
+	"github.com/afk-ankit/sparkgap"
+	"github.com/afk-ankit/sparkgap/admin"
+)
+
+func TestHealthReporter(t *testing.T) {
+	cases := []struct {
+		name   string
+		opts   []admin.HealthOption
+		setup  func(a, b, c *sparkgap.CircuitBreaker)
+		reason string
+		open   []string
+	}{
+		{"all closed", nil, func(a, b, c *sparkgap.CircuitBreaker) {}, "", nil},
+		{"any open", nil, func(a, b, c *sparkgap.CircuitBreaker) { b.Trip() }, "breakers open", []string{"b"}},
+		{"forced open counts", nil, func(a, b, c *sparkgap.CircuitBreaker) { a.ForceOpen() }, "breakers open", []string{"a"}},
+		{"forced closed does not", nil, func(a, b, c *sparkgap.CircuitBreaker) { a.Trip(); a.ForceClosed() }, "", nil},
+		{"critical open", []admin.HealthOption{admin.Critical("a")}, func(a, b, c *sparkgap.CircuitBreaker) { a.Trip(); b.Trip() }, "critical breakers open", []string{"a"}},
+		{"non-critical open", []admin.HealthOption{admin.Critical("a")}, func(a, b, c *sparkgap.CircuitBreaker) { b.Trip() }, "", nil},
+		{"under MaxOpenPercent", []admin.HealthOption{admin.MaxOpenPercent(50)}, func(a, b, c *sparkgap.CircuitBreaker) { a.Trip() }, "", nil},
+		{"over MaxOpenPercent", []admin.HealthOption{admin.MaxOpenPercent(50)}, func(a, b, c *sparkgap.CircuitBreaker) { a.Trip(); c.Trip() }, "more than 50% of breakers open", []string{"a", "c"}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			reg := newRegistry(t, "a", "b", "c")
+			a, _ := reg.CircuitBreaker("a")
+			b, _ := reg.CircuitBreaker("b")
+			c, _ := reg.CircuitBreaker("c")
+			tc.setup(a, b, c)
+			h := admin.NewHealthReporter(reg, tc.opts...)
+
+			err := h.Check()
+			rec := serve(t, h, http.MethodGet, "/ready")
+			if tc.reason == "" {
+				if err != nil || rec.Code != http.StatusOK {
+					t.Fatalf("Check = %v, served %d; want healthy", err, rec.Code)
+				}
+				return
+			}
+			var he *admin.HealthError
+			if !errors.As(err, &he) || he.Reason != tc.reason || !slices.Equal(he.Open, tc.open) {
+				t.Fatalf("Check = %v, want %q with %v open", err, tc.reason, tc.open)
+			}
+			var body struct {
+				Status, Reason string
+				Open           []string
+			}
+			if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+				t.Fatal(err)
+			}
+			if rec.Code != http.StatusServiceUnavailable || body.Status != "unhealthy" || body.Reason != tc.reason || !slices.Equal(body.Open, tc.open) {
+				t.Fatalf("served %d %+v, want 503 with %q", rec.Code, body, tc.reason)
+			}
+		})
+	}
+}
@@ -0,0 +1,84 @@
+package admin
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/afk-ankit/sparkgap"
+)
+
+const (
+	defaultStreamInterval = time.Second
+	streamBuffer          = 64
+)
+
+/*
+stream serves Server-Sent Events: a "state" event for every state change, snooze reminder and
+saturation warning as it happens, and a "snapshot" event with the counters of every breaker
+each interval (default 1s, override with ?interval=500ms). Per-call events are not forwarded.
+*/
+func stream(reg *sparkgap.Registry) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		interval := defaultStreamInterval
+		if v := r.URL.Query().Get("interval"); v != "" {
+			d, err := time.ParseDuration(v)
+			if err != nil || d <= 0 {
+				writeError(w, http.StatusBadRequest, "invalid interval")
+				return
+			}
+			interval = d
+		}
+
+		rc := http.NewResponseController(w)
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Header().Set("Connection", "keep-alive")
+		w.WriteHeader(http.StatusOK)
+
+		events := make(chan sparkgap.Event, streamBuffer)
+		unsubscribe := reg.Subscribe(func(ev sparkgap.Event) {
+			switch ev.Kind {
+			case sparkgap.EventStateChange, sparkgap.EventSnoozeEnded, sparkgap.EventSaturation:
+				select {
+				case events <- ev:
+				default:
+				}
+			}
+		})
+		defer unsubscribe()
+
+		tick := time.NewTicker(interval)
+		defer tick.Stop()
+
+		send := func(name string, v any) bool {
+			data, err := json.Marshal(v)
+			if err != nil {
+				return false
+			}
+			if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", name, data); err != nil {
+				return false
+			}
+			return rc.Flush() == nil
+		}
+
+		if !send("snapshot", reg.Snapshots()) {
+			return
+		}
+		for {
+			select {
+			case <-r.Context().Done():
+				return
+			case ev := <-events:
+				if !send("state", ev) {
+					return
+				}
+			case <-tick.C:
+				if !send("snapshot", reg.Snapshots()) {
+					return
+				}
+			}
+		}
+	}
+}
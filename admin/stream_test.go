@@ -0,0 +1,69 @@
+package admin_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/afk-ankit/sparkgap"
+	"github.com/afk-ankit/sparkgap/admin"
+)
+
+var errStop = errors.New("stop")
+
+func TestStream(t *testing.T) {
+	reg := newRegistry(t, "db")
+	srv := httptest.NewServer(admin.Handler(reg))
+	defer srv.Close()
+	br, _ := reg.CircuitBreaker("db")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	var got []admin.StreamEvent
+	err := (&admin.Client{BaseURL: srv.URL}).Stream(ctx, time.Hour, func(ev admin.StreamEvent) error {
+		got = append(got, ev)
+		if ev.Snapshots != nil {
+			br.Trip() // the first event is a snapshot sent before any change
+			return nil
+		}
+		return errStop
+	})
+	if !errors.Is(err, errStop) {
+		t.Fatalf("Stream = %v, want the callback's error", err)
+	}
+	if len(got) != 2 || len(got[0].Snapshots) != 1 || got[1].Change == nil {
+		t.Fatalf("events = %+v, want a snapshot then a change", got)
+	}
+	if c := got[1].Change; c.Kind != "StateChange" || c.Breaker != "db" || c.From != sparkgap.StateClosed || c.To != sparkgap.StateOpen {
+		t.Fatalf("change = %+v, want db going from Closed to Open", c)
+	}
+}
+
+func TestStreamRoutes(t *testing.T) {
+	h := admin.Handler(newRegistry(t, "db"))
+	cases := []struct {
+		path   string
+		status int
+	}{
+		{"/breakers/stream", http.StatusOK},
+		{"/events/stream", http.StatusOK},
+		{"/breakers/stream?interval=soon", http.StatusBadRequest},
+		{"/events/stream?interval=-1s", http.StatusBadRequest},
+	}
+	for _, tc := range cases {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel() // the handler returns after its first snapshot
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tc.path, nil).WithContext(ctx))
+		if rec.Code != tc.status {
+			t.Errorf("GET %s = %d, want %d", tc.path, rec.Code, tc.status)
+			continue
+		}
+		if ct := rec.Header().Get("Content-Type"); tc.status == http.StatusOK && ct != "text/event-stream" {
+			t.Errorf("GET %s served %q, want text/event-stream", tc.path, ct)
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package sparkgap_test
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/afk-ankit/sparkgap"
+)
+
+// TestSuccessPathAllocs keeps successful calls free of heap allocations. Timeout, ContextInfo
+// and interceptors allocate by design and are left unset.
+func TestSuccessPathAllocs(t *testing.T) {
+	cb, err := sparkgap.NewCircuitBreaker("allocs", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cb.Close()
+	typed := sparkgap.Typed[int](cb)
+	ctx := context.Background()
+	n := 0
+
+	cases := []struct {
+		name string
+		fn   func()
+	}{
+		{"Do", func() { _ = cb.Do(func() error { return nil }) }},
+		{"DoCapturing", func() { _ = cb.Do(func() error { n++; return nil }) }},
+		{"DoContext", func() { _ = cb.DoContext(ctx, func(context.Context) error { return nil }) }},
+		{"Execute", func() { _, _ = typed.Execute(func() (int, error) { return 1, nil }) }},
+		{"ExecuteContext", func() { _, _ = typed.ExecuteContext(ctx, func(context.Context) (int, error) { return 1, nil }) }},
+	}
+	for _, tc := range cases {
+		if allocs := testing.AllocsPerRun(100, tc.fn); allocs != 0 {
+			t.Errorf("%s: %v allocs per call, want 0", tc.name, allocs)
+		}
+	}
+	if st := cb.State(); st != sparkgap.StateClosed {
+		t.Fatalf("state = %s, want Closed", st)
+	}
+}
+
+func TestHalfOpenProbeAllocs(t *testing.T) {
+	cb, err := sparkgap.NewCircuitBreaker("allocs", &sparkgap.BreakerConfig{
+		RetryInterval:    time.Hour,
+		HalfOpenMode:     sparkgap.HalfOpenConsecutive,
+		SuccessThreshold: math.MaxUint32,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cb.Close()
+	cb.Trip()
+	cb.ProbeNow()
+	allocs := testing.AllocsPerRun(100, func() { _ = cb.Do(func() error { return nil }) })
+	if allocs != 0 {
+		t.Errorf("%v allocs per probe, want 0", allocs)
+	}
+	if st := cb.State(); st != sparkgap.StateHalfOpen {
+		t.Fatalf("state = %s, want Half-Open", st)
+	}
+}
@@ -0,0 +1,20 @@
+module github.com/afk-ankit/sparkgap/awssdk
+
+go 1.24.4
+
+require (
+	github.com/afk-ankit/sparkgap v0.0.0
+	github.com/aws/aws-sdk-go-v2 v1.47.1
+	github.com/aws/smithy-go v1.28.2
+)
+
+require (
+	github.com/jedib0t/go-pretty/v6 v6.6.8 // indirect
+	github.com/mattn/go-runewidth v0.0.16 // indirect
+	github.com/rivo/uniseg v0.4.7 // indirect
+	golang.org/x/sys v0.30.0 // indirect
+	golang.org/x/text v0.22.0 // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
+)
+
+replace github.com/afk-ankit/sparkgap => ../
@@ -0,0 +1,152 @@
+/*
+Package awssdk routes aws-sdk-go-v2 API operations through sparkgap breakers, one per service,
+so an AWS outage or a throttling storm fails fast instead of stacking up retries:
+
+	cfg.APIOptions = append(cfg.APIOptions, awssdk.Middleware(func(service string) *sparkgap.CircuitBreaker {
+		return sparkgap.InitBreaker[any]("aws."+service, nil).CircuitBreaker
+	}))
+
+The breaker sees each operation once, after the SDK's own retries, and rejections are returned
+as the operation's error. The breaker's Timeout is not applied; bound operations through their
+context instead.
+*/
+package awssdk
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsmiddleware "github.com/aws/aws-sdk-go-v2/aws/middleware"
+	"github.com/aws/aws-sdk-go-v2/aws/retry"
+	"github.com/aws/smithy-go"
+	"github.com/aws/smithy-go/middleware"
+	smithyhttp "github.com/aws/smithy-go/transport/http"
+
+	"github.com/afk-ankit/sparkgap"
+)
+
+// Error categories for sparkgap.BreakerConfig.CategoryThresholds, as returned by Categorize.
+const (
+	CategoryThrottled   sparkgap.ErrorCategory = "throttled"
+	CategoryServerError sparkgap.ErrorCategory = "5xx"
+)
+
+// throttles recognises the throttling error codes the SDK's retryer backs off on.
+var throttles = retry.ThrottleErrorCode{Codes: retry.DefaultThrottleErrorCodes}
+
+// IsThrottle reports whether err is AWS throttling the caller, e.g. ThrottlingException.
+func IsThrottle(err error) bool {
+	return throttles.IsErrorThrottle(err) == aws.TrueTernary
+}
+
+/*
+IsFailure is the default classifier. Throttling, 5xx responses, server-fault API errors and
+transport errors count as failures; other API errors, such as NoSuchKey or AccessDenied, show
+the service is up and do not, nor do cancellations by the caller.
+*/
+func IsFailure(err error) bool {
+	switch {
+	case errors.Is(err, context.Canceled):
+		return false
+	case IsThrottle(err):
+		return true
+	}
+	var re *smithyhttp.ResponseError
+	if errors.As(err, &re) && re.HTTPStatusCode() >= 500 {
+		return true
+	}
+	var api smithy.APIError
+	if errors.As(err, &api) {
+		return api.ErrorFault() == smithy.FaultServer
+	}
+	return true
+}
+
+/*
+Categorize is a sparkgap.ErrorClassifier putting throttling and 5xx errors in their own
+categories, e.g. to trip on a handful of 5xx errors but tolerate a longer run of throttling.
+*/
+func Categorize(err error) sparkgap.ErrorCategory {
+	if IsThrottle(err) {
+		return CategoryThrottled
+	}
+	var re *smithyhttp.ResponseError
+	if errors.As(err, &re) && re.HTTPStatusCode() >= 500 {
+		return CategoryServerError
+	}
+	return ""
+}
+
+// Option configures Middleware.
+type Option func(*breakers)
+
+// WithClassifier replaces IsFailure as the classifier deciding which errors count against the breaker.
+func WithClassifier(c sparkgap.Classifier) Option {
+	return func(b *breakers) { b.isFailure = c }
+}
+
+/*
+Middleware returns an API option, for aws.Config.APIOptions or a service client's
+Options.APIOptions, running every operation through the breaker newBreaker returns for its
+service ID, such as "S3" or "DynamoDB". newBreaker is called once per service; a nil breaker
+leaves that service unprotected.
+*/
+func Middleware(newBreaker func(service string) *sparkgap.CircuitBreaker, opts ...Option) func(*middleware.Stack) error {
+	b := &breakers{newBreaker: newBreaker, isFailure: IsFailure}
+	for _, opt := range opts {
+		opt(b)
+	}
+	return func(stack *middleware.Stack) error {
+		return stack.Initialize.Add(middleware.InitializeMiddlewareFunc("SparkgapBreaker", b.handle), middleware.After)
+	}
+}
+
+type breakers struct {
+	newBreaker func(service string) *sparkgap.CircuitBreaker
+	isFailure  sparkgap.Classifier
+
+	mu     sync.Mutex
+	byName map[string]*sparkgap.CircuitBreaker
+}
+
+func (b *breakers) get(service string) *sparkgap.CircuitBreaker {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	br, ok := b.byName[service]
+	if !ok {
+		br = b.newBreaker(service)
+		if b.byName == nil {
+			b.byName = make(map[string]*sparkgap.CircuitBreaker)
+		}
+		b.byName[service] = br
+	}
+	return br
+}
+
+// handle runs the rest of the stack through the service's breaker, hiding errors that are not failures from it.
+func (b *breakers) handle(ctx context.Context, in middleware.InitializeInput, next middleware.InitializeHandler) (middleware.InitializeOutput, middleware.Metadata, error) {
+	br := b.get(awsmiddleware.GetServiceID(ctx))
+	if br == nil {
+		return next.HandleInitialize(ctx, in)
+	}
+	var (
+		out     middleware.InitializeOutput
+		md      middleware.Metadata
+		callErr error
+	)
+	// The operation runs with ctx rather than the breaker's context, since cancelling that one
+	// on return would cut off streamed response bodies such as S3 objects.
+	err := br.DoContext(ctx, func(context.Context) error {
+		out, md, callErr = next.HandleInitialize(ctx, in)
+		if callErr != nil && !b.isFailure(callErr) {
+			return nil
+		}
+		return callErr
+	})
+	if callErr != nil {
+		return out, md, callErr
+	}
+	return out, md, err
+}
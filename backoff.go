@@ -0,0 +1,112 @@
+package sparkgap
+
+import (
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"time"
+)
+
+const (
+	defaultBackoffMultiplier = 2.0
+	defaultBackoffMax        = time.Minute
+)
+
+/*
+Backoff lengthens the open period on repeated trips. The n-th consecutive trip keeps the breaker
+open for Initial * Multiplier^(n-1), capped at Max, then randomized by ±Jitter of that value.
+The sequence restarts once the breaker closes again.
+*/
+type Backoff struct {
+	// Initial is the first open period. Zero uses the breaker's RetryInterval.
+	Initial time.Duration
+	// Multiplier scales the period on every further trip. Zero means 2.
+	Multiplier float64
+	// Max caps the period before jitter. Zero means one minute, or Initial if that is longer.
+	Max time.Duration
+	// Jitter is the fraction (0 to 1) by which each period is randomly shortened or lengthened.
+	Jitter float64
+}
+
+func (b *Backoff) applyDefaults(retryInterval time.Duration) {
+	if b.Initial <= 0 {
+		b.Initial = retryInterval
+	}
+	if b.Multiplier == 0 {
+		b.Multiplier = defaultBackoffMultiplier
+	}
+	if b.Max <= 0 {
+		b.Max = max(defaultBackoffMax, b.Initial)
+	}
+}
+
+// interval returns the open period for the given 1-based consecutive trip.
+func (b *Backoff) interval(trip uint32) time.Duration {
+	d := float64(b.Initial) * math.Pow(b.Multiplier, float64(trip-1))
+	d = min(d, float64(b.Max))
+	if b.Jitter > 0 {
+		d += d * b.Jitter * (2*rand.Float64() - 1)
+	}
+	return time.Duration(d)
+}
+
+/*
+Interval returns the period for the n-th (1-based) consecutive trip or retry, with defaults
+applied to zero Multiplier and Max. Retry helpers use it to share the breaker's backoff shape.
+*/
+func (b Backoff) Interval(n int) time.Duration {
+	b.applyDefaults(b.Initial)
+	return b.interval(uint32(max(n, 1)))
+}
+
+/*
+JitterMode randomizes the open period so that a fleet of instances tripped by the same outage
+does not probe the recovering dependency in lockstep.
+*/
+type JitterMode int
+
+const (
+	// JitterNone keeps the open period as computed from RetryInterval and RetryBackoff.
+	JitterNone JitterMode = iota
+	// JitterFull waits a uniformly random period between zero and the computed one.
+	JitterFull
+	/*
+		JitterDecorrelated waits a random period between the base interval and three times the
+		previous open period, capped at RetryBackoff.Max (one minute without a backoff). It grows
+		on its own, replacing the RetryBackoff multiplier, and restarts once the breaker closes.
+	*/
+	JitterDecorrelated
+)
+
+func (m JitterMode) String() string {
+	switch m {
+	case JitterNone:
+		return "none"
+	case JitterFull:
+		return "full"
+	case JitterDecorrelated:
+		return "decorrelated"
+	default:
+		return fmt.Sprintf("JitterMode(%d)", int(m))
+	}
+}
+
+// MarshalText renders the mode by name.
+func (m JitterMode) MarshalText() ([]byte, error) {
+	return []byte(m.String()), nil
+}
+
+// UnmarshalText parses a name produced by MarshalText.
+func (m *JitterMode) UnmarshalText(text []byte) error {
+	switch string(text) {
+	case "none", "":
+		*m = JitterNone
+	case "full":
+		*m = JitterFull
+	case "decorrelated":
+		*m = JitterDecorrelated
+	default:
+		return fmt.Errorf("unknown jitter mode %q", text)
+	}
+	return nil
+}
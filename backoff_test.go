@@ -0,0 +1,113 @@
+package sparkgap_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/afk-ankit/sparkgap"
+	"github.com/afk-ankit/sparkgap/sparkgaptest"
+)
+
+// openPeriods trips br n times in a row, returning how long it stayed open each time.
+func openPeriods(br *sparkgap.CircuitBreaker, clock *sparkgaptest.FakeClock, n int) []time.Duration {
+	periods := make([]time.Duration, n)
+	for i := range periods {
+		br.Trip()
+		periods[i] = br.UntilHalfOpen()
+		clock.Advance(periods[i])
+	}
+	return periods
+}
+
+func TestBackoffIntervals(t *testing.T) {
+	cases := []struct {
+		name    string
+		backoff sparkgap.Backoff
+		want    []time.Duration
+	}{
+		{"doubling", sparkgap.Backoff{Initial: time.Second}, []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 8 * time.Second}},
+		{"capped", sparkgap.Backoff{Initial: time.Second, Multiplier: 3, Max: 5 * time.Second}, []time.Duration{time.Second, 3 * time.Second, 5 * time.Second, 5 * time.Second}},
+		{"initial from RetryInterval", sparkgap.Backoff{}, []time.Duration{2 * time.Second, 4 * time.Second, 8 * time.Second}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			backoff := tc.backoff
+			br, clock := newTestBreaker(t, sparkgap.BreakerConfig{RetryInterval: 2 * time.Second, RetryBackoff: &backoff})
+			got := openPeriods(br, clock, len(tc.want))
+			for i := range tc.want {
+				if got[i] != tc.want[i] {
+					t.Fatalf("open periods = %v, want %v", got, tc.want)
+				}
+			}
+		})
+	}
+}
+
+func TestBackoffRestartsAfterClose(t *testing.T) {
+	br, clock := newTestBreaker(t, sparkgap.BreakerConfig{RetryBackoff: &sparkgap.Backoff{Initial: time.Second}})
+	openPeriods(br, clock, 3)
+	br.Reset()
+	if got := openPeriods(br, clock, 1)[0]; got != time.Second {
+		t.Fatalf("open period after Reset = %s, want 1s", got)
+	}
+}
+
+func TestBackoffInterval(t *testing.T) {
+	cases := []struct {
+		backoff sparkgap.Backoff
+		n       int
+		want    time.Duration
+	}{
+		{sparkgap.Backoff{Initial: time.Second}, 0, time.Second},
+		{sparkgap.Backoff{Initial: time.Second}, 1, time.Second},
+		{sparkgap.Backoff{Initial: time.Second}, 4, 8 * time.Second},
+		{sparkgap.Backoff{Initial: time.Second}, 20, time.Minute},
+		{sparkgap.Backoff{Initial: 2 * time.Minute}, 3, 2 * time.Minute},
+		{sparkgap.Backoff{Initial: time.Second, Multiplier: 1.5, Max: 2 * time.Second}, 2, 1500 * time.Millisecond},
+	}
+	for _, tc := range cases {
+		if got := tc.backoff.Interval(tc.n); got != tc.want {
+			t.Errorf("%+v.Interval(%d) = %s, want %s", tc.backoff, tc.n, got, tc.want)
+		}
+	}
+}
+
+func TestJitterBounds(t *testing.T) {
+	const trips = 50
+	cases := []struct {
+		name string
+		cfg  sparkgap.BreakerConfig
+		// bounds returns the allowed open period for a trip, given the previous one.
+		bounds func(prev time.Duration) (lo, hi time.Duration)
+	}{
+		{
+			name:   "backoff jitter",
+			cfg:    sparkgap.BreakerConfig{RetryBackoff: &sparkgap.Backoff{Initial: 10 * time.Second, Multiplier: 1, Jitter: 0.2}},
+			bounds: func(time.Duration) (time.Duration, time.Duration) { return 8 * time.Second, 12 * time.Second },
+		},
+		{
+			name:   "full",
+			cfg:    sparkgap.BreakerConfig{RetryInterval: 10 * time.Second, RetryJitter: sparkgap.JitterFull},
+			bounds: func(time.Duration) (time.Duration, time.Duration) { return 0, 10 * time.Second },
+		},
+		{
+			name: "decorrelated",
+			cfg:  sparkgap.BreakerConfig{RetryInterval: time.Second, RetryJitter: sparkgap.JitterDecorrelated},
+			bounds: func(prev time.Duration) (time.Duration, time.Duration) {
+				return time.Second, min(time.Minute, 3*max(prev, time.Second))
+			},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			br, clock := newTestBreaker(t, tc.cfg)
+			var prev time.Duration
+			for i, d := range openPeriods(br, clock, trips) {
+				if lo, hi := tc.bounds(prev); d < lo || d > hi {
+					t.Fatalf("trip %d: open for %s, want between %s and %s", i+1, d, lo, hi)
+				}
+				prev = d
+			}
+		})
+	}
+}
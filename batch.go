@@ -0,0 +1,109 @@
+package sparkgap
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// BatchMode selects what ExecuteBatch does once the breaker starts rejecting calls.
+type BatchMode int
+
+const (
+	// BatchCollect attempts every call and collects the errors, including rejections.
+	BatchCollect BatchMode = iota
+	// BatchFailFast stops at the first rejection, e.g. when the breaker opens mid-batch, and
+	// fails the remaining calls with the same rejection without attempting them.
+	BatchFailFast
+)
+
+// BatchResult is the outcome of one call of a batch.
+type BatchResult[T any] struct {
+	Value T
+	Err   error
+}
+
+/*
+BatchError is returned by ExecuteBatch when not every call succeeded. It unwraps to the first
+error and the first rejection, so errors.Is(err, ErrOpen) reports whether the batch ran into an
+open breaker.
+*/
+type BatchError struct {
+	// Failed counts calls that returned an error, Rejected calls that were not attempted because
+	// the breaker rejected them, an earlier rejection stopped a BatchFailFast batch, or ctx was done.
+	Failed   int
+	Rejected int
+	// First is the first error of the batch, Rejection the first reason a call was not attempted.
+	First     error
+	Rejection error
+}
+
+func (e *BatchError) Error() string {
+	return fmt.Sprintf("batch: %d failed, %d rejected: %v", e.Failed, e.Rejected, e.First)
+}
+
+func (e *BatchError) Unwrap() []error {
+	if e.Rejection == nil || e.Rejection == e.First {
+		return []error{e.First}
+	}
+	return []error{e.First, e.Rejection}
+}
+
+/*
+ExecuteBatch runs fns in order through the breaker, one breaker call each, for bulk API clients.
+Results are returned in the order of fns, alongside a *BatchError unless every call succeeded.
+*/
+func (br *Breaker[T]) ExecuteBatch(fns []func() (T, error), mode BatchMode) ([]BatchResult[T], error) {
+	ctxFns := make([]func(context.Context) (T, error), len(fns))
+	for i, fn := range fns {
+		ctxFns[i] = func(context.Context) (T, error) { return fn() }
+	}
+	return br.ExecuteBatchContext(context.Background(), ctxFns, mode)
+}
+
+// ExecuteBatchContext is like ExecuteBatch but passes ctx to every call and stops once ctx is done.
+func (br *Breaker[T]) ExecuteBatchContext(ctx context.Context, fns []func(ctx context.Context) (T, error), mode BatchMode) ([]BatchResult[T], error) {
+	results := make([]BatchResult[T], len(fns))
+	var be BatchError
+	var stop error
+	for i, fn := range fns {
+		if stop == nil {
+			stop = ctx.Err()
+		}
+		if stop != nil {
+			results[i].Err = stop
+			be.Rejected++
+			if be.Rejection == nil {
+				be.Rejection = stop
+			}
+			continue
+		}
+		v, err := br.ExecuteContext(ctx, fn)
+		results[i] = BatchResult[T]{Value: v, Err: err}
+		if err == nil {
+			continue
+		}
+		if be.First == nil {
+			be.First = err
+		}
+		var rej *RejectionError
+		if !errors.As(err, &rej) {
+			be.Failed++
+			continue
+		}
+		be.Rejected++
+		if be.Rejection == nil {
+			be.Rejection = err
+		}
+		if mode == BatchFailFast {
+			stop = err
+		}
+	}
+	if be.First == nil {
+		be.First = be.Rejection
+	}
+	if be.First == nil {
+		return results, nil
+	}
+	return results, &be
+}
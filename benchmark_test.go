@@ -0,0 +1,92 @@
+package sparkgap_test
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/afk-ankit/sparkgap"
+)
+
+func newBenchBreaker(b *testing.B, cfg *sparkgap.BreakerConfig) *sparkgap.CircuitBreaker {
+	b.Helper()
+	br, err := sparkgap.NewCircuitBreaker(b.Name(), cfg)
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.Cleanup(func() { _ = br.Close() })
+	return br
+}
+
+// halfOpenBreaker returns a breaker that stays Half-Open however many probes succeed.
+func halfOpenBreaker(b *testing.B) *sparkgap.CircuitBreaker {
+	br := newBenchBreaker(b, &sparkgap.BreakerConfig{
+		RetryInterval:    time.Hour,
+		HalfOpenMode:     sparkgap.HalfOpenConsecutive,
+		SuccessThreshold: math.MaxUint32,
+	})
+	br.Trip()
+	br.ProbeNow()
+	return br
+}
+
+func ok() error { return nil }
+
+func BenchmarkDoClosed(b *testing.B) {
+	br := newBenchBreaker(b, nil)
+	b.ReportAllocs()
+	for b.Loop() {
+		_ = br.Do(ok)
+	}
+}
+
+func BenchmarkDoClosedParallel(b *testing.B) {
+	br := newBenchBreaker(b, nil)
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			_ = br.Do(ok)
+		}
+	})
+}
+
+func BenchmarkExecuteClosed(b *testing.B) {
+	br := sparkgap.Typed[int](newBenchBreaker(b, nil))
+	fn := func() (int, error) { return 1, nil }
+	b.ReportAllocs()
+	for b.Loop() {
+		_, _ = br.Execute(fn)
+	}
+}
+
+func BenchmarkDoOpen(b *testing.B) {
+	br := newBenchBreaker(b, &sparkgap.BreakerConfig{RetryInterval: time.Hour})
+	br.Trip()
+	b.ReportAllocs()
+	for b.Loop() {
+		_ = br.Do(ok)
+	}
+}
+
+func BenchmarkDoHalfOpenParallel(b *testing.B) {
+	br := halfOpenBreaker(b)
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			_ = br.Do(ok)
+		}
+	})
+	if st := br.State(); st != sparkgap.StateHalfOpen {
+		b.Fatalf("state = %s, want Half-Open", st)
+	}
+}
+
+func BenchmarkStateParallel(b *testing.B) {
+	br := newBenchBreaker(b, nil)
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			_ = br.State()
+		}
+	})
+}
@@ -0,0 +1,144 @@
+package sparkgap
+
+import (
+	"math/rand/v2"
+	"sync"
+	"time"
+)
+
+const (
+	defaultBrownOutAdmitPercent uint32 = 50
+	defaultBrownOutWindow              = 10 * time.Second
+	defaultBrownOutMinCalls     uint32 = 10
+
+	brownOutBuckets = 10
+)
+
+// CauseFailureRate marks transitions into and out of Throttled driven by the failure rate.
+const CauseFailureRate Cause = "failure_rate"
+
+/*
+BrownOut adds a Throttled state between Closed and Open. Once at least FailurePercent of at
+least MinCalls calls over the trailing Window have failed, a Closed breaker starts admitting
+only AdmitPercent of calls, rejecting the rest with the throttled reason, and goes back to
+Closed once the failure rate falls below ExitPercent. Throttled counts consecutive failures
+like Closed, so a dependency that keeps failing still trips the breaker open.
+*/
+type BrownOut struct {
+	// FailurePercent is the failure rate, from 1 to 100, at which throttling starts.
+	FailurePercent uint32
+	// ExitPercent is the failure rate below which throttling stops. Zero means half of
+	// FailurePercent, and at least 1.
+	ExitPercent uint32
+	// AdmitPercent is the share of calls admitted while Throttled. Zero means 50.
+	AdmitPercent uint32
+	// Window is the trailing period the failure rate is measured over. Zero means ten seconds.
+	Window time.Duration
+	// MinCalls is how many calls the window needs before the rate is trusted. Zero means 10.
+	MinCalls uint32
+}
+
+func (b *BrownOut) applyDefaults() {
+	if b.ExitPercent == 0 {
+		b.ExitPercent = max(b.FailurePercent/2, 1)
+	}
+	if b.AdmitPercent == 0 {
+		b.AdmitPercent = defaultBrownOutAdmitPercent
+	}
+	if b.Window <= 0 {
+		b.Window = defaultBrownOutWindow
+	}
+	if b.MinCalls == 0 {
+		b.MinCalls = defaultBrownOutMinCalls
+	}
+}
+
+type brownOutBucket struct {
+	start           int64
+	calls, failures uint64
+}
+
+// brownOutState counts calls and failures per fixed-width time bucket across the window.
+type brownOutState struct {
+	BrownOut
+	resolution time.Duration
+
+	mu      sync.Mutex
+	buckets [brownOutBuckets]brownOutBucket
+}
+
+func newBrownOutState(b *BrownOut) *brownOutState {
+	if b == nil {
+		return nil
+	}
+	return &brownOutState{
+		BrownOut:   *b,
+		resolution: max(b.Window/brownOutBuckets, time.Millisecond),
+	}
+}
+
+// record adds a call and returns the failure percentage over the window, or -1 below MinCalls.
+func (w *brownOutState) record(now time.Time, failed bool) int {
+	slot := now.UnixNano() / int64(w.resolution)
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	b := &w.buckets[slot%brownOutBuckets]
+	if b.start != slot {
+		*b = brownOutBucket{start: slot}
+	}
+	b.calls++
+	if failed {
+		b.failures++
+	}
+	var calls, failures uint64
+	for i := range w.buckets {
+		if b := &w.buckets[i]; b.start > slot-brownOutBuckets && b.start <= slot {
+			calls += b.calls
+			failures += b.failures
+		}
+	}
+	if calls < uint64(w.MinCalls) {
+		return -1
+	}
+	return int(failures * 100 / calls)
+}
+
+func (w *brownOutState) reset() {
+	if w == nil {
+		return
+	}
+	w.mu.Lock()
+	w.buckets = [brownOutBuckets]brownOutBucket{}
+	w.mu.Unlock()
+}
+
+// throttles reports whether a call arriving while Throttled should be turned away.
+func (w *brownOutState) throttles() bool {
+	return rand.Uint32N(100) >= w.AdmitPercent
+}
+
+/*
+observeBrownOut feeds the outcome of a call admitted in st to the brown-out window and moves
+the breaker between Closed and Throttled when the failure rate crosses a threshold.
+*/
+func (br *CircuitBreaker) observeBrownOut(st State, failed bool) {
+	rate := br.brownOut.record(br.clock.Now(), failed)
+	var to State
+	switch {
+	case rate < 0:
+		return
+	case st == StateClosed && rate >= int(br.brownOut.FailurePercent):
+		to = StateThrottled
+	case st == StateThrottled && rate < int(br.brownOut.ExitPercent):
+		to = StateClosed
+	default:
+		return
+	}
+	br.mu.Lock()
+	defer br.unlockAndNotify()
+	if br.state != st {
+		return
+	}
+	br.cause = CauseFailureRate
+	br.setStateLocked(to)
+}
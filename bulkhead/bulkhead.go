@@ -0,0 +1,185 @@
+/*
+Package bulkhead limits how many calls to a dependency may be in flight at once, protecting both
+the caller's goroutine budget and the dependency. A Bulkhead can be used on its own or in front
+of a sparkgap breaker with Wrap.
+*/
+package bulkhead
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+
+	"github.com/afk-ankit/sparkgap"
+)
+
+const defaultMaxConcurrent = 10
+
+// Config configures a Bulkhead.
+type Config struct {
+	// MaxConcurrent is the number of calls allowed in flight at once. Zero means 10.
+	MaxConcurrent int
+	// MaxQueue is how many callers may wait for a slot once all are taken. Zero rejects them straight away.
+	MaxQueue int
+	// QueueTimeout bounds how long a queued caller waits for a slot. Zero waits until its context is done.
+	QueueTimeout time.Duration
+}
+
+/*
+Bulkhead is a concurrency limiter. Callers that find every slot taken wait in a bounded FIFO
+queue and are handed slots in arrival order; once the queue is full they are rejected with
+a *sparkgap.RejectionError wrapping sparkgap.ErrBulkheadFull.
+*/
+type Bulkhead struct {
+	name         string
+	limit        int
+	maxQueue     int
+	queueTimeout time.Duration
+
+	mu       sync.Mutex
+	inFlight int
+	queue    list.List // of chan struct{}
+}
+
+// New returns a Bulkhead named name; the name is reported in rejections.
+func New(name string, cfg Config) *Bulkhead {
+	if name == "" {
+		name = "bulkhead"
+	}
+	if cfg.MaxConcurrent <= 0 {
+		cfg.MaxConcurrent = defaultMaxConcurrent
+	}
+	return &Bulkhead{name: name, limit: cfg.MaxConcurrent, maxQueue: max(cfg.MaxQueue, 0), queueTimeout: cfg.QueueTimeout}
+}
+
+// Name returns the bulkhead's name.
+func (b *Bulkhead) Name() string { return b.name }
+
+/*
+Acquire takes a slot, queueing for one if the bulkhead allows it. The caller must call release
+exactly once when its call has finished. A queued caller gives up when ctx is done or after
+QueueTimeout, returning a rejection wrapping sparkgap.ErrBulkheadFull for the timeout and
+ctx.Err() otherwise.
+*/
+func (b *Bulkhead) Acquire(ctx context.Context) (release func(), err error) {
+	b.mu.Lock()
+	if b.inFlight < b.limit && b.queue.Len() == 0 {
+		b.inFlight++
+		b.mu.Unlock()
+		return b.releaseFunc(), nil
+	}
+	if b.queue.Len() >= b.maxQueue {
+		b.mu.Unlock()
+		return nil, b.full()
+	}
+	ready := make(chan struct{})
+	elem := b.queue.PushBack(ready)
+	b.mu.Unlock()
+
+	var timeout <-chan time.Time
+	if b.queueTimeout > 0 {
+		t := time.NewTimer(b.queueTimeout)
+		defer t.Stop()
+		timeout = t.C
+	}
+	select {
+	case <-ready:
+		return b.releaseFunc(), nil
+	case <-ctx.Done():
+		err = ctx.Err()
+	case <-timeout:
+		err = b.full()
+	}
+	b.mu.Lock()
+	select {
+	case <-ready:
+		// The slot was handed over while we were giving up; pass it on.
+		b.mu.Unlock()
+		b.release()
+	default:
+		b.queue.Remove(elem)
+		b.mu.Unlock()
+	}
+	return nil, err
+}
+
+/*
+DoContext runs fn in a slot, returning a rejection without calling fn if none could be taken.
+It makes a Bulkhead a sparkgap.Policy.
+*/
+func (b *Bulkhead) DoContext(ctx context.Context, fn func(ctx context.Context) error) error {
+	release, err := b.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer release()
+	return fn(ctx)
+}
+
+// InFlight returns the number of slots currently taken.
+func (b *Bulkhead) InFlight() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.inFlight
+}
+
+// Queued returns the number of callers waiting for a slot.
+func (b *Bulkhead) Queued() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.queue.Len()
+}
+
+func (b *Bulkhead) full() error {
+	return &sparkgap.RejectionError{Breaker: b.name, Reason: sparkgap.ReasonBulkheadFull, Err: sparkgap.ErrBulkheadFull}
+}
+
+func (b *Bulkhead) releaseFunc() func() {
+	var once sync.Once
+	return func() { once.Do(b.release) }
+}
+
+// release frees a slot, handing it directly to the longest waiting caller if there is one.
+func (b *Bulkhead) release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if front := b.queue.Front(); front != nil {
+		b.queue.Remove(front)
+		close(front.Value.(chan struct{}))
+		return
+	}
+	b.inFlight--
+}
+
+/*
+Guarded is a breaker behind a bulkhead. Calls take a bulkhead slot before reaching the breaker,
+so callers shed by a full bulkhead never count against the dependency.
+*/
+type Guarded[T any] struct {
+	Bulkhead *Bulkhead
+	Breaker  *sparkgap.Breaker[T]
+}
+
+// Wrap puts br behind b.
+func Wrap[T any](b *Bulkhead, br *sparkgap.Breaker[T]) *Guarded[T] {
+	return &Guarded[T]{Bulkhead: b, Breaker: br}
+}
+
+// Execute is ExecuteContext with a background context.
+func (g *Guarded[T]) Execute(fn func() (T, error)) (T, error) {
+	return g.ExecuteContext(context.Background(), func(context.Context) (T, error) { return fn() })
+}
+
+// ExecuteContext takes a bulkhead slot, then runs fn through the breaker.
+func (g *Guarded[T]) ExecuteContext(ctx context.Context, fn func(ctx context.Context) (T, error)) (T, error) {
+	release, err := g.Bulkhead.Acquire(ctx)
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+	defer release()
+	return g.Breaker.ExecuteContext(ctx, fn)
+}
+
+var _ sparkgap.Policy = (*Bulkhead)(nil)
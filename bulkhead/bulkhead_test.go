@@ -0,0 +1,141 @@
+package bulkhead_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/afk-ankit/sparkgap"
+	"github.com/afk-ankit/sparkgap/bulkhead"
+)
+
+var errDown = errors.New("down")
+
+func TestRejectsWhenFull(t *testing.T) {
+	b := bulkhead.New("db", bulkhead.Config{MaxConcurrent: 2})
+	r1, err := b.Acquire(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	r2, _ := b.Acquire(context.Background())
+	_, err = b.Acquire(context.Background())
+	if code, _ := sparkgap.Reason(err); !errors.Is(err, sparkgap.ErrBulkheadFull) || code != sparkgap.ReasonBulkheadFull {
+		t.Fatalf("Acquire when full = %v, want a bulkhead_full rejection", err)
+	}
+	if n := b.InFlight(); n != 2 {
+		t.Fatalf("InFlight = %d, want 2", n)
+	}
+	r1()
+	r1() // releasing twice frees one slot only
+	r2()
+	if n := b.InFlight(); n != 0 {
+		t.Fatalf("InFlight after release = %d, want 0", n)
+	}
+}
+
+func TestQueueHandsSlotsInOrder(t *testing.T) {
+	b := bulkhead.New("db", bulkhead.Config{MaxConcurrent: 1, MaxQueue: 2})
+	release, _ := b.Acquire(context.Background())
+	order := make(chan int, 2)
+	for i := range 2 {
+		go func() {
+			r, err := b.Acquire(context.Background())
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			order <- i
+			r()
+		}()
+		for b.Queued() != i+1 {
+			time.Sleep(time.Millisecond)
+		}
+	}
+	if _, err := b.Acquire(context.Background()); !errors.Is(err, sparkgap.ErrBulkheadFull) {
+		t.Fatalf("Acquire with a full queue = %v, want ErrBulkheadFull", err)
+	}
+	release()
+	if first, second := <-order, <-order; first != 0 || second != 1 {
+		t.Fatalf("slots handed to %d then %d, want arrival order", first, second)
+	}
+}
+
+func TestQueueGivesUp(t *testing.T) {
+	cases := []struct {
+		name    string
+		timeout time.Duration
+		cancel  bool
+		want    error
+	}{
+		{"QueueTimeout", 5 * time.Millisecond, false, sparkgap.ErrBulkheadFull},
+		{"ctx done", 0, true, context.Canceled},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			b := bulkhead.New("db", bulkhead.Config{MaxConcurrent: 1, MaxQueue: 1, QueueTimeout: tc.timeout})
+			release, _ := b.Acquire(context.Background())
+			defer release()
+			ctx, cancel := context.WithCancel(context.Background())
+			if tc.cancel {
+				time.AfterFunc(5*time.Millisecond, cancel)
+			}
+			defer cancel()
+			if _, err := b.Acquire(ctx); !errors.Is(err, tc.want) {
+				t.Fatalf("queued Acquire = %v, want %v", err, tc.want)
+			}
+			if n := b.Queued(); n != 0 {
+				t.Fatalf("Queued = %d after giving up, want 0", n)
+			}
+		})
+	}
+}
+
+func TestGuarded(t *testing.T) {
+	br, err := sparkgap.NewBreaker[int]("db", &sparkgap.BreakerConfig{FailureThreshold: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer br.Close()
+	b := bulkhead.New("db", bulkhead.Config{MaxConcurrent: 1})
+	g := bulkhead.Wrap(b, br)
+
+	if v, err := g.Execute(func() (int, error) { return 1, nil }); v != 1 || err != nil {
+		t.Fatalf("Execute = %d, %v", v, err)
+	}
+
+	// Callers shed by the full bulkhead never reach the breaker.
+	release, _ := b.Acquire(context.Background())
+	for range 3 {
+		if _, err := g.Execute(func() (int, error) { return 0, errDown }); !errors.Is(err, sparkgap.ErrBulkheadFull) {
+			t.Fatalf("Execute with a full bulkhead = %v, want ErrBulkheadFull", err)
+		}
+	}
+	release()
+	if st := br.State(); st != sparkgap.StateClosed {
+		t.Fatalf("state after shed calls = %s, want Closed", st)
+	}
+
+	for range 2 {
+		_, _ = g.Execute(func() (int, error) { return 0, errDown })
+	}
+	if _, err := g.Execute(func() (int, error) { return 1, nil }); !errors.Is(err, sparkgap.ErrOpen) {
+		t.Fatalf("Execute after failures = %v, want ErrOpen", err)
+	}
+	if n := b.InFlight(); n != 0 {
+		t.Fatalf("InFlight = %d, want slots released after rejections", n)
+	}
+}
+
+func TestDoContextIsPolicy(t *testing.T) {
+	b := bulkhead.New("db", bulkhead.Config{MaxConcurrent: 1})
+	err := b.DoContext(context.Background(), func(context.Context) error {
+		if n := b.InFlight(); n != 1 {
+			t.Errorf("InFlight during the call = %d, want 1", n)
+		}
+		return errDown
+	})
+	if !errors.Is(err, errDown) || b.InFlight() != 0 {
+		t.Fatalf("DoContext = %v with %d in flight, want the call's error and the slot freed", err, b.InFlight())
+	}
+}
@@ -0,0 +1,44 @@
+package sparkgap
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+/*
+WithCache returns an Interceptor that remembers the last successful result and serves it in
+place of the outcome when the breaker rejects a call or the call fails, as judged by
+WithIsSuccessful if set, for read paths that can tolerate stale data. Results older than ttl, by br's Clock, are not served; a ttl of zero or
+less keeps them forever. The cache holds a single value, so install it on the Breaker[T]
+dedicated to one resource that it is created for:
+
+	br.Use(sparkgap.WithCache(br, time.Minute))
+*/
+func WithCache[T any](br *Breaker[T], ttl time.Duration) Interceptor[T] {
+	var (
+		mu     sync.Mutex
+		cached T
+		stored time.Time
+	)
+	return func(next CallFunc[T]) CallFunc[T] {
+		return func(ctx context.Context) (T, error) {
+			res, err := next(ctx)
+			mu.Lock()
+			defer mu.Unlock()
+			now := br.clock.Now()
+			ok := err == nil
+			if pred := br.successful(); pred != nil {
+				ok = pred(res, err)
+			}
+			if ok {
+				cached, stored = res, now
+				return res, err
+			}
+			if !stored.IsZero() && (ttl <= 0 || now.Sub(stored) < ttl) {
+				return cached, nil
+			}
+			return res, err
+		}
+	}
+}
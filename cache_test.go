@@ -0,0 +1,65 @@
+package sparkgap_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/afk-ankit/sparkgap"
+)
+
+type response struct {
+	status int
+	body   string
+}
+
+func TestWithCache(t *testing.T) {
+	cb, clock := newTestBreaker(t, sparkgap.BreakerConfig{FailureThreshold: 100})
+	br := sparkgap.Typed[response](cb).
+		WithIsSuccessful(func(r response, err error) bool { return err == nil && r.status < 500 })
+	br.Use(sparkgap.WithCache(br, time.Minute))
+
+	call := func(r response, err error) (response, error) {
+		return br.Execute(func() (response, error) { return r, err })
+	}
+	if _, err := call(response{503, "unavailable"}, nil); err != nil {
+		t.Fatalf("503 before anything was cached: %v", err)
+	}
+	if got, _ := call(response{200, "v1"}, nil); got.body != "v1" {
+		t.Fatalf("success returned %+v", got)
+	}
+
+	cases := []struct {
+		name    string
+		advance time.Duration
+		res     response
+		err     error
+		want    string
+		wantErr bool
+	}{
+		{"error serves the cached value", 0, response{}, errTest, "v1", false},
+		{"result judged failed serves the cached value", 0, response{503, "unavailable"}, nil, "v1", false},
+		{"expired value is not served", time.Minute, response{}, errTest, "", true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			clock.Advance(tc.advance)
+			got, err := call(tc.res, tc.err)
+			if got.body != tc.want || (err != nil) != tc.wantErr {
+				t.Fatalf("got %+v, %v; want body %q, error %t", got, err, tc.want, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestWithCacheServesWhileOpen(t *testing.T) {
+	cb, _ := newTestBreaker(t, sparkgap.BreakerConfig{})
+	br := sparkgap.Typed[string](cb)
+	br.Use(sparkgap.WithCache(br, 0))
+	_, _ = br.Execute(func() (string, error) { return "cached", nil })
+	cb.Trip()
+	got, err := br.Execute(func() (string, error) { return "", errors.New("not called") })
+	if got != "cached" || err != nil {
+		t.Fatalf("got %q, %v while open; want the cached value", got, err)
+	}
+}
@@ -0,0 +1,50 @@
+package sparkgap
+
+import (
+	"context"
+	"log/slog"
+)
+
+/*
+CallInfo describes the breaker governing a call. With ContextInfo set, the breaker adds it to the
+context passed to the protected function, so logging and tracing middleware further down can
+annotate requests with it.
+*/
+type CallInfo struct {
+	Breaker string
+	// State is the state the call was admitted in. Calls admitted under ForceClosed or Disable
+	// report Closed, with Forced saying why.
+	State  State
+	Forced ForcedMode
+}
+
+// LogValue renders the info as a group, e.g. slog.Any("circuit", info).
+func (ci CallInfo) LogValue() slog.Value {
+	attrs := []slog.Attr{
+		slog.String("breaker", ci.Breaker),
+		slog.String("state", ci.State.String()),
+	}
+	if ci.Forced != NotForced {
+		attrs = append(attrs, slog.String("forced", ci.Forced.String()))
+	}
+	return slog.GroupValue(attrs...)
+}
+
+type callInfoKey struct{}
+
+// ContextWithCallInfo returns a copy of ctx carrying ci.
+func ContextWithCallInfo(ctx context.Context, ci CallInfo) context.Context {
+	return context.WithValue(ctx, callInfoKey{}, ci)
+}
+
+// CallInfoFrom returns the CallInfo of the innermost breaker governing ctx, if any.
+func CallInfoFrom(ctx context.Context) (CallInfo, bool) {
+	ci, ok := ctx.Value(callInfoKey{}).(CallInfo)
+	return ci, ok
+}
+
+// BreakerName returns the name of the innermost breaker governing ctx, or "" if there is none.
+func BreakerName(ctx context.Context) string {
+	ci, _ := CallInfoFrom(ctx)
+	return ci.Breaker
+}
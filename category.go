@@ -0,0 +1,57 @@
+package sparkgap
+
+import "sync/atomic"
+
+// ErrorCategory names a class of errors, such as "timeout", "5xx" or "conn_refused".
+type ErrorCategory string
+
+/*
+ErrorClassifier maps a failed call's error to its category. Failures in a category listed in
+CategoryThresholds are counted and tripped on separately; the empty category and unlisted
+ones count against FailureThreshold as usual. err is nil for failures reported without an
+error, e.g. through Allow or a result-aware success predicate.
+*/
+type ErrorClassifier func(err error) ErrorCategory
+
+// categoryCounter counts consecutive Closed-state failures of one category.
+type categoryCounter struct {
+	threshold uint32
+	count     atomic.Uint32
+}
+
+func newCategoryCounters(thresholds map[ErrorCategory]uint32) map[ErrorCategory]*categoryCounter {
+	if len(thresholds) == 0 {
+		return nil
+	}
+	out := make(map[ErrorCategory]*categoryCounter, len(thresholds))
+	for cat, t := range thresholds {
+		out[cat] = &categoryCounter{threshold: t}
+	}
+	return out
+}
+
+// categoryFor returns the counter err is tracked by, or nil if it counts against FailureThreshold.
+func (br *CircuitBreaker) categoryFor(err error) *categoryCounter {
+	if br.categorize == nil || br.categories == nil {
+		return nil
+	}
+	return br.categories[br.categorize(err)]
+}
+
+// overThreshold reports whether the general counter or any category has reached its threshold.
+func (br *CircuitBreaker) overThreshold() bool {
+	v := br.readCounters()
+	if br.weigh != nil {
+		if br.weightReached(v.failureWeight) {
+			return true
+		}
+	} else if v.failureCount >= atomic.LoadUint32(&br.counter.failureThreshold) {
+		return true
+	}
+	for cat, n := range v.categories {
+		if n >= br.categories[cat].threshold {
+			return true
+		}
+	}
+	return false
+}
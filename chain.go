@@ -0,0 +1,85 @@
+package sparkgap
+
+import (
+	"context"
+	"time"
+)
+
+/*
+Policy is one layer of resilience around a call, such as a rate limiter, a bulkhead, a breaker
+or a timeout. DoContext runs fn, or returns an error without running it, and may give fn a
+derived context. *CircuitBreaker, and so every *Breaker[T], is a Policy that uses its IsFailure
+classifier; a Breaker[T]'s WithIsSuccessful predicate only applies to its own Execute.
+*/
+type Policy interface {
+	DoContext(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+// PolicyFunc adapts a function to a Policy.
+type PolicyFunc func(ctx context.Context, fn func(ctx context.Context) error) error
+
+func (f PolicyFunc) DoContext(ctx context.Context, fn func(ctx context.Context) error) error {
+	return f(ctx, fn)
+}
+
+/*
+Timeout returns a Policy running each call with a context cancelled after d, or as soon as the
+call returns. Results read lazily after the call, such as an *http.Response body or *sql.Rows,
+are cut off by then, so calls under Timeout must consume them before returning.
+*/
+func Timeout(d time.Duration) Policy {
+	return PolicyFunc(func(ctx context.Context, fn func(ctx context.Context) error) error {
+		ctx, cancel := context.WithTimeout(ctx, d)
+		defer cancel()
+		return fn(ctx)
+	})
+}
+
+/*
+Pipeline runs calls through a fixed sequence of policies. Create one with Chain.
+*/
+type Pipeline[T any] struct {
+	policies []Policy
+}
+
+/*
+Chain composes policies around a single call, outermost first, e.g.
+
+	p := sparkgap.Chain[[]byte](limiter, bulkhead, breaker, sparkgap.Timeout(time.Second))
+	body, err := p.ExecuteContext(ctx, func(ctx context.Context) ([]byte, error) {
+		return fetch(ctx, url) // reads the whole body before Timeout cancels ctx
+	})
+
+lets the limiter reject before a bulkhead slot is taken and only times out the call itself,
+so slow calls are seen by the breaker as context errors.
+*/
+func Chain[T any](policies ...Policy) *Pipeline[T] {
+	return &Pipeline[T]{policies: policies}
+}
+
+// Execute is ExecuteContext with a background context.
+func (p *Pipeline[T]) Execute(fn func() (T, error)) (T, error) {
+	return p.ExecuteContext(context.Background(), func(context.Context) (T, error) { return fn() })
+}
+
+// ExecuteContext runs fn through every policy of p and returns its result, or the error of the policy that refused it.
+func (p *Pipeline[T]) ExecuteContext(ctx context.Context, fn func(ctx context.Context) (T, error)) (T, error) {
+	var res T
+	err := p.DoContext(ctx, func(ctx context.Context) error {
+		var err error
+		res, err = fn(ctx)
+		return err
+	})
+	return res, err
+}
+
+// DoContext runs fn through every policy of p, so pipelines can be nested.
+func (p *Pipeline[T]) DoContext(ctx context.Context, fn func(ctx context.Context) error) error {
+	for i := len(p.policies) - 1; i >= 0; i-- {
+		next, pol := fn, p.policies[i]
+		fn = func(ctx context.Context) error { return pol.DoContext(ctx, next) }
+	}
+	return fn(ctx)
+}
+
+var _ Policy = (*CircuitBreaker)(nil)
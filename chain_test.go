@@ -0,0 +1,73 @@
+package sparkgap_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/afk-ankit/sparkgap"
+)
+
+// tracePolicy appends name to *order when a call passes through it.
+func tracePolicy(order *[]string, name string) sparkgap.Policy {
+	return sparkgap.PolicyFunc(func(ctx context.Context, fn func(ctx context.Context) error) error {
+		*order = append(*order, name)
+		return fn(ctx)
+	})
+}
+
+func TestChainOrder(t *testing.T) {
+	var order []string
+	inner := sparkgap.Chain[int](tracePolicy(&order, "b"), tracePolicy(&order, "c"))
+	p := sparkgap.Chain[int](tracePolicy(&order, "a"), inner)
+	v, err := p.Execute(func() (int, error) {
+		order = append(order, "fn")
+		return 7, nil
+	})
+	if v != 7 || err != nil {
+		t.Fatalf("Execute = %d, %v", v, err)
+	}
+	if got := len(order); got != 4 || order[0] != "a" || order[1] != "b" || order[2] != "c" || order[3] != "fn" {
+		t.Fatalf("order = %v, want [a b c fn]", order)
+	}
+}
+
+func TestChainOpenBreaker(t *testing.T) {
+	br, _ := newTestBreaker(t, sparkgap.BreakerConfig{FailureThreshold: 1})
+	var order []string
+	p := sparkgap.Chain[int](br, tracePolicy(&order, "inner"))
+	if _, err := p.Execute(func() (int, error) { return 0, errTest }); !errors.Is(err, errTest) {
+		t.Fatalf("Execute = %v, want the call's error", err)
+	}
+	if _, err := p.Execute(func() (int, error) { return 1, nil }); !errors.Is(err, sparkgap.ErrOpen) {
+		t.Fatalf("Execute while open = %v, want ErrOpen", err)
+	}
+	if len(order) != 1 {
+		t.Fatalf("inner policy ran %d times, want once: the open breaker must stop the call", len(order))
+	}
+}
+
+func TestChainTimeout(t *testing.T) {
+	br, _ := newTestBreaker(t, sparkgap.BreakerConfig{FailureThreshold: 1})
+	p := sparkgap.Chain[int](br, sparkgap.Timeout(5*time.Millisecond))
+	_, err := p.ExecuteContext(context.Background(), func(ctx context.Context) (int, error) {
+		<-ctx.Done()
+		return 0, ctx.Err()
+	})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("ExecuteContext = %v, want DeadlineExceeded", err)
+	}
+	if st := br.State(); st != sparkgap.StateOpen {
+		t.Fatalf("state = %s, want Open: a timed-out call is a failure", st)
+	}
+
+	var leaked context.Context
+	_ = sparkgap.Timeout(time.Hour).DoContext(context.Background(), func(ctx context.Context) error {
+		leaked = ctx
+		return nil
+	})
+	if leaked.Err() == nil {
+		t.Fatal("Timeout left the call's context live after it returned")
+	}
+}
@@ -0,0 +1,70 @@
+package sparkgap
+
+import (
+	"context"
+	"errors"
+)
+
+/*
+Classifier decides whether a non-nil error returned by a protected call counts as a failure of
+the dependency. Errors it rejects, such as validation or not-found errors, count as successes.
+*/
+type Classifier func(err error) bool
+
+/*
+As returns a Classifier matching errors whose chain contains an E, as found by errors.As.
+If match is non-nil it must also return true for the found error, e.g. to select specific
+driver error codes:
+
+	sparkgap.As(func(e *pq.Error) bool { return e.Code.Class() == "08" })
+*/
+func As[E error](match func(E) bool) Classifier {
+	return func(err error) bool {
+		var target E
+		if !errors.As(err, &target) {
+			return false
+		}
+		return match == nil || match(target)
+	}
+}
+
+// Is returns a Classifier matching errors whose chain contains any of targets, as found by errors.Is.
+func Is(targets ...error) Classifier {
+	return func(err error) bool {
+		for _, t := range targets {
+			if errors.Is(err, t) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// MatchAny returns a Classifier matching errors that any of cs matches.
+func MatchAny(cs ...Classifier) Classifier {
+	return func(err error) bool {
+		for _, c := range cs {
+			if c(err) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// Not inverts c, e.g. to count every error except the ones c matches.
+func Not(c Classifier) Classifier {
+	return func(err error) bool { return !c(err) }
+}
+
+// neutral reports whether err is a cancellation that counts neither way; see CountCanceled.
+func (br *CircuitBreaker) neutral(err error) bool {
+	return err != nil && !br.countCanceled && errors.Is(err, context.Canceled)
+}
+
+func (br *CircuitBreaker) isFailure(err error) bool {
+	if err == nil {
+		return false
+	}
+	return br.classify == nil || br.classify(err)
+}
@@ -0,0 +1,59 @@
+package sparkgap_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/afk-ankit/sparkgap"
+)
+
+func TestCanceledClassification(t *testing.T) {
+	cases := []struct {
+		name          string
+		err           error
+		countCanceled bool
+		wantFailures  uint32
+	}{
+		{"canceled is neutral", context.Canceled, false, 0},
+		{"wrapped canceled is neutral", fmt.Errorf("query: %w", context.Canceled), false, 0},
+		{"canceled counts with CountCanceled", context.Canceled, true, 2},
+		{"deadline exceeded counts", context.DeadlineExceeded, false, 2},
+		{"other errors count", errTest, false, 2},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			br, _ := newTestBreaker(t, sparkgap.BreakerConfig{FailureThreshold: 2, CountCanceled: tc.countCanceled})
+			for range 2 {
+				if err := br.Do(func() error { return tc.err }); !errors.Is(err, tc.err) {
+					t.Fatalf("Do = %v, want the call's error", err)
+				}
+			}
+			s := br.Snapshot()
+			if s.TotalFailures != uint64(tc.wantFailures) {
+				t.Errorf("TotalFailures = %d, want %d", s.TotalFailures, tc.wantFailures)
+			}
+			wantState := sparkgap.StateClosed
+			if tc.wantFailures >= 2 {
+				wantState = sparkgap.StateOpen
+			}
+			if s.State != wantState {
+				t.Errorf("state = %s, want %s", s.State, wantState)
+			}
+		})
+	}
+}
+
+func TestCanceledKeepsFailureStreak(t *testing.T) {
+	br, _ := newTestBreaker(t, sparkgap.BreakerConfig{FailureThreshold: 2})
+	_ = br.Do(fail)
+	_ = br.Do(func() error { return context.Canceled })
+	if got := br.Snapshot().FailureCount; got != 1 {
+		t.Fatalf("FailureCount after a cancellation = %d, want the streak of 1 kept", got)
+	}
+	_ = br.Do(fail)
+	if st := br.State(); st != sparkgap.StateOpen {
+		t.Fatalf("state = %s, want Open", st)
+	}
+}
@@ -0,0 +1,28 @@
+package sparkgap
+
+import "time"
+
+/*
+Clock abstracts time so breaker state transitions can be driven deterministically in tests.
+The zero BreakerConfig uses the wall clock; see the sparkgaptest package for a fake.
+*/
+type Clock interface {
+	Now() time.Time
+	After(d time.Duration) <-chan time.Time
+	// AfterFunc calls f in its own goroutine once d has elapsed, like time.AfterFunc.
+	AfterFunc(d time.Duration, f func()) Timer
+}
+
+// Timer is a pending AfterFunc call.
+type Timer interface {
+	Stop() bool
+	Reset(d time.Duration) bool
+}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time                         { return time.Now() }
+func (realClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+func (realClock) AfterFunc(d time.Duration, f func()) Timer {
+	return time.AfterFunc(d, f)
+}
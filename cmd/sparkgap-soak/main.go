@@ -0,0 +1,178 @@
+/*
+Command sparkgap-soak runs a synthetic workload against a circuit breaker for a long period
+and periodically reports memory/goroutine growth together with decision quality, i.e. how
+often the breaker let calls through to an unhealthy dependency or rejected calls while the
+dependency was healthy.
+
+Usage:
+
+	go run ./cmd/sparkgap-soak -profile flapping -duration 2h -rate 200
+*/
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"math/rand"
+	"os"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/afk-ankit/sparkgap"
+)
+
+var errDependency = errors.New("dependency failure")
+
+// profile decides whether the simulated dependency is healthy at a given offset into the run.
+type profile func(elapsed time.Duration) bool
+
+func steady(time.Duration) bool { return true }
+
+// bursty keeps the dependency healthy but injects a short outage every minute.
+func bursty(elapsed time.Duration) bool {
+	return elapsed%time.Minute >= 5*time.Second
+}
+
+// flapping alternates between healthy and unhealthy periods of equal length.
+func flapping(period time.Duration) profile {
+	return func(elapsed time.Duration) bool {
+		return (elapsed/period)%2 == 0
+	}
+}
+
+type stats struct {
+	calls       atomic.Uint64
+	successes   atomic.Uint64
+	failures    atomic.Uint64
+	rejected    atomic.Uint64
+	falseReject atomic.Uint64 // rejected while the dependency was healthy
+	leaked      atomic.Uint64 // let through while the dependency was unhealthy
+}
+
+func main() {
+	var (
+		profileName = flag.String("profile", "steady", "workload profile: steady, bursty or flapping")
+		duration    = flag.Duration("duration", time.Hour, "total run time")
+		rate        = flag.Int("rate", 100, "calls per second per worker")
+		workers     = flag.Int("workers", 4, "number of concurrent callers")
+		report      = flag.Duration("report", 30*time.Second, "report interval")
+		errRate     = flag.Float64("error-rate", 0.01, "background error rate while the dependency is healthy")
+		flapPeriod  = flag.Duration("flap-period", 30*time.Second, "half cycle length of the flapping profile")
+		threshold   = flag.Uint("failure-threshold", 0, "breaker failure threshold (0 = default)")
+		retry       = flag.Duration("retry-interval", 0, "breaker retry interval (0 = default)")
+		probes      = flag.Uint("half-open-probes", 0, "breaker half-open probes (0 = default)")
+		maxFailPct  = flag.Uint("half-open-max-failure-percent", 0, "breaker half-open max failure percent (0 = default)")
+	)
+	flag.Parse()
+
+	var healthy profile
+	switch *profileName {
+	case "steady":
+		healthy = steady
+	case "bursty":
+		healthy = bursty
+	case "flapping":
+		healthy = flapping(*flapPeriod)
+	default:
+		fmt.Fprintf(os.Stderr, "unknown profile %q\n", *profileName)
+		os.Exit(2)
+	}
+
+	br := sparkgap.InitBreaker[struct{}]("soak", &sparkgap.BreakerConfig{
+		FailureThreshold:          uint32(*threshold),
+		RetryInterval:             *retry,
+		HalfOpenMaxProbes:         uint32(*probes),
+		HalfOpenMaxFailurePercent: uint32(*maxFailPct),
+	})
+
+	var st stats
+	start := time.Now()
+	deadline := start.Add(*duration)
+
+	var wg sync.WaitGroup
+	for w := range *workers {
+		wg.Add(1)
+		go func(seed int64) {
+			defer wg.Done()
+			rng := rand.New(rand.NewSource(seed))
+			tick := time.NewTicker(time.Second / time.Duration(max(*rate, 1)))
+			defer tick.Stop()
+			for now := range tick.C {
+				if now.After(deadline) {
+					return
+				}
+				up := healthy(now.Sub(start))
+				called := false
+				_, err := br.Execute(func() (struct{}, error) {
+					called = true
+					if !up || rng.Float64() < *errRate {
+						return struct{}{}, errDependency
+					}
+					return struct{}{}, nil
+				})
+				st.calls.Add(1)
+				switch {
+				case !called:
+					st.rejected.Add(1)
+					if up {
+						st.falseReject.Add(1)
+					}
+				case err != nil:
+					st.failures.Add(1)
+				default:
+					st.successes.Add(1)
+				}
+				if called && !up {
+					st.leaked.Add(1)
+				}
+			}
+		}(start.UnixNano() + int64(w))
+	}
+
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	var base runtime.MemStats
+	runtime.ReadMemStats(&base)
+	baseGoroutines := runtime.NumGoroutine()
+
+	tick := time.NewTicker(*report)
+	defer tick.Stop()
+	for {
+		select {
+		case <-tick.C:
+			printReport(&st, time.Since(start), &base, baseGoroutines)
+		case <-done:
+			printReport(&st, time.Since(start), &base, baseGoroutines)
+			br.LogState()
+			return
+		}
+	}
+}
+
+func printReport(st *stats, elapsed time.Duration, base *runtime.MemStats, baseGoroutines int) {
+	var m runtime.MemStats
+	runtime.ReadMemStats(&m)
+
+	calls := st.calls.Load()
+	pct := func(n uint64) float64 {
+		if calls == 0 {
+			return 0
+		}
+		return float64(n) * 100 / float64(calls)
+	}
+
+	fmt.Printf("[%s] calls=%d ok=%d fail=%d rejected=%d false-reject=%.2f%% leaked=%.2f%% heap=%dKiB (%+dKiB) goroutines=%d (%+d)\n",
+		elapsed.Truncate(time.Second),
+		calls, st.successes.Load(), st.failures.Load(), st.rejected.Load(),
+		pct(st.falseReject.Load()), pct(st.leaked.Load()),
+		m.HeapAlloc/1024, (int64(m.HeapAlloc)-int64(base.HeapAlloc))/1024,
+		runtime.NumGoroutine(), runtime.NumGoroutine()-baseGoroutines,
+	)
+}
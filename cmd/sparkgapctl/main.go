@@ -0,0 +1,254 @@
+/*
+Command sparkgapctl inspects and controls the breakers of a running process through its admin
+HTTP API, the operational counterpart to the in-process tui dashboard.
+
+Usage:
+
+	sparkgapctl [-addr URL] <command> [arguments]
+
+Commands:
+
+	list              list every breaker with its state and counters; -label key:value filters
+	show <name>       show the snapshot of one breaker; -json prints it as JSON
+	tail              print state changes as they happen; -snapshots also prints counters
+	trip <name>       force a breaker open
+	reset <name>      force a breaker closed
+	probe <name>      move an open breaker to half-open now
+	force <name> <m>  pin a breaker: m is open, closed, disabled or none to unpin it
+	doctor            report likely breaker misconfigurations; exits 1 if any warnings are found
+*/
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+
+	"github.com/afk-ankit/sparkgap"
+	"github.com/afk-ankit/sparkgap/admin"
+)
+
+// errProblems makes the process exit with status 1 without printing anything further.
+var errProblems = errors.New("problems found")
+
+// errUsage makes the process print the usage and exit with status 2.
+var errUsage = errors.New("usage")
+
+func main() {
+	addr := flag.String("addr", "http://localhost:8080/admin", "base URL of the admin API")
+	timeout := flag.Duration("timeout", 10*time.Second, "request timeout, except for tail")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: sparkgapctl [-addr URL] <list|show|tail|trip|reset|probe|force|doctor> [arguments]\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	client := &admin.Client{BaseURL: *addr}
+	cmd, args := flag.Arg(0), flag.Args()[1:]
+	if cmd != "tail" {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, *timeout)
+		defer cancel()
+	}
+
+	var err error
+	switch cmd {
+	case "list":
+		err = list(ctx, client, args)
+	case "show":
+		err = show(ctx, client, args)
+	case "tail":
+		err = tail(ctx, client, args)
+	case "trip", "reset", "probe", "force":
+		err = control(ctx, client, cmd, args)
+	case "doctor":
+		err = doctor(ctx, client, args)
+	default:
+		fmt.Fprintf(os.Stderr, "sparkgapctl: unknown command %q\n", cmd)
+		err = errUsage
+	}
+	switch {
+	case err == nil:
+	case errors.Is(err, errUsage):
+		flag.Usage()
+		os.Exit(2)
+	case errors.Is(err, errProblems):
+		os.Exit(1)
+	case errors.Is(err, context.Canceled) && cmd == "tail":
+		// Interrupted by the user.
+	default:
+		fmt.Fprintf(os.Stderr, "sparkgapctl: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// labelFlag collects every -label key:value flag.
+type labelFlag []string
+
+func (f *labelFlag) String() string { return strings.Join(*f, ",") }
+
+func (f *labelFlag) Set(v string) error {
+	*f = append(*f, v)
+	return nil
+}
+
+// oneName parses a command taking a breaker name and no other arguments.
+func oneName(fs *flag.FlagSet, args []string) (string, error) {
+	_ = fs.Parse(args)
+	if fs.NArg() != 1 {
+		fmt.Fprintf(os.Stderr, "sparkgapctl: %s takes a breaker name\n", fs.Name())
+		return "", errUsage
+	}
+	return fs.Arg(0), nil
+}
+
+func list(ctx context.Context, client *admin.Client, args []string) error {
+	fs := flag.NewFlagSet("list", flag.ExitOnError)
+	asJSON := fs.Bool("json", false, "print snapshots as JSON")
+	var labels labelFlag
+	fs.Var(&labels, "label", "only list breakers labelled key:value; may be repeated")
+	_ = fs.Parse(args)
+
+	selector, err := admin.ParseSelector(labels)
+	if err != nil {
+		return err
+	}
+	snaps, err := client.SnapshotsMatching(ctx, selector)
+	if err != nil {
+		return err
+	}
+	if *asJSON {
+		return printJSON(snaps)
+	}
+	printList(os.Stdout, snaps)
+	return nil
+}
+
+func show(ctx context.Context, client *admin.Client, args []string) error {
+	fs := flag.NewFlagSet("show", flag.ExitOnError)
+	asJSON := fs.Bool("json", false, "print the snapshot as JSON")
+	name, err := oneName(fs, args)
+	if err != nil {
+		return err
+	}
+	snap, err := client.Snapshot(ctx, name)
+	if err != nil {
+		return err
+	}
+	if *asJSON {
+		return printJSON(snap)
+	}
+	printSnapshot(os.Stdout, snap)
+	return nil
+}
+
+func tail(ctx context.Context, client *admin.Client, args []string) error {
+	fs := flag.NewFlagSet("tail", flag.ExitOnError)
+	snapshots := fs.Bool("snapshots", false, "also print the counters of every breaker each interval")
+	interval := fs.Duration("interval", 0, "interval between snapshots; zero leaves it to the server")
+	asJSON := fs.Bool("json", false, "print events as JSON, one per line")
+	_ = fs.Parse(args)
+
+	enc := json.NewEncoder(os.Stdout)
+	return client.Stream(ctx, *interval, func(ev admin.StreamEvent) error {
+		switch {
+		case ev.Change != nil && *asJSON:
+			return enc.Encode(ev.Change)
+		case ev.Change != nil:
+			printChange(os.Stdout, ev.Change)
+		case !*snapshots:
+		case *asJSON:
+			return enc.Encode(ev.Snapshots)
+		default:
+			printList(os.Stdout, ev.Snapshots)
+		}
+		return nil
+	})
+}
+
+func control(ctx context.Context, client *admin.Client, cmd string, args []string) error {
+	fs := flag.NewFlagSet(cmd, flag.ExitOnError)
+	var (
+		snap sparkgap.BreakerSnapshot
+		err  error
+	)
+	if cmd == "force" {
+		_ = fs.Parse(args)
+		if fs.NArg() != 2 {
+			fmt.Fprintln(os.Stderr, "sparkgapctl: force takes a breaker name and a mode")
+			return errUsage
+		}
+		var mode sparkgap.ForcedMode
+		if err := mode.UnmarshalText([]byte(fs.Arg(1))); err != nil {
+			return err
+		}
+		snap, err = client.Force(ctx, fs.Arg(0), mode)
+	} else {
+		name, perr := oneName(fs, args)
+		if perr != nil {
+			return perr
+		}
+		switch cmd {
+		case "trip":
+			snap, err = client.Trip(ctx, name)
+		case "reset":
+			snap, err = client.Reset(ctx, name)
+		case "probe":
+			snap, err = client.Probe(ctx, name)
+		}
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%s is now %s\n", snap.Name, describeState(snap))
+	return nil
+}
+
+func doctor(ctx context.Context, client *admin.Client, args []string) error {
+	fs := flag.NewFlagSet("doctor", flag.ExitOnError)
+	asJSON := fs.Bool("json", false, "print findings as JSON")
+	_ = fs.Parse(args)
+
+	snaps, err := client.Snapshots(ctx)
+	if err != nil {
+		return err
+	}
+	findings := admin.Diagnose(snaps)
+
+	if *asJSON {
+		if err := printJSON(findings); err != nil {
+			return err
+		}
+	} else if len(findings) == 0 {
+		fmt.Printf("checked %d breakers, no problems found\n", len(snaps))
+	} else {
+		for _, f := range findings {
+			fmt.Printf("%-7s %-24s %-26s %s\n", f.Severity, f.Breaker, f.Check, f.Message)
+		}
+	}
+
+	for _, f := range findings {
+		if f.Severity == admin.SeverityWarning {
+			return errProblems
+		}
+	}
+	return nil
+}
+
+func printJSON(v any) error {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
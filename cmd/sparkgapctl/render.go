@@ -0,0 +1,105 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"time"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+
+	"github.com/afk-ankit/sparkgap"
+	"github.com/afk-ankit/sparkgap/admin"
+)
+
+// describeState renders the state of a breaker along with any operator override.
+func describeState(s sparkgap.BreakerSnapshot) string {
+	if s.Forced != sparkgap.NotForced {
+		return fmt.Sprintf("%s (forced %s)", s.State, s.Forced)
+	}
+	return s.State.String()
+}
+
+func printList(w io.Writer, snaps []sparkgap.BreakerSnapshot) {
+	tw := table.NewWriter()
+	tw.SetStyle(table.StyleRounded)
+	tw.AppendHeader(table.Row{"Breaker", "State", "Failures", "Calls", "Failed", "Rejected", "In-flight"})
+	for _, s := range snaps {
+		var rejected uint64
+		for _, n := range s.Rejections {
+			rejected += n
+		}
+		tw.AppendRow(table.Row{
+			s.Name,
+			describeState(s),
+			fmt.Sprintf("%d / %d", s.FailureCount, s.FailureThreshold),
+			s.TotalCalls,
+			s.TotalFailures,
+			rejected,
+			s.InFlight,
+		})
+	}
+	fmt.Fprintln(w, tw.Render())
+}
+
+func printSnapshot(w io.Writer, s sparkgap.BreakerSnapshot) {
+	tw := table.NewWriter()
+	tw.SetStyle(table.StyleRounded)
+	tw.AppendHeader(table.Row{"Circuit Breaker", s.Name})
+	tw.AppendRow(table.Row{"State", describeState(s)})
+	if s.Parent != "" {
+		tw.AppendRow(table.Row{"Parent", s.Parent})
+	}
+	for _, k := range slices.Sorted(maps.Keys(s.Labels)) {
+		tw.AppendRow(table.Row{fmt.Sprintf("Label (%s)", k), s.Labels[k]})
+	}
+	if s.State == sparkgap.StateOpen {
+		tw.AppendRow(table.Row{"Half-Open in", s.UntilHalfOpen.Round(time.Millisecond)})
+	}
+	if !s.LastTransition.IsZero() {
+		tw.AppendRow(table.Row{"Last transition", s.LastTransition.Format(time.RFC3339)})
+	}
+	tw.AppendRow(table.Row{"Failure (current/threshold)", fmt.Sprintf("%d / %d", s.FailureCount, s.FailureThreshold)})
+	if s.FailureWeight > 0 {
+		tw.AppendRow(table.Row{"Failure weight", fmt.Sprintf("%.2f", s.FailureWeight)})
+	}
+	for _, cat := range slices.Sorted(maps.Keys(s.FailuresByCategory)) {
+		tw.AppendRow(table.Row{fmt.Sprintf("Failure (%s)", cat), s.FailuresByCategory[cat]})
+	}
+	tw.AppendRow(table.Row{"Retry interval", s.RetryInterval})
+	tw.AppendRow(table.Row{"Calls (total/failed)", fmt.Sprintf("%d / %d", s.TotalCalls, s.TotalFailures)})
+	tw.AppendRow(table.Row{"In-flight (now/max/avg)", fmt.Sprintf("%d / %d / %.1f", s.InFlight, s.MaxInFlight, s.AvgInFlight)})
+	if s.HalfOpenMode == sparkgap.HalfOpenConsecutive {
+		tw.AppendRow(table.Row{"Half-Open successes (current/threshold)", fmt.Sprintf("%d / %d", s.HalfOpenSuccessCount, s.SuccessThreshold)})
+	} else {
+		tw.AppendRow(table.Row{"Half-Open probes (success/failure/max)", fmt.Sprintf("%d / %d / %d", s.HalfOpenSuccessCount, s.HalfOpenFailureCount, s.HalfOpenMaxProbes)})
+	}
+	for _, reason := range slices.Sorted(maps.Keys(s.Rejections)) {
+		tw.AppendRow(table.Row{fmt.Sprintf("Rejected (%s)", reason), s.Rejections[reason]})
+	}
+	for _, sli := range s.SLI {
+		tw.AppendRow(table.Row{fmt.Sprintf("Availability (%s)", sli.Window), fmt.Sprintf("%.2f%% of %d", sli.Ratio*100, sli.Total)})
+	}
+	if l := s.Latency; l != nil {
+		tw.AppendRow(table.Row{fmt.Sprintf("Slow calls (%s)", l.Window), fmt.Sprintf("%.2f%% of %d", l.SlowCallPercent, l.Calls)})
+		tw.AppendRow(table.Row{"Latency p99", l.P99})
+	}
+	fmt.Fprintln(w, tw.Render())
+}
+
+func printChange(w io.Writer, c *admin.Change) {
+	ts := c.Time.Format("15:04:05.000")
+	switch c.Kind {
+	case "StateChange":
+		fmt.Fprintf(w, "%s  %-24s %s → %s", ts, c.Breaker, c.From, c.To)
+		if c.Cause != "" {
+			fmt.Fprintf(w, " (%s)", c.Cause)
+		}
+		fmt.Fprintln(w)
+	case "Saturation":
+		fmt.Fprintf(w, "%s  %-24s saturated, %d in flight\n", ts, c.Breaker, c.InFlight)
+	default:
+		fmt.Fprintf(w, "%s  %-24s %s\n", ts, c.Breaker, c.Kind)
+	}
+}
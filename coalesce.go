@@ -0,0 +1,68 @@
+package sparkgap
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// errFlightPanicked is handed to callers sharing a coalesced call that panicked.
+var errFlightPanicked = errors.New("sparkgap: coalesced call panicked")
+
+type flight[T any] struct {
+	done chan struct{}
+	val  T
+	err  error
+}
+
+// flightGroup collapses concurrent calls with the same key into one, like x/sync/singleflight.
+type flightGroup[T any] struct {
+	mu sync.Mutex
+	m  map[string]*flight[T]
+}
+
+// do runs fn unless a call for key is already in flight, in which case it waits for its result or for ctx to be done.
+func (g *flightGroup[T]) do(ctx context.Context, key string, fn func() (T, error)) (T, error) {
+	g.mu.Lock()
+	if f, ok := g.m[key]; ok {
+		g.mu.Unlock()
+		select {
+		case <-f.done:
+			return f.val, f.err
+		case <-ctx.Done():
+			var zero T
+			return zero, ctx.Err()
+		}
+	}
+	if g.m == nil {
+		g.m = make(map[string]*flight[T])
+	}
+	f := &flight[T]{done: make(chan struct{}), err: errFlightPanicked}
+	g.m[key] = f
+	g.mu.Unlock()
+
+	defer func() {
+		g.mu.Lock()
+		delete(g.m, key)
+		g.mu.Unlock()
+		close(f.done)
+	}()
+	f.val, f.err = fn()
+	return f.val, f.err
+}
+
+/*
+ExecuteKeyed is like ExecuteContext, except that with HalfOpenCoalesce set, concurrent calls
+with the same key made while the breaker is Half-Open are collapsed: only the first one reaches
+the dependency, as a single probe, and the others wait for and share its result, up to their own
+ctx. Shared results are not copied, so callers must not mutate them. Outside Half-Open, or
+without HalfOpenCoalesce, every call runs on its own.
+*/
+func (br *Breaker[T]) ExecuteKeyed(ctx context.Context, key string, fn func(ctx context.Context) (T, error)) (T, error) {
+	if !br.coalesce || br.getState() != StateHalfOpen {
+		return br.ExecuteContext(ctx, fn)
+	}
+	return br.flights.do(ctx, key, func() (T, error) {
+		return br.ExecuteContext(ctx, fn)
+	})
+}
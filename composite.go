@@ -0,0 +1,131 @@
+package sparkgap
+
+import "time"
+
+/*
+Condition is one input to a Composite breaker. Tripped reports whether the condition currently
+wants calls to be rejected, and Observe is told the outcome of every call the Composite let through.
+*CircuitBreaker (and so every *Breaker[T]) implements Condition, as does HealthSignal for external signals.
+*/
+type Condition interface {
+	Tripped() bool
+	Observe(err error, elapsed time.Duration)
+}
+
+// Tripped reports whether the breaker is rejecting calls, i.e. it is open or has been closed.
+func (br *CircuitBreaker) Tripped() bool {
+	return br.closed.Load() || br.getState() == StateOpen
+}
+
+/*
+Observe feeds the outcome of a call made outside Execute into the breaker's accounting,
+as if it had been admitted in the breaker's current state. Calls observed while the breaker
+is open are ignored.
+*/
+func (br *CircuitBreaker) Observe(err error, _ time.Duration) {
+	if br.closed.Load() || br.neutral(err) {
+		return
+	}
+	if adm := br.currentAdmission(); adm.state != StateOpen {
+		br.record(adm, !br.isFailure(err), err)
+	}
+}
+
+// HealthSignal adapts an external health check to a Condition; it trips while the func returns false.
+type HealthSignal func() bool
+
+func (h HealthSignal) Tripped() bool { return !h() }
+
+func (HealthSignal) Observe(error, time.Duration) {}
+
+// CompositeMode selects how a Composite combines its conditions.
+type CompositeMode int
+
+const (
+	// AnyTripped rejects calls as soon as one condition is tripped.
+	AnyTripped CompositeMode = iota
+	// AllTripped rejects calls only while every condition is tripped.
+	AllTripped
+)
+
+/*
+Composite presents several conditions, e.g. an error-rate breaker, a latency breaker and an
+external health signal, as a single breaker. Call through it with Do, or ExecuteComposite for
+calls returning a value.
+*/
+type Composite struct {
+	name       string
+	mode       CompositeMode
+	conditions []Condition
+	clock      Clock
+}
+
+// NewComposite returns a Composite combining conditions according to mode.
+func NewComposite(name string, mode CompositeMode, conditions ...Condition) *Composite {
+	if name == "" {
+		name = "composite"
+	}
+	return &Composite{name: name, mode: mode, conditions: conditions, clock: realClock{}}
+}
+
+// Name returns the composite's name.
+func (c *Composite) Name() string { return c.name }
+
+// Tripped reports whether the composite is currently rejecting calls.
+func (c *Composite) Tripped() bool {
+	if len(c.conditions) == 0 {
+		return false
+	}
+	for _, cond := range c.conditions {
+		tripped := cond.Tripped()
+		if tripped && c.mode == AnyTripped {
+			return true
+		}
+		if !tripped && c.mode == AllTripped {
+			return false
+		}
+	}
+	return c.mode == AllTripped
+}
+
+// Observe forwards the outcome of a call to every condition.
+func (c *Composite) Observe(err error, elapsed time.Duration) {
+	for _, cond := range c.conditions {
+		cond.Observe(err, elapsed)
+	}
+}
+
+// State returns Open while the composite rejects calls and Closed otherwise.
+func (c *Composite) State() State {
+	if c.Tripped() {
+		return StateOpen
+	}
+	return StateClosed
+}
+
+/*
+ExecuteComposite calls fn unless c is tripped, in which case it returns a
+*RejectionError wrapping ErrOpen without calling fn. The outcome and duration of fn are
+reported to every condition.
+*/
+func ExecuteComposite[T any](c *Composite, fn func() (T, error)) (T, error) {
+	var zero T
+	if c.Tripped() {
+		return zero, reject(c.name, ReasonOpen, ErrOpen)
+	}
+	start := c.clock.Now()
+	res, err := fn()
+	c.Observe(err, c.clock.Now().Sub(start))
+	return res, err
+}
+
+// Do is ExecuteComposite for fn that return only an error.
+func (c *Composite) Do(fn func() error) error {
+	if c.Tripped() {
+		return reject(c.name, ReasonOpen, ErrOpen)
+	}
+	start := c.clock.Now()
+	err := fn()
+	c.Observe(err, c.clock.Now().Sub(start))
+	return err
+}
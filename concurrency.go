@@ -0,0 +1,72 @@
+package sparkgap
+
+import "sync/atomic"
+
+const defaultSaturationPercent uint32 = 80
+
+// concurrency tracks the number of calls a breaker has in flight.
+type concurrency struct {
+	inFlight atomic.Int64
+	max      atomic.Int64
+	// sum and samples give the average number of calls already in flight when a call starts.
+	sum     atomic.Uint64
+	samples atomic.Uint64
+	// saturated is set while in-flight calls are at or above the saturation mark.
+	saturated atomic.Bool
+}
+
+// enter records a call starting and returns the number of calls now in flight.
+func (c *concurrency) enter() int64 {
+	n := c.inFlight.Add(1)
+	for {
+		m := c.max.Load()
+		if n <= m || c.max.CompareAndSwap(m, n) {
+			break
+		}
+	}
+	c.sum.Add(uint64(n - 1))
+	c.samples.Add(1)
+	return n
+}
+
+func (c *concurrency) exit() int64 {
+	return c.inFlight.Add(-1)
+}
+
+func (c *concurrency) average() float64 {
+	n := c.samples.Load()
+	if n == 0 {
+		return 0
+	}
+	return float64(c.sum.Load()) / float64(n)
+}
+
+// callStarted tracks concurrency for an admitted call and raises EventSaturation when the
+// in-flight count first reaches the configured share of SaturationLimit.
+func (br *CircuitBreaker) callStarted() {
+	n := br.conc.enter()
+	if br.saturationMark == 0 || n < br.saturationMark {
+		return
+	}
+	if br.conc.saturated.CompareAndSwap(false, true) {
+		st := br.getState()
+		br.publish(Event{Kind: EventSaturation, From: st, To: st, InFlight: n})
+	}
+}
+
+func (br *CircuitBreaker) callFinished() {
+	n := br.conc.exit()
+	if br.saturationMark > 0 && n < br.saturationMark {
+		br.conc.saturated.Store(false)
+	}
+}
+
+func saturationMark(limit, percent uint32) int64 {
+	if limit == 0 {
+		return 0
+	}
+	if percent == 0 || percent > 100 {
+		percent = defaultSaturationPercent
+	}
+	return max(1, int64(limit)*int64(percent)/100)
+}
@@ -0,0 +1,524 @@
+package sparkgap
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"maps"
+	"reflect"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+/*
+HalfOpenMode selects how a Half-Open breaker decides between closing and reopening.
+*/
+type HalfOpenMode int
+
+const (
+	// HalfOpenPercentage admits HalfOpenMaxProbes probes and reopens if at least
+	// HalfOpenMaxFailurePercent of them failed. It is the default.
+	HalfOpenPercentage HalfOpenMode = iota
+	// HalfOpenConsecutive closes after SuccessThreshold consecutive successful probes and
+	// reopens on the first failed one.
+	HalfOpenConsecutive
+)
+
+func (m HalfOpenMode) String() string {
+	switch m {
+	case HalfOpenPercentage:
+		return "percentage"
+	case HalfOpenConsecutive:
+		return "consecutive"
+	default:
+		return fmt.Sprintf("HalfOpenMode(%d)", int(m))
+	}
+}
+
+// MarshalText renders the mode by name.
+func (m HalfOpenMode) MarshalText() ([]byte, error) {
+	return []byte(m.String()), nil
+}
+
+// UnmarshalText parses a name produced by MarshalText.
+func (m *HalfOpenMode) UnmarshalText(text []byte) error {
+	switch string(text) {
+	case "percentage", "":
+		*m = HalfOpenPercentage
+	case "consecutive":
+		*m = HalfOpenConsecutive
+	default:
+		return fmt.Errorf("unknown half-open mode %q", text)
+	}
+	return nil
+}
+
+const (
+	defaultFailureThreshold          uint32 = 5
+	defaultSuccessThreshold          uint32 = 3
+	defaultHalfOpenProbes            uint32 = 10
+	defaultHalfOpenMaxFailurePercent uint32 = 30
+	defaultRetryInterval                    = 5 * time.Second
+)
+
+/*
+BreakerConfig configures a circuit breaker. Zero-valued fields fall back to their defaults.
+*/
+type BreakerConfig struct {
+	FailureThreshold          uint32
+	RetryInterval             time.Duration
+	HalfOpenMaxProbes         uint32
+	HalfOpenMaxFailurePercent uint32
+	// HalfOpenFastFail reopens as soon as enough probes have failed that the window can no
+	// longer pass, instead of waiting for all HalfOpenMaxProbes results.
+	HalfOpenFastFail bool
+	// HalfOpenMaxFailures, when non-zero, reopens after that many failed probes in a window.
+	HalfOpenMaxFailures uint32
+	// HalfOpenMode chooses between the probe-percentage strategy above and closing after
+	// SuccessThreshold (default 3) consecutive successful probes.
+	HalfOpenMode     HalfOpenMode
+	SuccessThreshold uint32
+	// Timeout sets a deadline on the context passed to every call, so a call that honors it
+	// gives up with context.DeadlineExceeded, classified like any other error. Zero means no
+	// limit. DoTimeout and ExecuteTimeout override it per call.
+	Timeout time.Duration
+	// ContextInfo adds a CallInfo with the breaker name and state to the context passed to
+	// every call, for CallInfoFrom. It costs one allocation per call, so it is off by default.
+	ContextInfo bool
+	// HalfOpenCoalesce makes concurrent ExecuteKeyed calls with the same key share one probe
+	// while Half-Open, so the recovering dependency sees a single request per key.
+	HalfOpenCoalesce bool
+	// HalfOpenMaxConcurrent limits how many probes may be in flight at once while Half-Open.
+	// Zero means no limit. Callers over the limit are rejected with ErrProbeQuotaExceeded.
+	HalfOpenMaxConcurrent uint32
+	// HalfOpenFairness makes callers over HalfOpenMaxConcurrent wait for a slot in FIFO order
+	// instead of being rejected, up to HalfOpenQueueSize waiters (default 64).
+	HalfOpenFairness  bool
+	HalfOpenQueueSize int
+	// ShedPriority, when set, rejects calls of that priority or lower with ErrShed while the
+	// breaker is degraded: Half-Open, Throttled, or Closed with consecutive failures at ShedFailurePercent
+	// (default 50) of FailureThreshold. PriorityLow sheds only low-priority calls, keeping the
+	// struggling dependency's capacity for the rest; PriorityNormal sheds all but
+	// PriorityCritical calls. See WithPriority.
+	ShedPriority       Priority
+	ShedFailurePercent uint32
+	// MaxConcurrent, when set, caps the calls in flight outside Half-Open, so the breaker also
+	// isolates the dependency like a bulkhead. Callers over the cap are rejected with
+	// ErrBulkheadFull, or, with MaxConcurrentQueue set, wait for a slot in FIFO order, up to
+	// that many waiters, until their context is done.
+	MaxConcurrent      uint32
+	MaxConcurrentQueue int
+	// IsFailure classifies errors returned by protected calls. Nil counts every error as a
+	// failure; see As, Is and MatchAny for building classifiers declaratively.
+	IsFailure Classifier
+	// CountCanceled classifies calls failing with context.Canceled, usually because the caller
+	// gave up, like any other error. By default they are neutral: they count neither as failures
+	// nor as successes, so client-side cancellations cannot trip the breaker or end a failure streak.
+	CountCanceled bool
+	// FailureWeight, when set, makes FailureThreshold apply to the accumulated weight of
+	// consecutive failures rather than their number; see FailureWeigher.
+	FailureWeight FailureWeigher
+	// ErrorClassifier and CategoryThresholds give classes of errors their own consecutive-failure
+	// threshold in Closed state, e.g. tripping after 2 timeouts but 10 other errors.
+	ErrorClassifier    ErrorClassifier
+	CategoryThresholds map[ErrorCategory]uint32
+	// RetryBackoff, when set, grows the open period on consecutive trips instead of always
+	// waiting RetryInterval.
+	RetryBackoff *Backoff
+	// RetryJitter randomizes the open period; see JitterMode.
+	RetryJitter JitterMode
+	// MaxOpenDuration caps how long the breaker stays Open in one go. Once it has passed, the
+	// breaker moves to Half-Open even if RetryBackoff, Adaptive, a peer's trip or a failing
+	// HealthProbe would keep it Open longer, so a recovered dependency is never isolated for
+	// good. Zero means no cap.
+	MaxOpenDuration time.Duration
+	// Adaptive, when set, lets the breaker tune FailureThreshold and RetryInterval from its recent trips.
+	Adaptive *Adaptive
+	// BrownOut, when set, throttles traffic while the failure rate is elevated but consecutive
+	// failures are still below FailureThreshold, instead of going straight from Closed to Open.
+	BrownOut *BrownOut
+	// WarmUpDuration, when set, ramps traffic back up once a tripped breaker closes again: it
+	// admits WarmUpStartPercent (default 10) of calls at first, rising linearly to all of them
+	// over WarmUpDuration, and rejects the rest with the warming_up reason. PriorityCritical
+	// calls and manual resets skip the ramp.
+	WarmUpDuration     time.Duration
+	WarmUpStartPercent uint32
+	// SlowCallThreshold makes calls slower than it count as slow. Once more than
+	// SlowCallRatePercent (default 50) of at least SlowCallMinCalls (default 10) calls over the
+	// trailing SlowCallWindow (default one minute) were slow, a Closed breaker trips even if
+	// they all succeeded. Zero disables latency tracking.
+	SlowCallThreshold   time.Duration
+	SlowCallRatePercent uint32
+	SlowCallMinCalls    uint32
+	SlowCallWindow      time.Duration
+	// StateStore, when set, shares trips with breakers of the same name in other processes,
+	// polling it every StateSyncInterval (default one second).
+	StateStore        StateStore
+	StateSyncInterval time.Duration
+	// HealthProbe, when set, is called by the breaker itself once the open period is over, and
+	// then every HealthProbeInterval (default RetryInterval) while it keeps failing, each call
+	// bounded by HealthProbeTimeout (default HealthProbeInterval). A successful probe moves the
+	// breaker to Half-Open, or straight to Closed with HealthProbeCloses, so recovery is tested
+	// without risking live traffic.
+	HealthProbe         func(ctx context.Context) error
+	HealthProbeInterval time.Duration
+	HealthProbeTimeout  time.Duration
+	HealthProbeCloses   bool
+	// SLIWindows lists the trailing windows over which SLI reports availability. Empty disables it.
+	SLIWindows []time.Duration
+	// StatsWindow is the trailing window over which Stats reports call rates and latency
+	// percentiles. Zero disables it.
+	StatsWindow time.Duration
+	// BeforeCall and AfterCall, if set, are called synchronously around every call the breaker
+	// admits, with its details and, after it, its outcome and duration, for custom accounting
+	// such as billing or quotas. Rejected calls and calls bypassing a Disabled breaker don't
+	// reach them; see Subscribe for those. They run on the hot path, so they should be fast.
+	BeforeCall func(ctx context.Context, d CallDetails)
+	AfterCall  func(ctx context.Context, d CallDetails, r CallResult)
+	// DeadlinePercentile, when set, rejects calls with ErrDeadlineTooShort instead of starting
+	// doomed work against a loaded dependency when the time left before their context's
+	// deadline is below that percentile of recent call durations, e.g. 50 for the median.
+	// Durations are tracked over StatsWindow, which it requires, and the check only applies
+	// once the window holds at least 10 calls. Half-Open probes are never rejected by it.
+	DeadlinePercentile uint32
+	// SaturationLimit is the concurrency limit enforced in front of this breaker, e.g. by a
+	// bulkhead. When set, EventSaturation is emitted once in-flight calls reach
+	// SaturationPercent (default 80) of it, as an early warning before rejections start.
+	SaturationLimit   uint32
+	SaturationPercent uint32
+	// SnoozeSuppressesTrips keeps a snoozed breaker from opening. Failures are still counted
+	// and re-evaluated when the snooze ends.
+	SnoozeSuppressesTrips bool
+	// EventsBuffer is the capacity of channels returned by Events, default 256, and
+	// EventsPolicy what they do once full; see EventsPolicy.
+	EventsBuffer       int
+	EventsPolicy       EventsPolicy
+	EventsBlockTimeout time.Duration
+	// RejectionSummaryInterval, when set, aggregates rejections instead of logging and
+	// publishing each one: at most one EventRejectionSummary and one Info log record, such as
+	// "rejected 12431 calls in 5s", are emitted per interval. Rejections still count towards
+	// Rejections, Stats and metrics.
+	RejectionSummaryInterval time.Duration
+	// HistorySize is how many state transitions History keeps. Zero means 32.
+	HistorySize int
+	// FlightRecorder, when set, is handed every event the breaker emits.
+	FlightRecorder Recorder
+	// Metrics, when set, receives counters, timings and gauges for the breaker; see MetricsSink.
+	Metrics MetricsSink
+	// MetricsStore holds the counts the breaker trips and recovers on. Nil keeps them in memory.
+	MetricsStore MetricsStore
+	// Labels, such as service, endpoint or region, are attached to the breaker's metrics,
+	// events and snapshots so fleets of breakers can be filtered and aggregated. Keys must be
+	// non-empty and must not contain ':'. They cannot be changed with UpdateConfig.
+	Labels map[string]string
+	// Logger receives structured records for transitions, probes and rejections; see WithLogger.
+	Logger *slog.Logger
+	// Clock drives timeouts and state transitions. Nil uses the wall clock.
+	Clock Clock
+}
+
+/*
+Clone returns a deep copy of c, so a standard config can be adjusted per breaker without
+affecting the others. Maps, slices and the RetryBackoff, Adaptive and BrownOut settings are
+copied; functions and shared components such as StateStore, Metrics, Logger and Clock are not.
+Cloning nil returns nil.
+*/
+func (c *BreakerConfig) Clone() *BreakerConfig {
+	if c == nil {
+		return nil
+	}
+	out := *c
+	if c.RetryBackoff != nil {
+		b := *c.RetryBackoff
+		out.RetryBackoff = &b
+	}
+	if c.Adaptive != nil {
+		a := *c.Adaptive
+		out.Adaptive = &a
+	}
+	if c.BrownOut != nil {
+		b := *c.BrownOut
+		out.BrownOut = &b
+	}
+	out.CategoryThresholds = maps.Clone(c.CategoryThresholds)
+	out.SLIWindows = slices.Clone(c.SLIWindows)
+	out.Labels = maps.Clone(c.Labels)
+	return &out
+}
+
+// fleetDefaults holds the config set with SetDefaultConfig.
+var fleetDefaults atomic.Pointer[BreakerConfig]
+
+/*
+SetDefaultConfig sets fleet-wide defaults: every breaker created afterwards, by InitBreaker,
+NewBreaker, NewCircuitBreaker, groups or templates, takes the value of cfg for each field it
+leaves zero, before the built-in defaults apply. Boolean fields can therefore only be turned
+on by cfg, not off per breaker. cfg is validated as by NewCircuitBreaker and copied; a nil cfg
+clears the defaults. Existing breakers are not affected.
+*/
+func SetDefaultConfig(cfg *BreakerConfig) error {
+	if cfg == nil {
+		fleetDefaults.Store(nil)
+		return nil
+	}
+	c := cfg.Clone()
+	if err := validate(c); err != nil {
+		return err
+	}
+	fleetDefaults.Store(c)
+	return nil
+}
+
+// DefaultConfig returns a copy of the config set with SetDefaultConfig, or nil if there is none.
+func DefaultConfig() *BreakerConfig {
+	return fleetDefaults.Load().Clone()
+}
+
+// halfOpenFields are the fields selecting and tuning the Half-Open mode. Some of them exclude
+// others, so mergeFleetDefaults takes them from the defaults together or not at all.
+var halfOpenFields = []string{
+	"HalfOpenMode", "HalfOpenMaxProbes", "HalfOpenMaxFailurePercent", "HalfOpenFastFail",
+	"HalfOpenMaxFailures", "SuccessThreshold",
+}
+
+/*
+mergeFleetDefaults fills the zero fields of c from the config set with SetDefaultConfig. The
+Half-Open fields are only filled if c leaves all of them zero, so a breaker choosing its own
+mode does not inherit settings of the other one.
+*/
+func mergeFleetDefaults(c *BreakerConfig) {
+	d := fleetDefaults.Load()
+	if d == nil {
+		return
+	}
+	d = d.Clone()
+	dst, src := reflect.ValueOf(c).Elem(), reflect.ValueOf(d).Elem()
+	ownHalfOpen := false
+	for _, name := range halfOpenFields {
+		if !dst.FieldByName(name).IsZero() {
+			ownHalfOpen = true
+		}
+	}
+	typ := dst.Type()
+	for i := range dst.NumField() {
+		if ownHalfOpen && slices.Contains(halfOpenFields, typ.Field(i).Name) {
+			continue
+		}
+		if f := dst.Field(i); f.IsZero() {
+			f.Set(src.Field(i))
+		}
+	}
+}
+
+func applyDefaults(c *BreakerConfig) {
+	if c.FailureThreshold == 0 {
+		c.FailureThreshold = defaultFailureThreshold
+	}
+	if c.RetryInterval <= 0 {
+		c.RetryInterval = defaultRetryInterval
+	}
+	if c.HalfOpenMaxProbes == 0 {
+		c.HalfOpenMaxProbes = defaultHalfOpenProbes
+	}
+	if c.HalfOpenMaxFailurePercent == 0 || c.HalfOpenMaxFailurePercent > 100 {
+		c.HalfOpenMaxFailurePercent = defaultHalfOpenMaxFailurePercent
+	}
+	if c.SuccessThreshold == 0 {
+		c.SuccessThreshold = defaultSuccessThreshold
+	}
+	if c.RetryBackoff != nil {
+		b := *c.RetryBackoff
+		b.applyDefaults(c.RetryInterval)
+		c.RetryBackoff = &b
+	}
+	if c.BrownOut != nil {
+		b := *c.BrownOut
+		b.applyDefaults()
+		c.BrownOut = &b
+	}
+	if c.Adaptive != nil {
+		a := *c.Adaptive
+		a.applyDefaults(c.FailureThreshold, c.RetryInterval)
+		c.Adaptive = &a
+	}
+	if c.SlowCallRatePercent == 0 || c.SlowCallRatePercent > 100 {
+		c.SlowCallRatePercent = defaultSlowCallRatePercent
+	}
+	if c.SlowCallMinCalls == 0 {
+		c.SlowCallMinCalls = defaultSlowCallMinCalls
+	}
+	if c.SlowCallWindow <= 0 {
+		c.SlowCallWindow = defaultSlowCallWindow
+	}
+	if c.HealthProbeInterval <= 0 {
+		c.HealthProbeInterval = c.RetryInterval
+	}
+	if c.HealthProbeTimeout <= 0 {
+		c.HealthProbeTimeout = c.HealthProbeInterval
+	}
+	if c.WarmUpStartPercent == 0 {
+		c.WarmUpStartPercent = defaultWarmUpStartPercent
+	}
+	if c.ShedFailurePercent == 0 {
+		c.ShedFailurePercent = defaultShedFailurePercent
+	}
+	if c.StateSyncInterval <= 0 {
+		c.StateSyncInterval = defaultStateSyncInterval
+	}
+	if c.EventsBuffer == 0 {
+		c.EventsBuffer = defaultEventsBuffer
+	}
+	if c.EventsBlockTimeout <= 0 {
+		c.EventsBlockTimeout = defaultEventsBlockTimeout
+	}
+	if c.MetricsStore == nil {
+		c.MetricsStore = MemoryStore{}
+	}
+	if c.Clock == nil {
+		c.Clock = realClock{}
+	}
+}
+
+func validate(c *BreakerConfig) error {
+	if c.RetryInterval < 0 {
+		return fmt.Errorf("%w: RetryInterval must not be negative, got %s", ErrInvalidConfig, c.RetryInterval)
+	}
+	if c.MaxOpenDuration < 0 {
+		return fmt.Errorf("%w: MaxOpenDuration must not be negative, got %s", ErrInvalidConfig, c.MaxOpenDuration)
+	}
+	if c.Timeout < 0 {
+		return fmt.Errorf("%w: Timeout must not be negative, got %s", ErrInvalidConfig, c.Timeout)
+	}
+	if c.HalfOpenMaxFailurePercent > 100 {
+		return fmt.Errorf("%w: HalfOpenMaxFailurePercent must be at most 100, got %d", ErrInvalidConfig, c.HalfOpenMaxFailurePercent)
+	}
+	if b := c.RetryBackoff; b != nil {
+		if b.Initial < 0 || b.Max < 0 {
+			return fmt.Errorf("%w: RetryBackoff durations must not be negative", ErrInvalidConfig)
+		}
+		if b.Multiplier != 0 && b.Multiplier < 1 {
+			return fmt.Errorf("%w: RetryBackoff.Multiplier must be at least 1, got %g", ErrInvalidConfig, b.Multiplier)
+		}
+		if b.Jitter < 0 || b.Jitter > 1 {
+			return fmt.Errorf("%w: RetryBackoff.Jitter must be between 0 and 1, got %g", ErrInvalidConfig, b.Jitter)
+		}
+	}
+	if b := c.BrownOut; b != nil {
+		if b.FailurePercent == 0 || b.FailurePercent > 100 {
+			return fmt.Errorf("%w: BrownOut.FailurePercent must be between 1 and 100, got %d", ErrInvalidConfig, b.FailurePercent)
+		}
+		if b.ExitPercent > b.FailurePercent {
+			return fmt.Errorf("%w: BrownOut.ExitPercent %d exceeds FailurePercent %d", ErrInvalidConfig, b.ExitPercent, b.FailurePercent)
+		}
+		if b.AdmitPercent > 100 {
+			return fmt.Errorf("%w: BrownOut.AdmitPercent must be at most 100, got %d", ErrInvalidConfig, b.AdmitPercent)
+		}
+		if b.Window < 0 {
+			return fmt.Errorf("%w: BrownOut.Window must not be negative", ErrInvalidConfig)
+		}
+	}
+	if a := c.Adaptive; a != nil {
+		if a.MinRetryInterval < 0 || a.MaxRetryInterval < 0 || a.Window < 0 {
+			return fmt.Errorf("%w: Adaptive durations must not be negative", ErrInvalidConfig)
+		}
+		if a.MaxFailureThreshold != 0 && a.MinFailureThreshold > a.MaxFailureThreshold {
+			return fmt.Errorf("%w: Adaptive.MinFailureThreshold %d exceeds MaxFailureThreshold %d", ErrInvalidConfig, a.MinFailureThreshold, a.MaxFailureThreshold)
+		}
+		if a.MaxRetryInterval != 0 && a.MinRetryInterval > a.MaxRetryInterval {
+			return fmt.Errorf("%w: Adaptive.MinRetryInterval %s exceeds MaxRetryInterval %s", ErrInvalidConfig, a.MinRetryInterval, a.MaxRetryInterval)
+		}
+	}
+	switch c.HalfOpenMode {
+	case HalfOpenPercentage:
+		if c.SuccessThreshold != 0 {
+			return fmt.Errorf("%w: SuccessThreshold requires HalfOpenMode HalfOpenConsecutive", ErrInvalidConfig)
+		}
+	case HalfOpenConsecutive:
+		if c.HalfOpenMaxProbes != 0 || c.HalfOpenMaxFailurePercent != 0 || c.HalfOpenFastFail || c.HalfOpenMaxFailures != 0 {
+			return fmt.Errorf("%w: HalfOpenMaxProbes, HalfOpenMaxFailurePercent, HalfOpenFastFail and HalfOpenMaxFailures only apply to HalfOpenMode HalfOpenPercentage", ErrInvalidConfig)
+		}
+	default:
+		return fmt.Errorf("%w: unknown HalfOpenMode %d", ErrInvalidConfig, c.HalfOpenMode)
+	}
+	if c.RetryJitter < JitterNone || c.RetryJitter > JitterDecorrelated {
+		return fmt.Errorf("%w: unknown RetryJitter %d", ErrInvalidConfig, c.RetryJitter)
+	}
+	for cat, t := range c.CategoryThresholds {
+		if t == 0 {
+			return fmt.Errorf("%w: CategoryThresholds[%q] must be positive", ErrInvalidConfig, cat)
+		}
+	}
+	if len(c.CategoryThresholds) > 0 && c.ErrorClassifier == nil {
+		return fmt.Errorf("%w: CategoryThresholds requires ErrorClassifier", ErrInvalidConfig)
+	}
+	if c.SlowCallThreshold < 0 || c.SlowCallWindow < 0 {
+		return fmt.Errorf("%w: SlowCallThreshold and SlowCallWindow must not be negative", ErrInvalidConfig)
+	}
+	if c.SlowCallRatePercent > 100 {
+		return fmt.Errorf("%w: SlowCallRatePercent must be at most 100, got %d", ErrInvalidConfig, c.SlowCallRatePercent)
+	}
+	if c.MaxConcurrentQueue < 0 {
+		return fmt.Errorf("%w: MaxConcurrentQueue must not be negative, got %d", ErrInvalidConfig, c.MaxConcurrentQueue)
+	}
+	if c.MaxConcurrentQueue > 0 && c.MaxConcurrent == 0 {
+		return fmt.Errorf("%w: MaxConcurrentQueue requires MaxConcurrent", ErrInvalidConfig)
+	}
+	if c.WarmUpDuration < 0 {
+		return fmt.Errorf("%w: WarmUpDuration must not be negative, got %s", ErrInvalidConfig, c.WarmUpDuration)
+	}
+	if c.WarmUpStartPercent > 100 {
+		return fmt.Errorf("%w: WarmUpStartPercent must be at most 100, got %d", ErrInvalidConfig, c.WarmUpStartPercent)
+	}
+	if c.ShedPriority < 0 || c.ShedPriority > PriorityLow {
+		return fmt.Errorf("%w: unknown ShedPriority %d", ErrInvalidConfig, c.ShedPriority)
+	}
+	if c.ShedFailurePercent > 100 {
+		return fmt.Errorf("%w: ShedFailurePercent must be at most 100, got %d", ErrInvalidConfig, c.ShedFailurePercent)
+	}
+	if c.SaturationPercent > 100 {
+		return fmt.Errorf("%w: SaturationPercent must be at most 100, got %d", ErrInvalidConfig, c.SaturationPercent)
+	}
+	if c.HalfOpenQueueSize < 0 {
+		return fmt.Errorf("%w: HalfOpenQueueSize must not be negative, got %d", ErrInvalidConfig, c.HalfOpenQueueSize)
+	}
+	if c.HalfOpenFairness && c.HalfOpenMaxConcurrent == 0 {
+		return fmt.Errorf("%w: HalfOpenFairness requires HalfOpenMaxConcurrent", ErrInvalidConfig)
+	}
+	if c.HealthProbeInterval < 0 || c.HealthProbeTimeout < 0 {
+		return fmt.Errorf("%w: HealthProbeInterval and HealthProbeTimeout must not be negative", ErrInvalidConfig)
+	}
+	for k := range c.Labels {
+		if k == "" || strings.Contains(k, ":") {
+			return fmt.Errorf("%w: label key %q must be non-empty and must not contain ':'", ErrInvalidConfig, k)
+		}
+	}
+	if c.EventsBuffer < 0 {
+		return fmt.Errorf("%w: EventsBuffer must not be negative, got %d", ErrInvalidConfig, c.EventsBuffer)
+	}
+	if c.EventsPolicy < EventsDropNewest || c.EventsPolicy > EventsBlock {
+		return fmt.Errorf("%w: unknown EventsPolicy %d", ErrInvalidConfig, c.EventsPolicy)
+	}
+	if c.EventsBlockTimeout < 0 {
+		return fmt.Errorf("%w: EventsBlockTimeout must not be negative, got %s", ErrInvalidConfig, c.EventsBlockTimeout)
+	}
+	if c.RejectionSummaryInterval < 0 {
+		return fmt.Errorf("%w: RejectionSummaryInterval must not be negative, got %s", ErrInvalidConfig, c.RejectionSummaryInterval)
+	}
+	if c.DeadlinePercentile > 99 {
+		return fmt.Errorf("%w: DeadlinePercentile must be at most 99, got %d", ErrInvalidConfig, c.DeadlinePercentile)
+	}
+	if c.DeadlinePercentile > 0 && c.StatsWindow <= 0 {
+		return fmt.Errorf("%w: DeadlinePercentile requires StatsWindow", ErrInvalidConfig)
+	}
+	if c.StatsWindow < 0 {
+		return fmt.Errorf("%w: StatsWindow must not be negative, got %s", ErrInvalidConfig, c.StatsWindow)
+	}
+	for _, w := range c.SLIWindows {
+		if w <= 0 {
+			return fmt.Errorf("%w: SLIWindows entries must be positive, got %s", ErrInvalidConfig, w)
+		}
+	}
+	return nil
+}
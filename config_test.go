@@ -0,0 +1,123 @@
+package sparkgap_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/afk-ankit/sparkgap"
+)
+
+func setDefaultConfig(t *testing.T, cfg *sparkgap.BreakerConfig) {
+	t.Helper()
+	if err := sparkgap.SetDefaultConfig(cfg); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { sparkgap.SetDefaultConfig(nil) })
+}
+
+func TestSetDefaultConfig(t *testing.T) {
+	fleet := &sparkgap.BreakerConfig{
+		FailureThreshold:  7,
+		RetryInterval:     30 * time.Second,
+		Timeout:           time.Second,
+		HalfOpenMaxProbes: 20,
+	}
+	cases := []struct {
+		name string
+		cfg  *sparkgap.BreakerConfig
+		want func(s sparkgap.BreakerSnapshot) bool
+	}{
+		{
+			name: "nil config takes the fleet defaults",
+			cfg:  nil,
+			want: func(s sparkgap.BreakerSnapshot) bool {
+				return s.FailureThreshold == 7 && s.RetryInterval == 30*time.Second && s.Timeout == time.Second && s.HalfOpenMaxProbes == 20
+			},
+		},
+		{
+			name: "set fields win",
+			cfg:  &sparkgap.BreakerConfig{FailureThreshold: 2, Timeout: 3 * time.Second},
+			want: func(s sparkgap.BreakerSnapshot) bool {
+				return s.FailureThreshold == 2 && s.Timeout == 3*time.Second && s.RetryInterval == 30*time.Second
+			},
+		},
+		{
+			name: "own Half-Open mode skips the fleet's Half-Open fields",
+			cfg:  &sparkgap.BreakerConfig{HalfOpenMode: sparkgap.HalfOpenConsecutive, SuccessThreshold: 4},
+			want: func(s sparkgap.BreakerSnapshot) bool {
+				return s.HalfOpenMode == sparkgap.HalfOpenConsecutive && s.SuccessThreshold == 4 && s.FailureThreshold == 7
+			},
+		},
+		{
+			name: "own Half-Open tuning skips the fleet's",
+			cfg:  &sparkgap.BreakerConfig{HalfOpenMaxFailurePercent: 50},
+			want: func(s sparkgap.BreakerSnapshot) bool {
+				return s.HalfOpenMaxFailurePercent == 50 && s.HalfOpenMaxProbes == 10
+			},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			setDefaultConfig(t, fleet)
+			br, err := sparkgap.NewCircuitBreaker("merged", tc.cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer br.Close()
+			if s := br.Snapshot(); !tc.want(s) {
+				t.Fatalf("unexpected config: %+v", s)
+			}
+		})
+	}
+}
+
+func TestSetDefaultConfigConsecutiveFleet(t *testing.T) {
+	setDefaultConfig(t, &sparkgap.BreakerConfig{HalfOpenMode: sparkgap.HalfOpenConsecutive, SuccessThreshold: 5})
+	br, err := sparkgap.NewCircuitBreaker("percentage", &sparkgap.BreakerConfig{HalfOpenMaxProbes: 8})
+	if err != nil {
+		t.Fatalf("breaker in percentage mode under a consecutive fleet default: %v", err)
+	}
+	defer br.Close()
+	if s := br.Snapshot(); s.HalfOpenMode != sparkgap.HalfOpenPercentage || s.HalfOpenMaxProbes != 8 {
+		t.Fatalf("Half-Open mode %s with %d probes, want percentage with 8", s.HalfOpenMode, s.HalfOpenMaxProbes)
+	}
+}
+
+func TestSetDefaultConfigPaths(t *testing.T) {
+	setDefaultConfig(t, &sparkgap.BreakerConfig{FailureThreshold: 9})
+	reg := sparkgap.NewRegistry()
+	if err := reg.DefineTemplate("db", &sparkgap.BreakerConfig{Timeout: time.Second}); err != nil {
+		t.Fatal(err)
+	}
+	fromTemplate, err := reg.NewFromTemplate("db", "db.primary")
+	if err != nil {
+		t.Fatal(err)
+	}
+	group, err := sparkgap.NewGroup("hosts", nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer group.Close()
+	breakers := map[string]sparkgap.Managed{
+		"InitBreaker":     sparkgap.InitBreaker[int]("init", nil),
+		"NewFromTemplate": fromTemplate,
+		"Group":           group.Get("a"),
+	}
+	for path, br := range breakers {
+		if got := br.Snapshot().FailureThreshold; got != 9 {
+			t.Errorf("%s: FailureThreshold = %d, want 9", path, got)
+		}
+		br.Close()
+	}
+}
+
+func TestSetDefaultConfigInvalid(t *testing.T) {
+	err := sparkgap.SetDefaultConfig(&sparkgap.BreakerConfig{RetryInterval: -time.Second})
+	if !errors.Is(err, sparkgap.ErrInvalidConfig) {
+		t.Fatalf("SetDefaultConfig = %v, want ErrInvalidConfig", err)
+	}
+	if sparkgap.DefaultConfig() != nil {
+		t.Fatal("invalid defaults were stored")
+	}
+}
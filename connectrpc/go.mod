@@ -0,0 +1,20 @@
+module github.com/afk-ankit/sparkgap/connectrpc
+
+go 1.24.4
+
+require (
+	connectrpc.com/connect v1.19.1
+	github.com/afk-ankit/sparkgap v0.0.0
+)
+
+require (
+	github.com/jedib0t/go-pretty/v6 v6.6.8 // indirect
+	github.com/mattn/go-runewidth v0.0.16 // indirect
+	github.com/rivo/uniseg v0.4.7 // indirect
+	golang.org/x/sys v0.30.0 // indirect
+	golang.org/x/text v0.22.0 // indirect
+	google.golang.org/protobuf v1.36.9 // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
+)
+
+replace github.com/afk-ankit/sparkgap => ../
@@ -0,0 +1,164 @@
+/*
+Package connectrpc is a client-side connect-go interceptor running RPCs through a sparkgap
+breaker, so a failing service is cut off instead of every client waiting out its deadlines:
+
+	client := pingv1connect.NewPingServiceClient(http.DefaultClient, url,
+		connect.WithInterceptors(connectrpc.NewInterceptor(cb)))
+
+Unary calls count once each. A streaming call counts once for the whole stream: it fails on the
+first failure code it receives, and succeeds if it ends cleanly or the response is closed.
+Rejected calls fail with connect.CodeUnavailable, wrapping the *sparkgap.RejectionError.
+Twirp clients are plain HTTP clients, so protect them with httpx.Transport instead.
+*/
+package connectrpc
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"sync"
+
+	"connectrpc.com/connect"
+
+	"github.com/afk-ankit/sparkgap"
+)
+
+/*
+IsFailure is the default classifier: Unavailable, DeadlineExceeded, ResourceExhausted, Internal,
+Unknown and DataLoss, which point at the server or the network, are failures. Other codes,
+such as NotFound or InvalidArgument, show the server is up, and Canceled is the caller's doing.
+*/
+func IsFailure(err error) bool {
+	switch connect.CodeOf(err) {
+	case connect.CodeUnavailable, connect.CodeDeadlineExceeded, connect.CodeResourceExhausted,
+		connect.CodeInternal, connect.CodeUnknown, connect.CodeDataLoss:
+		return true
+	}
+	return false
+}
+
+// Option configures NewInterceptor.
+type Option func(*Interceptor)
+
+// WithClassifier replaces IsFailure as the classifier deciding which errors count against the breaker.
+func WithClassifier(c sparkgap.Classifier) Option {
+	return func(i *Interceptor) { i.isFailure = c }
+}
+
+// Interceptor is a connect.Interceptor guarding client calls with a breaker. Handlers are left alone.
+type Interceptor struct {
+	br        *sparkgap.CircuitBreaker
+	isFailure sparkgap.Classifier
+}
+
+var _ connect.Interceptor = (*Interceptor)(nil)
+
+// NewInterceptor returns an Interceptor for br.
+func NewInterceptor(br *sparkgap.CircuitBreaker, opts ...Option) *Interceptor {
+	i := &Interceptor{br: br, isFailure: IsFailure}
+	for _, opt := range opts {
+		opt(i)
+	}
+	return i
+}
+
+func rejected(err error) error {
+	return connect.NewError(connect.CodeUnavailable, err)
+}
+
+func (i *Interceptor) WrapUnary(next connect.UnaryFunc) connect.UnaryFunc {
+	return func(ctx context.Context, req connect.AnyRequest) (connect.AnyResponse, error) {
+		if !req.Spec().IsClient {
+			return next(ctx, req)
+		}
+		var (
+			resp    connect.AnyResponse
+			callErr error
+			called  bool
+		)
+		err := i.br.DoContext(ctx, func(ctx context.Context) error {
+			called = true
+			resp, callErr = next(ctx, req)
+			if callErr != nil && !i.isFailure(callErr) {
+				return nil
+			}
+			return callErr
+		})
+		if called {
+			return resp, callErr
+		}
+		return nil, rejected(err)
+	}
+}
+
+func (i *Interceptor) WrapStreamingClient(next connect.StreamingClientFunc) connect.StreamingClientFunc {
+	return func(ctx context.Context, spec connect.Spec) connect.StreamingClientConn {
+		done, err := i.br.AllowContext(ctx)
+		if err != nil {
+			return &rejectedConn{spec: spec, err: rejected(err)}
+		}
+		return &streamConn{StreamingClientConn: next(ctx, spec), isFailure: i.isFailure, done: done}
+	}
+}
+
+func (i *Interceptor) WrapStreamingHandler(next connect.StreamingHandlerFunc) connect.StreamingHandlerFunc {
+	return next
+}
+
+// streamConn reports the outcome of a streaming call once it is known.
+type streamConn struct {
+	connect.StreamingClientConn
+	isFailure sparkgap.Classifier
+	done      func(success bool)
+	once      sync.Once
+}
+
+func (c *streamConn) finish(success bool) {
+	c.once.Do(func() { c.done(success) })
+}
+
+func (c *streamConn) observe(err error) error {
+	switch {
+	case errors.Is(err, io.EOF):
+		c.finish(true)
+	case err != nil && c.isFailure(err):
+		c.finish(false)
+	}
+	return err
+}
+
+func (c *streamConn) Send(msg any) error {
+	// Send reports io.EOF when the server ended the stream; Receive then returns the reason.
+	err := c.StreamingClientConn.Send(msg)
+	if errors.Is(err, io.EOF) {
+		return err
+	}
+	return c.observe(err)
+}
+
+func (c *streamConn) Receive(msg any) error {
+	return c.observe(c.StreamingClientConn.Receive(msg))
+}
+
+func (c *streamConn) CloseResponse() error {
+	err := c.StreamingClientConn.CloseResponse()
+	c.finish(true)
+	return err
+}
+
+// rejectedConn is the stream handed out while the breaker rejects calls; every operation fails.
+type rejectedConn struct {
+	spec connect.Spec
+	err  error
+}
+
+func (c *rejectedConn) Spec() connect.Spec           { return c.spec }
+func (c *rejectedConn) Peer() connect.Peer           { return connect.Peer{} }
+func (c *rejectedConn) Send(any) error               { return c.err }
+func (c *rejectedConn) RequestHeader() http.Header   { return http.Header{} }
+func (c *rejectedConn) CloseRequest() error          { return nil }
+func (c *rejectedConn) Receive(any) error            { return c.err }
+func (c *rejectedConn) ResponseHeader() http.Header  { return http.Header{} }
+func (c *rejectedConn) ResponseTrailer() http.Header { return http.Header{} }
+func (c *rejectedConn) CloseResponse() error         { return nil }
@@ -0,0 +1,100 @@
+/*
+Package consumer protects message handlers, such as Kafka or queue consumers, with a sparkgap
+breaker. While the breaker is open, or forced open, the consumer pauses and holds on to the current message
+instead of failing it, so a downstream outage does not burn through retries and dead-letter a
+whole backlog. It works with any client that can pause and resume fetching.
+*/
+package consumer
+
+import (
+	"context"
+	"time"
+
+	"github.com/afk-ankit/sparkgap"
+)
+
+const defaultPollInterval = time.Second
+
+// Consumer wraps a message handler with a breaker.
+type Consumer[M any] struct {
+	// Breaker guards every Handle call.
+	Breaker *sparkgap.CircuitBreaker
+	// Handle processes one message.
+	Handle func(ctx context.Context, msg M) error
+	// Pause and Resume, if set, stop and restart fetching from the partition or queue while
+	// the breaker is open, e.g. by calling the client's pause API.
+	Pause  func()
+	Resume func()
+	// PollInterval caps how long Process waits before checking the breaker again, e.g. while
+	// Half-Open probe slots are taken. Zero means one second.
+	PollInterval time.Duration
+}
+
+/*
+Process runs Handle for msg through the breaker. If the breaker rejects the call, Process
+pauses the consumer, waits until the breaker is due to probe again and retries the same
+message, resuming once a call is admitted. A closed breaker's rejection is returned as is. It returns Handle's error, which the breaker has
+already counted, or ctx.Err() if ctx ends while waiting.
+*/
+func (c *Consumer[M]) Process(ctx context.Context, msg M) error {
+	paused := false
+	defer func() {
+		if paused && c.Resume != nil {
+			c.Resume()
+		}
+	}()
+	for {
+		err := c.Breaker.DoContext(ctx, func(ctx context.Context) error {
+			if paused {
+				paused = false
+				if c.Resume != nil {
+					c.Resume()
+				}
+			}
+			return c.Handle(ctx, msg)
+		})
+		if !blocked(err) {
+			return err
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if !paused {
+			paused = true
+			if c.Pause != nil {
+				c.Pause()
+			}
+		}
+		if err := c.wait(ctx); err != nil {
+			return err
+		}
+	}
+}
+
+// wait sleeps until the breaker is due to go Half-Open, but at most PollInterval.
+func (c *Consumer[M]) wait(ctx context.Context) error {
+	d := c.PollInterval
+	if d <= 0 {
+		d = defaultPollInterval
+	}
+	if until := c.Breaker.UntilHalfOpen(); until > 0 {
+		d = min(d, until)
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// blocked reports whether err is a rejection the consumer should wait out rather than return.
+func blocked(err error) bool {
+	switch reason, _ := sparkgap.Reason(err); reason {
+	case sparkgap.ReasonOpen, sparkgap.ReasonForcedOpen, sparkgap.ReasonProbeQuotaExceeded:
+		return true
+	}
+	return false
+}
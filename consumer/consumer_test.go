@@ -0,0 +1,97 @@
+package consumer_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/afk-ankit/sparkgap"
+	"github.com/afk-ankit/sparkgap/consumer"
+)
+
+var errDown = errors.New("downstream down")
+
+// newConsumer returns a consumer whose breaker opens on the first failure and probes again after retry.
+func newConsumer(t *testing.T, retry time.Duration, handle func(context.Context, string) error) (*consumer.Consumer[string], *[]string) {
+	t.Helper()
+	br, err := sparkgap.NewCircuitBreaker(t.Name(), &sparkgap.BreakerConfig{
+		FailureThreshold: 1,
+		RetryInterval:    retry,
+		HalfOpenMode:     sparkgap.HalfOpenConsecutive,
+		SuccessThreshold: 1,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { br.Close() })
+	var flow []string
+	return &consumer.Consumer[string]{
+		Breaker:      br,
+		Handle:       handle,
+		Pause:        func() { flow = append(flow, "pause") },
+		Resume:       func() { flow = append(flow, "resume") },
+		PollInterval: time.Millisecond,
+	}, &flow
+}
+
+func TestProcess(t *testing.T) {
+	var handled []string
+	c, flow := newConsumer(t, time.Minute, func(_ context.Context, msg string) error {
+		handled = append(handled, msg)
+		if msg == "bad" {
+			return errDown
+		}
+		return nil
+	})
+	if err := c.Process(context.Background(), "ok"); err != nil {
+		t.Fatalf("Process(ok): %v", err)
+	}
+	if err := c.Process(context.Background(), "bad"); !errors.Is(err, errDown) {
+		t.Fatalf("Process(bad) = %v, want the handler's error", err)
+	}
+	if st := c.Breaker.State(); st != sparkgap.StateOpen {
+		t.Fatalf("state = %s, want Open", st)
+	}
+	if len(handled) != 2 || len(*flow) != 0 {
+		t.Fatalf("handled %v with pauses %v, want both messages handled without pausing", handled, *flow)
+	}
+}
+
+func TestProcessWaitsOutOpenBreaker(t *testing.T) {
+	handled := 0
+	c, flow := newConsumer(t, 20*time.Millisecond, func(context.Context, string) error {
+		handled++
+		return nil
+	})
+	c.Breaker.Trip()
+	start := time.Now()
+	if err := c.Process(context.Background(), "msg"); err != nil {
+		t.Fatalf("Process: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 20*time.Millisecond {
+		t.Fatalf("Process returned after %s, before the breaker went Half-Open", elapsed)
+	}
+	if handled != 1 || len(*flow) != 2 || (*flow)[0] != "pause" || (*flow)[1] != "resume" {
+		t.Fatalf("handled %d times with %v, want once after pause and resume", handled, *flow)
+	}
+	if st := c.Breaker.State(); st != sparkgap.StateClosed {
+		t.Fatalf("state = %s, want Closed after the probe", st)
+	}
+}
+
+func TestProcessCtxDoneWhilePaused(t *testing.T) {
+	c, flow := newConsumer(t, time.Minute, func(context.Context, string) error {
+		t.Error("handled a message while the breaker was open")
+		return nil
+	})
+	c.Breaker.Trip()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := c.Process(ctx, "msg"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Process = %v, want the context's error", err)
+	}
+	if len(*flow) != 2 || (*flow)[1] != "resume" {
+		t.Fatalf("flow = %v, want the consumer resumed on return", *flow)
+	}
+}
@@ -0,0 +1,106 @@
+package sparkgap
+
+import "sync/atomic"
+
+/*
+counterView is a consistent copy of the counters of a breaker. Counters are updated atomically
+so hot paths can read them without locking, but every update also holds counter.mu, and views
+are taken under it, so a snapshot never mixes values from before and after an update made by
+this process.
+*/
+type counterView struct {
+	failureCount         uint32
+	failureWeight        uint64
+	halfOpenSuccessCount uint32
+	halfOpenFailureCount uint32
+	categories           map[ErrorCategory]uint32
+}
+
+func (br *CircuitBreaker) readCounters() counterView {
+	c := &br.counter
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	v := counterView{
+		failureCount:         c.counts.Load(CountFailures),
+		failureWeight:        c.failureWeight.Load(),
+		halfOpenSuccessCount: c.counts.Load(CountProbeSuccesses),
+		halfOpenFailureCount: c.counts.Load(CountProbeFailures),
+	}
+	if br.categories != nil {
+		v.categories = make(map[ErrorCategory]uint32, len(br.categories))
+		for cat, cc := range br.categories {
+			v.categories[cat] = cc.count.Load()
+		}
+	}
+	return v
+}
+
+/*
+countFailure adds a Closed-state failure weighing w to cat, or to the general counter if cat is
+nil, and returns the cause to trip for if that reached its threshold.
+*/
+func (br *CircuitBreaker) countFailure(cat *categoryCounter, w uint64) Cause {
+	c := &br.counter
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if cat != nil {
+		if cat.count.Add(1) >= cat.threshold {
+			return CauseCategoryThreshold
+		}
+		return ""
+	}
+	n := c.counts.Add(CountFailures, 1)
+	if br.weigh != nil {
+		if br.weightReached(c.failureWeight.Add(w)) {
+			return CauseFailureThreshold
+		}
+		return ""
+	}
+	if n >= atomic.LoadUint32(&c.failureThreshold) {
+		return CauseFailureThreshold
+	}
+	return ""
+}
+
+// resetFailures clears the Closed-state failure counters, without locking if they are already clear.
+func (br *CircuitBreaker) resetFailures() {
+	c := &br.counter
+	if c.counts.Load(CountFailures) == 0 && c.failureWeight.Load() == 0 && !br.categoryFailing() {
+		return
+	}
+	c.mu.Lock()
+	c.counts.Store(CountFailures, 0)
+	c.failureWeight.Store(0)
+	for _, cc := range br.categories {
+		cc.count.Store(0)
+	}
+	c.mu.Unlock()
+}
+
+func (br *CircuitBreaker) categoryFailing() bool {
+	for _, cc := range br.categories {
+		if cc.count.Load() > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func (br *CircuitBreaker) resetHalfOpenCounts() {
+	c := &br.counter
+	c.mu.Lock()
+	c.counts.Store(CountProbeFailures, 0)
+	c.counts.Store(CountProbeSuccesses, 0)
+	c.mu.Unlock()
+}
+
+// countProbe adds a Half-Open result and returns the updated success and failure counts.
+func (br *CircuitBreaker) countProbe(success bool) (succ, fail uint32) {
+	c := &br.counter
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if success {
+		return c.counts.Add(CountProbeSuccesses, 1), c.counts.Load(CountProbeFailures)
+	}
+	return c.counts.Load(CountProbeSuccesses), c.counts.Add(CountProbeFailures, 1)
+}
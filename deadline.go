@@ -0,0 +1,59 @@
+package sparkgap
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// deadlineMinCalls is how many calls the stats window must hold before deadlines are judged.
+const deadlineMinCalls = 10
+
+/*
+deadlineGuard caches the DeadlinePercentile duration of recent calls. Summing the stats window
+is too costly for every call, so the value is refreshed at most once per stats bucket.
+*/
+type deadlineGuard struct {
+	percentile uint64
+	every      time.Duration
+
+	mu        sync.Mutex
+	typical   time.Duration
+	refreshed time.Time
+}
+
+func newDeadlineGuard(percentile uint32, window time.Duration) *deadlineGuard {
+	if percentile == 0 || window <= 0 {
+		return nil
+	}
+	return &deadlineGuard{percentile: uint64(percentile), every: max(window/statsBuckets, time.Millisecond)}
+}
+
+// deadlineTooShort reports whether ctx expires before a call would typically finish.
+func (br *CircuitBreaker) deadlineTooShort(ctx context.Context) bool {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return false
+	}
+	now := br.clock.Now()
+	typical := br.deadline.typicalAt(now, br.stats)
+	return typical > 0 && deadline.Sub(now) < typical
+}
+
+// typicalAt returns the configured percentile of call durations in w, or 0 with too few calls.
+func (g *deadlineGuard) typicalAt(now time.Time, w *statsWindow) time.Duration {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if now.Sub(g.refreshed) < g.every {
+		return g.typical
+	}
+	g.refreshed = now
+	s, hist := w.sum(now)
+	g.typical = 0
+	if calls := s.Successes + s.Failures; calls >= deadlineMinCalls {
+		// percentile returns the upper bound of a power-of-two bin; its lower bound keeps
+		// calls that might still make it from being rejected.
+		g.typical = percentile(hist, calls, g.percentile) / 2
+	}
+	return g.typical
+}
@@ -0,0 +1,85 @@
+package sparkgap_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/afk-ankit/sparkgap"
+	"github.com/afk-ankit/sparkgap/sparkgaptest"
+)
+
+// slowCalls runs n successful calls through br that each take d on clock.
+func slowCalls(br *sparkgap.CircuitBreaker, clock *sparkgaptest.FakeClock, n int, d time.Duration) {
+	for range n {
+		_ = br.Do(func() error { clock.Advance(d); return nil })
+	}
+}
+
+func callWithin(br *sparkgap.CircuitBreaker, clock *sparkgaptest.FakeClock, budget time.Duration) error {
+	ctx, cancel := context.WithDeadline(context.Background(), clock.Now().Add(budget))
+	defer cancel()
+	return br.DoContext(ctx, func(context.Context) error { return nil })
+}
+
+func TestDeadlineGuard(t *testing.T) {
+	cases := []struct {
+		name   string
+		calls  int
+		budget time.Duration
+		reject bool
+	}{
+		{"too few calls to judge", 9, time.Millisecond, false},
+		{"budget below the median", 20, time.Millisecond, true},
+		{"budget above the median", 20, time.Second, false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			br, clock := newTestBreaker(t, sparkgap.BreakerConfig{StatsWindow: time.Minute, DeadlinePercentile: 50})
+			slowCalls(br, clock, tc.calls, 200*time.Millisecond)
+			clock.Advance(time.Second) // let the guard refresh its cached percentile
+
+			err := callWithin(br, clock, tc.budget)
+			if got := errors.Is(err, sparkgap.ErrDeadlineTooShort); got != tc.reject {
+				t.Fatalf("call with %s left = %v, want rejected %t", tc.budget, err, tc.reject)
+			}
+			if tc.reject {
+				if code, _ := sparkgap.Reason(err); code != sparkgap.ReasonDeadlineTooShort {
+					t.Fatalf("reason = %q, want %q", code, sparkgap.ReasonDeadlineTooShort)
+				}
+			}
+		})
+	}
+}
+
+func TestDeadlineGuardNoDeadline(t *testing.T) {
+	br, clock := newTestBreaker(t, sparkgap.BreakerConfig{StatsWindow: time.Minute, DeadlinePercentile: 50})
+	slowCalls(br, clock, 20, 200*time.Millisecond)
+	clock.Advance(time.Second)
+	if err := br.DoContext(context.Background(), func(context.Context) error { return nil }); err != nil {
+		t.Fatalf("call without a deadline: %v", err)
+	}
+}
+
+func TestDeadlineGuardSkipsProbes(t *testing.T) {
+	br, clock := newTestBreaker(t, sparkgap.BreakerConfig{
+		StatsWindow:        time.Minute,
+		DeadlinePercentile: 50,
+		RetryInterval:      time.Second,
+		HalfOpenMode:       sparkgap.HalfOpenConsecutive,
+		SuccessThreshold:   1,
+	})
+	slowCalls(br, clock, 20, 200*time.Millisecond)
+	br.Trip()
+	clock.Advance(time.Second)
+	sparkgaptest.RequireState(t, br, sparkgap.StateHalfOpen)
+
+	if err := callWithin(br, clock, time.Millisecond); err != nil {
+		t.Fatalf("probe with a short deadline: %v", err)
+	}
+	sparkgaptest.RequireState(t, br, sparkgap.StateClosed)
+	if err := callWithin(br, clock, time.Millisecond); !errors.Is(err, sparkgap.ErrDeadlineTooShort) {
+		t.Fatalf("call once closed = %v, want ErrDeadlineTooShort", err)
+	}
+}
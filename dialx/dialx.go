@@ -0,0 +1,77 @@
+/*
+Package dialx runs the dials of a connection pool through a sparkgap breaker per host:port, so
+dials to a dead host fail fast instead of queueing up behind connect timeouts while other hosts
+keep working. It fits anything that takes a dial function, such as http.Transport:
+
+	d := dialx.NewDialer(nil, func(addr string) *sparkgap.CircuitBreaker { return breakerFor("dial." + addr) })
+	client := &http.Client{Transport: &http.Transport{DialContext: d.DialContext}}
+*/
+package dialx
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+
+	"github.com/afk-ankit/sparkgap"
+)
+
+// DialFunc is the signature of net.Dialer.DialContext and http.Transport.DialContext.
+type DialFunc func(ctx context.Context, network, addr string) (net.Conn, error)
+
+// Dialer wraps a DialFunc with one breaker per address dialed.
+type Dialer struct {
+	dial       DialFunc
+	newBreaker func(addr string) *sparkgap.CircuitBreaker
+
+	mu       sync.Mutex
+	breakers map[string]*sparkgap.CircuitBreaker
+}
+
+/*
+NewDialer returns a Dialer dialing with dial, or a zero net.Dialer if dial is nil. newBreaker is
+called once for every host:port the first time it is dialed; the breaker it returns guards
+all later dials to that address.
+*/
+func NewDialer(dial DialFunc, newBreaker func(addr string) *sparkgap.CircuitBreaker) *Dialer {
+	if dial == nil {
+		dial = (&net.Dialer{}).DialContext
+	}
+	return &Dialer{dial: dial, newBreaker: newBreaker, breakers: make(map[string]*sparkgap.CircuitBreaker)}
+}
+
+/*
+DialContext dials addr through its breaker. While the breaker is open it returns the
+*sparkgap.RejectionError without dialing. Failed dials count against the breaker, except those
+cancelled by the caller; the breaker's Timeout, if set, bounds every dial.
+*/
+func (d *Dialer) DialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	var (
+		conn    net.Conn
+		dialErr error
+	)
+	err := d.Breaker(addr).DoContext(ctx, func(ctx context.Context) error {
+		conn, dialErr = d.dial(ctx, network, addr)
+		if dialErr != nil && errors.Is(dialErr, context.Canceled) {
+			return nil
+		}
+		return dialErr
+	})
+	if dialErr != nil {
+		return nil, dialErr
+	}
+	return conn, err
+}
+
+// Breaker returns the breaker guarding dials to addr, creating it if addr has not been dialed.
+func (d *Dialer) Breaker(addr string) *sparkgap.CircuitBreaker {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	br, ok := d.breakers[addr]
+	if !ok {
+		br = d.newBreaker(addr)
+		d.breakers[addr] = br
+	}
+	return br
+}
@@ -0,0 +1,87 @@
+package dialx_test
+
+import (
+	"context"
+	"errors"
+	"net"
+	"syscall"
+	"testing"
+
+	"github.com/afk-ankit/sparkgap"
+	"github.com/afk-ankit/sparkgap/dialx"
+)
+
+// fakeDial fails dials to addresses in down and hands out one end of a pipe otherwise.
+func fakeDial(down map[string]bool, dials *int) dialx.DialFunc {
+	return func(ctx context.Context, _, addr string) (net.Conn, error) {
+		*dials++
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if down[addr] {
+			return nil, &net.OpError{Op: "dial", Net: "tcp", Err: syscall.ECONNREFUSED}
+		}
+		c, s := net.Pipe()
+		s.Close()
+		return c, nil
+	}
+}
+
+func newDialer(t *testing.T, dial dialx.DialFunc) *dialx.Dialer {
+	t.Helper()
+	return dialx.NewDialer(dial, func(addr string) *sparkgap.CircuitBreaker {
+		br, err := sparkgap.NewCircuitBreaker("dial."+addr, &sparkgap.BreakerConfig{FailureThreshold: 2})
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { br.Close() })
+		return br
+	})
+}
+
+func TestDialContext(t *testing.T) {
+	var dials int
+	d := newDialer(t, fakeDial(map[string]bool{"down:80": true}, &dials))
+	ctx := context.Background()
+
+	conn, err := d.DialContext(ctx, "tcp", "up:80")
+	if err != nil {
+		t.Fatalf("dial up:80: %v", err)
+	}
+	conn.Close()
+
+	for range 2 {
+		if _, err := d.DialContext(ctx, "tcp", "down:80"); !errors.Is(err, syscall.ECONNREFUSED) {
+			t.Fatalf("dial down:80 = %v, want the dial error", err)
+		}
+	}
+	before := dials
+	if _, err := d.DialContext(ctx, "tcp", "down:80"); !errors.Is(err, sparkgap.ErrOpen) || dials != before {
+		t.Fatalf("dial down:80 while open = %v, want ErrOpen without dialing", err)
+	}
+
+	// Each address has its own breaker.
+	if conn, err := d.DialContext(ctx, "tcp", "up:80"); err != nil {
+		t.Fatalf("dial up:80 after down:80 opened: %v", err)
+	} else {
+		conn.Close()
+	}
+	if d.Breaker("up:80") == d.Breaker("down:80") {
+		t.Fatal("addresses share a breaker")
+	}
+}
+
+func TestDialCanceled(t *testing.T) {
+	var dials int
+	d := newDialer(t, fakeDial(nil, &dials))
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	for range 3 {
+		if _, err := d.DialContext(ctx, "tcp", "up:80"); !errors.Is(err, context.Canceled) {
+			t.Fatalf("canceled dial = %v, want context.Canceled", err)
+		}
+	}
+	if st := d.Breaker("up:80").State(); st != sparkgap.StateClosed {
+		t.Fatalf("state = %s, want Closed: cancelled dials are not failures", st)
+	}
+}
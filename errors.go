@@ -0,0 +1,90 @@
+package sparkgap
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+var (
+	// ErrOpen is wrapped by the error Execute returns when the breaker is open and the call was not attempted.
+	ErrOpen = errors.New("circuit breaker is open")
+	// ErrClosed is wrapped by the error Execute returns once the breaker has been shut down with Close.
+	ErrClosed = errors.New("circuit breaker is closed")
+	// ErrShed is wrapped by rejections issued when a call is dropped to relieve a degraded dependency.
+	ErrShed = errors.New("call shed")
+	// ErrBulkheadFull is wrapped by rejections issued when the concurrency limit is reached.
+	ErrBulkheadFull = errors.New("bulkhead is full")
+	// ErrRateLimited is wrapped by rejections issued when the call rate limit is exceeded.
+	ErrRateLimited = errors.New("rate limit exceeded")
+	// ErrProbeQuotaExceeded is wrapped by rejections issued in Half-Open once the probe window is fully booked.
+	ErrProbeQuotaExceeded = errors.New("half-open probe quota exceeded")
+	// ErrDeadlineTooShort is wrapped by rejections issued when the caller's deadline leaves less
+	// time than calls typically take; see DeadlinePercentile.
+	ErrDeadlineTooShort = errors.New("deadline too short")
+	// ErrInvalidConfig is wrapped by the errors NewBreaker returns for unusable configurations.
+	ErrInvalidConfig = errors.New("invalid breaker config")
+)
+
+// ReasonCode is a machine-readable reason for rejecting a call, stable across releases.
+type ReasonCode string
+
+const (
+	ReasonOpen               ReasonCode = "open"
+	ReasonClosed             ReasonCode = "closed"
+	ReasonShed               ReasonCode = "shed"
+	ReasonBulkheadFull       ReasonCode = "bulkhead_full"
+	ReasonRateLimited        ReasonCode = "rate_limited"
+	ReasonProbeQuotaExceeded ReasonCode = "probe_quota_exceeded"
+	// ReasonThrottled marks calls turned away by a Throttled breaker; they wrap ErrShed.
+	ReasonThrottled ReasonCode = "throttled"
+	// ReasonWarmingUp marks calls turned away while a recovered breaker ramps traffic back up;
+	// they wrap ErrShed.
+	ReasonWarmingUp ReasonCode = "warming_up"
+	// ReasonDeadlineTooShort marks calls rejected with ErrDeadlineTooShort.
+	ReasonDeadlineTooShort ReasonCode = "deadline_too_short"
+	// ReasonForcedOpen marks rejections by a breaker pinned open with ForceOpen; they wrap ErrOpen.
+	ReasonForcedOpen ReasonCode = "forced_open"
+)
+
+/*
+RejectionError is returned whenever a call is rejected without being attempted. It unwraps to
+the matching sentinel, so errors.Is(err, ErrOpen) keeps working, while Reason lets adapters map
+rejections to status codes and metrics without string matching.
+*/
+type RejectionError struct {
+	Breaker string
+	Reason  ReasonCode
+	Err     error
+	// RetryAfter is how long the open breaker waits before probing again, for Retry-After
+	// headers and client-side retries. It is zero for other rejections and when not known.
+	RetryAfter time.Duration
+}
+
+func (e *RejectionError) Error() string {
+	return fmt.Sprintf("%s: %v", e.Breaker, e.Err)
+}
+
+func (e *RejectionError) Unwrap() error { return e.Err }
+
+// Reason returns the ReasonCode of err if it is, or wraps, a *RejectionError.
+func Reason(err error) (ReasonCode, bool) {
+	var re *RejectionError
+	if errors.As(err, &re) {
+		return re.Reason, true
+	}
+	return "", false
+}
+
+// RetryAfter returns the RetryAfter of err if it is, or wraps, a *RejectionError with one set.
+func RetryAfter(err error) (time.Duration, bool) {
+	var re *RejectionError
+	if errors.As(err, &re) && re.RetryAfter > 0 {
+		return re.RetryAfter, true
+	}
+	return 0, false
+}
+
+func reject(name string, reason ReasonCode, err error) *RejectionError {
+	return &RejectionError{Breaker: name, Reason: reason, Err: err}
+}
@@ -0,0 +1,191 @@
+package sparkgap
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+/*
+Rotation decides when a RotatingFile starts a new file. The current file is renamed to its path
+with the time of rotation appended, e.g. events.jsonl.20260102T150405.000Z, and a fresh one is
+opened in its place. The zero Rotation never rotates.
+*/
+type Rotation struct {
+	// MaxBytes rotates the file before a write would take it past this size.
+	MaxBytes int64
+	// MaxAge rotates the file once its first write is older than this.
+	MaxAge time.Duration
+	// OnRotate, if set, is called with the path a file was renamed to, e.g. to compress or
+	// ship it. It runs synchronously while the file is locked, so slow work should be handed off.
+	OnRotate func(rotated string)
+	// Clock is used for MaxAge and to name rotated files. Nil means the system clock.
+	Clock Clock
+}
+
+/*
+EventWriter appends breaker events as JSON lines, giving a durable audit trail of trips
+without a metrics stack. It is a WriterRecorder over a RotatingFile, or over any io.Writer, so
+it can be set as BreakerConfig.FlightRecorder, or fed every breaker of a registry:
+
+	log, err := sparkgap.OpenEventLog("/var/log/app/breakers.jsonl", sparkgap.Rotation{MaxBytes: 64 << 20})
+	reg.Subscribe(func(ev sparkgap.Event) { _ = log.Record(ev) })
+
+It is safe for concurrent use.
+*/
+type EventWriter struct {
+	*WriterRecorder
+	w io.Writer
+}
+
+// NewEventWriter returns an EventWriter appending to w. It never rotates, and Close closes w if it is an io.Closer.
+func NewEventWriter(w io.Writer) *EventWriter {
+	return &EventWriter{WriterRecorder: NewWriterRecorder(w), w: w}
+}
+
+// OpenEventLog returns an EventWriter appending to a RotatingFile at path, rotated by r.
+func OpenEventLog(path string, r Rotation) (*EventWriter, error) {
+	f, err := OpenRotatingFile(path, r)
+	if err != nil {
+		return nil, err
+	}
+	return NewEventWriter(f), nil
+}
+
+// Rotate starts a new file now, e.g. on SIGHUP. It does nothing unless the EventWriter came from OpenEventLog.
+func (ew *EventWriter) Rotate() error {
+	if f, ok := ew.w.(*RotatingFile); ok {
+		return f.Rotate()
+	}
+	return nil
+}
+
+// Close closes the underlying file or writer. Later events fail with os.ErrClosed for a file.
+func (ew *EventWriter) Close() error {
+	if c, ok := ew.w.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
+
+/*
+RotatingFile is an io.WriteCloser appending to a file and rotating it by a Rotation. Every
+Write goes to one file, so every event an EventWriter records stays on one line of one file.
+It is safe for concurrent use.
+*/
+type RotatingFile struct {
+	mu       sync.Mutex
+	f        *os.File
+	path     string
+	rotation Rotation
+	size     int64
+	// first is the time of the first write to the current file.
+	first time.Time
+}
+
+// OpenRotatingFile returns a RotatingFile appending to the file at path, creating it if needed.
+func OpenRotatingFile(path string, r Rotation) (*RotatingFile, error) {
+	if r.Clock == nil {
+		r.Clock = realClock{}
+	}
+	rf := &RotatingFile{path: path, rotation: r}
+	if err := rf.openLocked(); err != nil {
+		return nil, err
+	}
+	return rf, nil
+}
+
+func (rf *RotatingFile) openLocked() error {
+	f, err := os.OpenFile(rf.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+	if err != nil {
+		return fmt.Errorf("sparkgap: opening %s: %w", rf.path, err)
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return fmt.Errorf("sparkgap: opening %s: %w", rf.path, err)
+	}
+	rf.f, rf.size, rf.first = f, info.Size(), time.Time{}
+	return nil
+}
+
+// Write appends p to the file, rotating it first if it is due.
+func (rf *RotatingFile) Write(p []byte) (int, error) {
+	rf.mu.Lock()
+	defer rf.mu.Unlock()
+	if rf.f == nil {
+		return 0, os.ErrClosed
+	}
+	now := rf.rotation.Clock.Now()
+	if rf.dueLocked(now, int64(len(p))) {
+		if err := rf.rotateLocked(now); err != nil {
+			return 0, err
+		}
+	}
+	n, err := rf.f.Write(p)
+	rf.size += int64(n)
+	if rf.first.IsZero() {
+		rf.first = now
+	}
+	return n, err
+}
+
+func (rf *RotatingFile) dueLocked(now time.Time, next int64) bool {
+	r := rf.rotation
+	if rf.size == 0 {
+		return false
+	}
+	return r.MaxBytes > 0 && rf.size+next > r.MaxBytes ||
+		r.MaxAge > 0 && !rf.first.IsZero() && now.Sub(rf.first) >= r.MaxAge
+}
+
+// Rotate starts a new file now, e.g. on SIGHUP, whatever the Rotation says.
+func (rf *RotatingFile) Rotate() error {
+	rf.mu.Lock()
+	defer rf.mu.Unlock()
+	if rf.f == nil {
+		return os.ErrClosed
+	}
+	return rf.rotateLocked(rf.rotation.Clock.Now())
+}
+
+func (rf *RotatingFile) rotateLocked(now time.Time) error {
+	if err := rf.f.Close(); err != nil {
+		return fmt.Errorf("sparkgap: rotating %s: %w", rf.path, err)
+	}
+	rf.f = nil
+	rotated := rf.path + "." + now.UTC().Format("20060102T150405.000Z")
+	if err := os.Rename(rf.path, rotated); err != nil {
+		// Keep appending to the current file rather than losing writes.
+		if oerr := rf.openLocked(); oerr != nil {
+			return oerr
+		}
+		return fmt.Errorf("sparkgap: rotating %s: %w", rf.path, err)
+	}
+	if err := rf.openLocked(); err != nil {
+		return err
+	}
+	if rf.rotation.OnRotate != nil {
+		rf.rotation.OnRotate(rotated)
+	}
+	return nil
+}
+
+// Close closes the file. Later writes fail with os.ErrClosed.
+func (rf *RotatingFile) Close() error {
+	rf.mu.Lock()
+	defer rf.mu.Unlock()
+	f := rf.f
+	rf.f = nil
+	if f == nil {
+		return nil
+	}
+	return f.Close()
+}
+
+var (
+	_ io.WriteCloser = (*RotatingFile)(nil)
+	_ Recorder       = (*EventWriter)(nil)
+)
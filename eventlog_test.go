@@ -0,0 +1,119 @@
+package sparkgap_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/afk-ankit/sparkgap"
+	"github.com/afk-ankit/sparkgap/sparkgaptest"
+)
+
+func testEvent(at time.Time) sparkgap.Event {
+	return sparkgap.Event{
+		Kind: sparkgap.EventStateChange, Breaker: "db", Time: at,
+		From: sparkgap.StateClosed, To: sparkgap.StateOpen,
+		Labels: map[string]string{"team": "payments"},
+	}
+}
+
+func TestEventWriter(t *testing.T) {
+	var buf bytes.Buffer
+	ew := sparkgap.NewEventWriter(&buf)
+	if err := ew.Record(testEvent(time.Unix(0, 0))); err != nil {
+		t.Fatal(err)
+	}
+	var got map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("line %q: %v", buf.String(), err)
+	}
+	if got["breaker"] != "db" || got["to"] != "Open" || got["labels"].(map[string]any)["team"] != "payments" {
+		t.Fatalf("unexpected line %s", buf.String())
+	}
+
+	var viaRecorder bytes.Buffer
+	_ = sparkgap.NewWriterRecorder(&viaRecorder).Record(testEvent(time.Unix(0, 0)))
+	if viaRecorder.String() != buf.String() {
+		t.Fatalf("EventWriter wrote %q, WriterRecorder %q", buf.String(), viaRecorder.String())
+	}
+}
+
+func TestEventLogRotation(t *testing.T) {
+	line, _ := json.Marshal(testEvent(time.Unix(0, 0)))
+	size := int64(len(line) + 1)
+	cases := []struct {
+		name     string
+		rotation sparkgap.Rotation
+		// step runs between events.
+		step    time.Duration
+		events  int
+		rotated int
+	}{
+		{"never", sparkgap.Rotation{}, time.Hour, 4, 0},
+		{"by size", sparkgap.Rotation{MaxBytes: 2 * size}, time.Second, 5, 2},
+		{"by age", sparkgap.Rotation{MaxAge: 90 * time.Second}, time.Minute, 4, 1},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := t.TempDir()
+			path := filepath.Join(dir, "events.jsonl")
+			clock := sparkgaptest.NewFakeClock(time.Date(2026, 1, 2, 15, 4, 5, 0, time.UTC))
+			var rotated []string
+			r := tc.rotation
+			r.Clock = clock
+			r.OnRotate = func(p string) { rotated = append(rotated, p) }
+			log, err := sparkgap.OpenEventLog(path, r)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for range tc.events {
+				if err := log.Record(testEvent(clock.Now())); err != nil {
+					t.Fatal(err)
+				}
+				clock.Advance(tc.step)
+			}
+			if err := log.Close(); err != nil {
+				t.Fatal(err)
+			}
+			if len(rotated) != tc.rotated {
+				t.Fatalf("rotated %d times (%v), want %d", len(rotated), rotated, tc.rotated)
+			}
+			lines := 0
+			files, _ := os.ReadDir(dir)
+			for _, f := range files {
+				data, _ := os.ReadFile(filepath.Join(dir, f.Name()))
+				lines += strings.Count(string(data), "\n")
+			}
+			if lines != tc.events {
+				t.Fatalf("%d lines across %d files, want %d", lines, len(files), tc.events)
+			}
+		})
+	}
+}
+
+func TestEventLogRotateNamesByClock(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.jsonl")
+	clock := sparkgaptest.NewFakeClock(time.Date(2026, 1, 2, 15, 4, 5, 0, time.UTC))
+	log, err := sparkgap.OpenEventLog(path, sparkgap.Rotation{Clock: clock})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer log.Close()
+	_ = log.Record(testEvent(clock.Now()))
+	if err := log.Rotate(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(path + ".20260102T150405.000Z"); err != nil {
+		t.Fatalf("rotated file: %v", err)
+	}
+	if err := log.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := log.Record(testEvent(clock.Now())); err == nil {
+		t.Fatal("Record after Close succeeded")
+	}
+}
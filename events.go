@@ -0,0 +1,362 @@
+package sparkgap
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// EventKind identifies what an Event reports.
+type EventKind int
+
+const (
+	// EventStateChange is emitted whenever the breaker moves between states.
+	EventStateChange EventKind = iota
+	// EventSnoozeEnded is the reminder emitted when a Snooze period is over.
+	EventSnoozeEnded
+	// EventCallSuccess is emitted after a call admitted by the breaker succeeded.
+	EventCallSuccess
+	// EventCallFailure is emitted after a call admitted by the breaker failed; Err holds its error.
+	EventCallFailure
+	// EventShortCircuit is emitted when a call is rejected without being attempted; Err holds
+	// the *RejectionError returned to the caller.
+	EventShortCircuit
+	// EventProbeResult is emitted for every call made while Half-Open, in addition to the
+	// success or failure event, and for every HealthProbe run while Open; Err is nil for a
+	// successful probe.
+	EventProbeResult
+	// EventSaturation is emitted when in-flight calls reach the saturation mark derived from
+	// SaturationLimit; InFlight holds the count. It fires again only after concurrency has dropped back.
+	EventSaturation
+	// EventRejectionSummary replaces the EventShortCircuit events of a breaker with a
+	// RejectionSummaryInterval: it is emitted at most once per interval, with Rejected and
+	// Rejections counting the calls rejected since the last summary and Elapsed the time covered.
+	EventRejectionSummary
+)
+
+func (k EventKind) String() string {
+	switch k {
+	case EventStateChange:
+		return "StateChange"
+	case EventSnoozeEnded:
+		return "SnoozeEnded"
+	case EventCallSuccess:
+		return "CallSuccess"
+	case EventCallFailure:
+		return "CallFailure"
+	case EventShortCircuit:
+		return "ShortCircuit"
+	case EventProbeResult:
+		return "ProbeResult"
+	case EventSaturation:
+		return "Saturation"
+	case EventRejectionSummary:
+		return "RejectionSummary"
+	default:
+		return "Unknown"
+	}
+}
+
+// MarshalText renders the kind by name.
+func (k EventKind) MarshalText() ([]byte, error) {
+	return []byte(k.String()), nil
+}
+
+// Event is a notification about something that happened to a breaker.
+type Event struct {
+	Kind    EventKind
+	Breaker string
+	Time    time.Time
+	// From and To are the states before and after the event; they are equal for events
+	// that do not change state.
+	From State
+	To   State
+	// Err is the error of a failed call or probe, or the rejection of a short-circuited call.
+	Err error
+	// Elapsed is how long the call took, for call and probe events.
+	Elapsed time.Duration
+	// InFlight is the number of calls in flight, for saturation events.
+	InFlight int64
+	// Cause is what triggered a state change.
+	Cause Cause
+	// Rejected is the number of calls rejected, and Rejections the same broken down by reason,
+	// for rejection summaries.
+	Rejected   uint64
+	Rejections map[ReasonCode]uint64
+	// Labels are the breaker's Labels. The map is shared and must not be modified.
+	Labels map[string]string
+	// snoozed marks events raised while the breaker is snoozed, which only reach the metrics
+	// reporter and the FlightRecorder.
+	snoozed bool
+}
+
+// EventsPolicy decides what a channel returned by Events does with an event that does not fit
+// because its reader has fallen behind. Dropped events are counted by DroppedEvents.
+type EventsPolicy int
+
+const (
+	// EventsDropNewest drops the event that does not fit. It is the default.
+	EventsDropNewest EventsPolicy = iota
+	// EventsDropOldest drops the oldest buffered event to make room, so readers catching up see
+	// the latest state.
+	EventsDropOldest
+	// EventsBlock makes the goroutine raising the event wait up to EventsBlockTimeout (default
+	// 10ms) for room, then drops it. It slows calls down while a reader lags, so the timeout
+	// should stay well below their latency budget.
+	EventsBlock
+)
+
+func (p EventsPolicy) String() string {
+	switch p {
+	case EventsDropNewest:
+		return "drop_newest"
+	case EventsDropOldest:
+		return "drop_oldest"
+	case EventsBlock:
+		return "block"
+	default:
+		return fmt.Sprintf("EventsPolicy(%d)", int(p))
+	}
+}
+
+// MarshalText renders the policy by name.
+func (p EventsPolicy) MarshalText() ([]byte, error) {
+	return []byte(p.String()), nil
+}
+
+// UnmarshalText parses a name produced by MarshalText.
+func (p *EventsPolicy) UnmarshalText(text []byte) error {
+	switch string(text) {
+	case "drop_newest", "":
+		*p = EventsDropNewest
+	case "drop_oldest":
+		*p = EventsDropOldest
+	case "block":
+		*p = EventsBlock
+	default:
+		return fmt.Errorf("unknown events policy %q", text)
+	}
+	return nil
+}
+
+const (
+	defaultEventsBuffer       = 256
+	defaultEventsBlockTimeout = 10 * time.Millisecond
+)
+
+type subscribers struct {
+	mu    sync.Mutex
+	next  int
+	fns   map[int]subscriber
+	chans []*eventChan
+	count atomic.Int32
+	// buffer, policy and timeout configure the channels returned by Events.
+	buffer  int
+	policy  EventsPolicy
+	timeout time.Duration
+	// dropped counts the events channels could not take.
+	dropped atomic.Uint64
+}
+
+type subscriber struct {
+	fn func(Event)
+	// always subscribers also get the events raised while the breaker is snoozed.
+	always bool
+}
+
+type eventChan struct {
+	mu      sync.Mutex
+	ch      chan Event
+	closed  bool
+	policy  EventsPolicy
+	timeout time.Duration
+	clock   Clock
+	dropped *atomic.Uint64
+}
+
+func (c *eventChan) send(ev Event) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		return
+	}
+	select {
+	case c.ch <- ev:
+		return
+	default:
+	}
+	switch c.policy {
+	case EventsDropOldest:
+		// Senders are serialized by mu and the reader only takes events out, so once the
+		// oldest is gone there is room.
+		select {
+		case <-c.ch:
+		default:
+		}
+		c.ch <- ev
+	case EventsBlock:
+		select {
+		case c.ch <- ev:
+			return
+		case <-c.clock.After(c.timeout):
+		}
+	}
+	c.dropped.Add(1)
+}
+
+func (c *eventChan) close() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.closed {
+		c.closed = true
+		close(c.ch)
+	}
+}
+
+/*
+Subscribe registers fn to be called for every event the breaker emits and returns a function
+that removes it again. fn runs synchronously on the goroutine that caused the event, after the
+breaker's internal lock has been released, so it may call back into the breaker but should
+return quickly.
+*/
+func (br *CircuitBreaker) Subscribe(fn func(Event)) (unsubscribe func()) {
+	return br.subscribe(fn, false)
+}
+
+// subscribe is Subscribe; always subscribers, such as metrics, are not muted by Snooze.
+func (br *CircuitBreaker) subscribe(fn func(Event), always bool) (unsubscribe func()) {
+	s := &br.subs
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.fns == nil {
+		s.fns = make(map[int]subscriber)
+	}
+	id := s.next
+	s.next++
+	s.fns[id] = subscriber{fn: fn, always: always}
+	s.count.Add(1)
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			s.mu.Lock()
+			delete(s.fns, id)
+			s.mu.Unlock()
+			s.count.Add(-1)
+		})
+	}
+}
+
+/*
+Events returns a buffered channel receiving every event the breaker emits. The channel holds
+EventsBuffer (default 256) events; once it is full, EventsPolicy decides which events are
+dropped so a slow reader does not hold up calls. The channel is closed by Close.
+*/
+func (br *CircuitBreaker) Events() <-chan Event {
+	s := &br.subs
+	c := &eventChan{
+		ch:      make(chan Event, s.buffer),
+		policy:  s.policy,
+		timeout: s.timeout,
+		clock:   br.clock,
+		dropped: &s.dropped,
+	}
+	if br.closed.Load() {
+		c.close()
+		return c.ch
+	}
+	br.subs.mu.Lock()
+	br.subs.chans = append(br.subs.chans, c)
+	br.subs.mu.Unlock()
+	br.Subscribe(c.send)
+	return c.ch
+}
+
+// DroppedEvents returns how many events channels returned by Events have dropped because their
+// readers fell behind.
+func (br *CircuitBreaker) DroppedEvents() uint64 {
+	return br.subs.dropped.Load()
+}
+
+func (br *CircuitBreaker) closeEvents() {
+	br.subs.mu.Lock()
+	chans := br.subs.chans
+	br.subs.chans = nil
+	br.subs.mu.Unlock()
+	for _, c := range chans {
+		c.close()
+	}
+}
+
+// hasSubscribers lets hot paths skip building events nobody listens to.
+func (br *CircuitBreaker) hasSubscribers() bool {
+	return br.subs.count.Load() > 0
+}
+
+// emitLocked queues an event for delivery once br.mu is released. Events other than the
+// snooze reminder only reach the metrics reporter and the FlightRecorder while the breaker is
+// snoozed.
+func (br *CircuitBreaker) emitLocked(ev Event) {
+	ev.snoozed = br.snoozedLocked() && ev.Kind != EventSnoozeEnded
+	ev.Breaker = br.name
+	ev.Labels = br.labels
+	if ev.Time.IsZero() {
+		ev.Time = br.clock.Now()
+	}
+	br.outbox = append(br.outbox, ev)
+}
+
+// publish delivers an event raised outside br.mu, such as a call outcome.
+func (br *CircuitBreaker) publish(ev Event) {
+	if !br.hasSubscribers() {
+		return
+	}
+	br.mu.RLock()
+	ev.snoozed = br.snoozedLocked()
+	br.mu.RUnlock()
+	ev.Breaker = br.name
+	ev.Labels = br.labels
+	if ev.Time.IsZero() {
+		ev.Time = br.clock.Now()
+	}
+	br.deliver([]Event{ev})
+}
+
+// unlockAndNotify releases br.mu and delivers the events queued while it was held.
+func (br *CircuitBreaker) unlockAndNotify() {
+	evs := br.outbox
+	br.outbox = nil
+	passTrip := br.passTrip
+	br.passTrip = false
+	br.mu.Unlock()
+	for _, ev := range evs {
+		if !ev.snoozed {
+			br.logEvent(ev)
+		}
+	}
+	br.deliver(evs)
+	if passTrip {
+		br.tripChildren()
+	}
+}
+
+func (br *CircuitBreaker) deliver(evs []Event) {
+	if !br.hasSubscribers() {
+		return
+	}
+	s := &br.subs
+	s.mu.Lock()
+	fns := make([]subscriber, 0, len(s.fns))
+	for _, fn := range s.fns {
+		fns = append(fns, fn)
+	}
+	s.mu.Unlock()
+
+	for _, ev := range evs {
+		for _, sub := range fns {
+			if !ev.snoozed || sub.always {
+				sub.fn(ev)
+			}
+		}
+	}
+}
@@ -4,6 +4,7 @@ go 1.24.4
 
 require (
 	github.com/afk-ankit/sparkgap v0.0.0
+	github.com/afk-ankit/sparkgap/tui v0.0.0
 	github.com/gdamore/tcell/v2 v2.9.0
 	github.com/rivo/tview v0.42.0
 )
@@ -17,6 +18,10 @@ require (
 	golang.org/x/sys v0.35.0 // indirect
 	golang.org/x/term v0.34.0 // indirect
 	golang.org/x/text v0.28.0 // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
 
-replace github.com/afk-ankit/sparkgap => ../
+replace (
+	github.com/afk-ankit/sparkgap => ../
+	github.com/afk-ankit/sparkgap/tui => ../tui
+)
@@ -1,16 +1,14 @@
 package main
 
 import (
-	"bytes"
 	"fmt"
-	"io"
-	"os"
 	"time"
 
 	"github.com/gdamore/tcell/v2"
 	"github.com/rivo/tview"
 
 	breaker "github.com/afk-ankit/sparkgap"
+	"github.com/afk-ankit/sparkgap/tui"
 )
 
 func accounts(s string, broke bool) (string, error) {
@@ -32,28 +30,18 @@ func main() {
 		SetChangedFunc(func() { app.Draw() })
 	logs.SetBorder(true).SetTitle(" Logs ")
 
-	// Breaker state (right)
-	state := tview.NewTextView().
-		SetDynamicColors(false).
-		SetChangedFunc(func() { app.Draw() })
-	state.SetBorder(true).SetTitle(" Circuit Breaker ")
+	// Breaker dashboard (right)
+	reg := breaker.NewRegistry()
+	if err := reg.Register(br); err != nil {
+		panic(err)
+	}
+	dash := tui.Dashboard(app, reg)
+	defer dash.Stop()
 
 	// Layout
 	flex := tview.NewFlex().
-		AddItem(logs, 0, 3, false).
-		AddItem(state, 48, 0, false)
-
-	// Update right every second
-	go func() {
-		t := time.NewTicker(1 * time.Second)
-		defer t.Stop()
-		for range t.C {
-			s := capture(func() { br.LogStateString() })
-			app.QueueUpdateDraw(func() {
-				state.SetText(s)
-			})
-		}
-	}()
+		AddItem(logs, 0, 2, false).
+		AddItem(dash, 0, 3, false)
 
 	// Simulate service flaps
 	broke := false
@@ -105,17 +93,3 @@ func main() {
 		panic(err)
 	}
 }
-
-// capture stdout of fn into a string (used to render LogState)
-func capture(fn func()) string {
-	orig := os.Stdout
-	r, w, _ := os.Pipe()
-	os.Stdout = w
-	fn()
-	w.Close()
-	os.Stdout = orig
-	var buf bytes.Buffer
-	_, _ = io.Copy(&buf, r)
-	r.Close()
-	return buf.String()
-}
@@ -0,0 +1,28 @@
+/*
+Package expvars publishes breaker state under expvar, so existing /debug/vars scrapers pick up
+breaker health without extra wiring. It lives in its own package because importing expvar
+registers /debug/vars on http.DefaultServeMux; only programs importing expvars opt in.
+*/
+package expvars
+
+import (
+	"expvar"
+
+	"github.com/afk-ankit/sparkgap"
+)
+
+/*
+Publish exports a variable called name holding a snapshot of every breaker in reg, keyed by
+breaker name, e.g. {"payments": {"state": "Open", "failure_count": 5, ...}}. The snapshots are
+taken when the variable is read, so breakers registered later show up too. Like expvar.Publish
+it panics if name is already in use.
+*/
+func Publish(name string, reg *sparkgap.Registry) {
+	expvar.Publish(name, expvar.Func(func() any {
+		out := make(map[string]sparkgap.BreakerSnapshot)
+		for _, s := range reg.Snapshots() {
+			out[s.Name] = s
+		}
+		return out
+	}))
+}
@@ -0,0 +1,48 @@
+package expvars_test
+
+import (
+	"encoding/json"
+	"expvar"
+	"testing"
+
+	"github.com/afk-ankit/sparkgap"
+	"github.com/afk-ankit/sparkgap/expvars"
+)
+
+func TestPublish(t *testing.T) {
+	reg := sparkgap.NewRegistry()
+	expvars.Publish("breakers", reg)
+	read := func() map[string]map[string]any {
+		t.Helper()
+		var out map[string]map[string]any
+		if err := json.Unmarshal([]byte(expvar.Get("breakers").String()), &out); err != nil {
+			t.Fatal(err)
+		}
+		return out
+	}
+	if got := read(); len(got) != 0 {
+		t.Fatalf("published %v for an empty registry", got)
+	}
+
+	// Breakers registered after Publish show up, with their current state.
+	br, err := sparkgap.NewCircuitBreaker("payments", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer br.Close()
+	if err := reg.Register(br); err != nil {
+		t.Fatal(err)
+	}
+	br.Trip()
+	got := read()
+	if s, ok := got["payments"]; !ok || s["state"] != "Open" {
+		t.Fatalf("published %v, want payments Open", got)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Fatal("publishing a name twice did not panic")
+		}
+	}()
+	expvars.Publish("breakers", reg)
+}
@@ -0,0 +1,180 @@
+/*
+Package faultinject injects errors and latency into calls, for checking in staging how an
+application behaves when its breakers trip. Wrap a dependency call with Wrap so the breaker
+counts the injected faults:
+
+	inj := faultinject.New(faultinject.Config{ErrorRate: 0.2, LatencyRate: 0.1, Latency: 2 * time.Second, Seed: 1})
+	user, err := br.ExecuteContext(ctx, faultinject.Wrap(inj, fetchUser))
+
+An Injector is also a sparkgap.Policy, to be placed after the breaker in a sparkgap.Chain.
+Faults are drawn from a generator seeded by Config.Seed, so a run with the same seed and the
+same sequence of calls injects the same faults.
+*/
+package faultinject
+
+import (
+	"context"
+	"errors"
+	"math"
+	"math/rand/v2"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/afk-ankit/sparkgap"
+)
+
+// ErrInjected is the error injected when Config.Err is nil.
+var ErrInjected = errors.New("faultinject: injected failure")
+
+// Config configures an Injector. Rates are probabilities between 0 and 1.
+type Config struct {
+	// ErrorRate is the share of calls that fail with Err instead of running.
+	ErrorRate float64
+	// Err is the injected error. Nil means ErrInjected.
+	Err error
+	// LatencyRate is the share of calls delayed by Latency, plus up to LatencyJitter, before running.
+	LatencyRate   float64
+	Latency       time.Duration
+	LatencyJitter time.Duration
+	// Seed seeds the generator deciding which calls are faulted.
+	Seed uint64
+	// Clock times injected latency. Nil uses the wall clock.
+	Clock sparkgap.Clock
+}
+
+/*
+Injector decides which calls to fault. It is safe for concurrent use; SetRates and Disable
+let an operator change its behaviour while it is in use.
+*/
+type Injector struct {
+	err           error
+	latency       time.Duration
+	latencyJitter time.Duration
+	clock         sparkgap.Clock
+
+	// errorRate and latencyRate hold float64 bits.
+	errorRate   atomic.Uint64
+	latencyRate atomic.Uint64
+	disabled    atomic.Bool
+
+	mu  sync.Mutex
+	rng *rand.Rand
+
+	errors  atomic.Uint64
+	delayed atomic.Uint64
+}
+
+// New returns an Injector configured by cfg.
+func New(cfg Config) *Injector {
+	if cfg.Err == nil {
+		cfg.Err = ErrInjected
+	}
+	i := &Injector{
+		err:           cfg.Err,
+		latency:       max(cfg.Latency, 0),
+		latencyJitter: max(cfg.LatencyJitter, 0),
+		clock:         cfg.Clock,
+		rng:           rand.New(rand.NewPCG(cfg.Seed, cfg.Seed)),
+	}
+	i.SetRates(cfg.ErrorRate, cfg.LatencyRate)
+	return i
+}
+
+// SetRates changes the error and latency rates; values are clamped to [0, 1].
+func (i *Injector) SetRates(errorRate, latencyRate float64) {
+	i.errorRate.Store(math.Float64bits(clamp(errorRate)))
+	i.latencyRate.Store(math.Float64bits(clamp(latencyRate)))
+}
+
+func clamp(rate float64) float64 {
+	if !(rate > 0) {
+		return 0
+	}
+	return min(rate, 1)
+}
+
+// Enable resumes injecting faults after Disable.
+func (i *Injector) Enable() { i.disabled.Store(false) }
+
+// Disable stops injecting faults; calls run untouched until Enable.
+func (i *Injector) Disable() { i.disabled.Store(true) }
+
+// Injected returns how many errors and delays have been injected so far.
+func (i *Injector) Injected() (failures, delays uint64) {
+	return i.errors.Load(), i.delayed.Load()
+}
+
+// draw decides the faults of one call.
+func (i *Injector) draw() (fail bool, delay time.Duration) {
+	if i.disabled.Load() {
+		return false, 0
+	}
+	errorRate := math.Float64frombits(i.errorRate.Load())
+	latencyRate := math.Float64frombits(i.latencyRate.Load())
+	if errorRate == 0 && latencyRate == 0 {
+		return false, 0
+	}
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	if latencyRate > 0 && i.rng.Float64() < latencyRate {
+		delay = i.latency
+		if i.latencyJitter > 0 {
+			delay += time.Duration(i.rng.Int64N(int64(i.latencyJitter) + 1))
+		}
+	}
+	fail = errorRate > 0 && i.rng.Float64() < errorRate
+	return fail, delay
+}
+
+// inject applies the faults of one call, returning a non-nil error if fn must not run.
+func (i *Injector) inject(ctx context.Context) error {
+	fail, delay := i.draw()
+	if delay > 0 {
+		i.delayed.Add(1)
+		if err := i.sleep(ctx, delay); err != nil {
+			return err
+		}
+	}
+	if fail {
+		i.errors.Add(1)
+		return i.err
+	}
+	return nil
+}
+
+func (i *Injector) sleep(ctx context.Context, d time.Duration) error {
+	var after <-chan time.Time
+	if i.clock != nil {
+		after = i.clock.After(d)
+	} else {
+		t := time.NewTimer(d)
+		defer t.Stop()
+		after = t.C
+	}
+	select {
+	case <-after:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// DoContext runs fn unless a fault is injected, after any injected latency. It makes an Injector a sparkgap.Policy.
+func (i *Injector) DoContext(ctx context.Context, fn func(ctx context.Context) error) error {
+	if err := i.inject(ctx); err != nil {
+		return err
+	}
+	return fn(ctx)
+}
+
+// Wrap returns fn with faults injected by i, for passing to Breaker[T].ExecuteContext.
+func Wrap[T any](i *Injector, fn func(ctx context.Context) (T, error)) func(ctx context.Context) (T, error) {
+	return func(ctx context.Context) (T, error) {
+		if err := i.inject(ctx); err != nil {
+			var zero T
+			return zero, err
+		}
+		return fn(ctx)
+	}
+}
@@ -0,0 +1,137 @@
+package faultinject_test
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/afk-ankit/sparkgap"
+	"github.com/afk-ankit/sparkgap/faultinject"
+	"github.com/afk-ankit/sparkgap/sparkgaptest"
+)
+
+var errCustom = errors.New("custom")
+
+func ok(context.Context) error { return nil }
+
+func TestErrorRate(t *testing.T) {
+	cases := []struct {
+		name string
+		cfg  faultinject.Config
+		want error
+	}{
+		{"never", faultinject.Config{}, nil},
+		{"always", faultinject.Config{ErrorRate: 1}, faultinject.ErrInjected},
+		{"clamped", faultinject.Config{ErrorRate: 7}, faultinject.ErrInjected},
+		{"custom error", faultinject.Config{ErrorRate: 1, Err: errCustom}, errCustom},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			inj := faultinject.New(tc.cfg)
+			ran := 0
+			for range 10 {
+				err := inj.DoContext(context.Background(), func(context.Context) error { ran++; return nil })
+				if !errors.Is(err, tc.want) || (err == nil) != (tc.want == nil) {
+					t.Fatalf("DoContext = %v, want %v", err, tc.want)
+				}
+			}
+			failures, _ := inj.Injected()
+			if tc.want == nil && (ran != 10 || failures != 0) || tc.want != nil && (ran != 0 || failures != 10) {
+				t.Fatalf("fn ran %d times with %d injected failures", ran, failures)
+			}
+		})
+	}
+}
+
+// pattern returns which of n calls inj failed.
+func pattern(inj *faultinject.Injector, n int) []bool {
+	out := make([]bool, n)
+	for i := range out {
+		out[i] = inj.DoContext(context.Background(), ok) != nil
+	}
+	return out
+}
+
+func TestSeed(t *testing.T) {
+	a := pattern(faultinject.New(faultinject.Config{ErrorRate: 0.5, Seed: 42}), 64)
+	b := pattern(faultinject.New(faultinject.Config{ErrorRate: 0.5, Seed: 42}), 64)
+	c := pattern(faultinject.New(faultinject.Config{ErrorRate: 0.5, Seed: 7}), 64)
+	if !slices.Equal(a, b) {
+		t.Fatal("the same seed injected different faults")
+	}
+	if slices.Equal(a, c) {
+		t.Fatal("different seeds injected the same faults")
+	}
+	if n := len(slices.DeleteFunc(a, func(f bool) bool { return !f })); n == 0 || n == 64 {
+		t.Fatalf("%d of 64 calls failed at rate 0.5", n)
+	}
+}
+
+func TestSetRatesAndDisable(t *testing.T) {
+	inj := faultinject.New(faultinject.Config{})
+	inj.SetRates(1, 0)
+	if err := inj.DoContext(context.Background(), ok); !errors.Is(err, faultinject.ErrInjected) {
+		t.Fatalf("DoContext after SetRates = %v, want ErrInjected", err)
+	}
+	inj.Disable()
+	if err := inj.DoContext(context.Background(), ok); err != nil {
+		t.Fatalf("DoContext while disabled = %v", err)
+	}
+	inj.Enable()
+	if err := inj.DoContext(context.Background(), ok); err == nil {
+		t.Fatal("DoContext after Enable ran untouched")
+	}
+}
+
+func TestLatency(t *testing.T) {
+	clock := sparkgaptest.NewFakeClock(time.Now())
+	inj := faultinject.New(faultinject.Config{LatencyRate: 1, Latency: time.Second, Clock: clock})
+	done := make(chan error, 1)
+	go func() { done <- inj.DoContext(context.Background(), ok) }()
+	for clock.Pending() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	select {
+	case <-done:
+		t.Fatal("call ran before its injected latency")
+	default:
+	}
+	clock.Advance(time.Second)
+	if err := <-done; err != nil {
+		t.Fatalf("delayed call: %v", err)
+	}
+	if _, delays := inj.Injected(); delays != 1 {
+		t.Fatalf("delays = %d, want 1", delays)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := inj.DoContext(ctx, ok); !errors.Is(err, context.Canceled) {
+		t.Fatalf("delayed call with a done ctx = %v, want context.Canceled", err)
+	}
+}
+
+func TestWrapTripsBreaker(t *testing.T) {
+	br, err := sparkgap.NewBreaker[int]("db", &sparkgap.BreakerConfig{FailureThreshold: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer br.Close()
+	inj := faultinject.New(faultinject.Config{ErrorRate: 1})
+	fetch := faultinject.Wrap(inj, func(context.Context) (int, error) { return 1, nil })
+	for range 3 {
+		if _, err := br.ExecuteContext(context.Background(), fetch); !errors.Is(err, faultinject.ErrInjected) {
+			t.Fatalf("ExecuteContext = %v, want ErrInjected", err)
+		}
+	}
+	if _, err := br.ExecuteContext(context.Background(), fetch); !errors.Is(err, sparkgap.ErrOpen) {
+		t.Fatalf("ExecuteContext after injected failures = %v, want ErrOpen", err)
+	}
+	inj.Disable()
+	br.Reset()
+	if v, err := br.ExecuteContext(context.Background(), fetch); v != 1 || err != nil {
+		t.Fatalf("ExecuteContext once disabled = %d, %v", v, err)
+	}
+}
@@ -0,0 +1,94 @@
+package sparkgap
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+)
+
+// ForcedMode is an operator override pinning a breaker regardless of its state machine.
+type ForcedMode int32
+
+const (
+	// NotForced leaves the breaker to its state machine.
+	NotForced ForcedMode = iota
+	// ForcedOpen rejects every call with ReasonForcedOpen.
+	ForcedOpen
+	// ForcedClosed admits every call. Outcomes are still counted but never trip the breaker.
+	ForcedClosed
+	// Disabled passes calls straight through without counting them.
+	Disabled
+)
+
+func (m ForcedMode) String() string {
+	switch m {
+	case NotForced:
+		return "none"
+	case ForcedOpen:
+		return "open"
+	case ForcedClosed:
+		return "closed"
+	case Disabled:
+		return "disabled"
+	default:
+		return fmt.Sprintf("unknown(%d)", int32(m))
+	}
+}
+
+// MarshalText renders the mode by name.
+func (m ForcedMode) MarshalText() ([]byte, error) {
+	return []byte(m.String()), nil
+}
+
+// UnmarshalText parses a name produced by MarshalText.
+func (m *ForcedMode) UnmarshalText(text []byte) error {
+	switch v := string(text); v {
+	case "none", "":
+		*m = NotForced
+	case "open":
+		*m = ForcedOpen
+	case "closed":
+		*m = ForcedClosed
+	case "disabled":
+		*m = Disabled
+	default:
+		return fmt.Errorf("unknown forced mode %q", v)
+	}
+	return nil
+}
+
+/*
+ForceOpen pins the breaker open until ClearForced: every call is rejected with ReasonForcedOpen,
+whatever the state machine says. Use it to hard-block a dependency during maintenance.
+*/
+func (br *CircuitBreaker) ForceOpen() { br.setForced(ForcedOpen) }
+
+/*
+ForceClosed pins the breaker closed until ClearForced: every call is admitted, even while the
+breaker is Open. Outcomes still feed the counters, statistics and SLIs, but do not change state.
+*/
+func (br *CircuitBreaker) ForceClosed() { br.setForced(ForcedClosed) }
+
+// Disable bypasses the breaker until ClearForced: calls run as if it was not there and are not counted.
+func (br *CircuitBreaker) Disable() { br.setForced(Disabled) }
+
+// ClearForced ends ForceOpen, ForceClosed or Disable, handing control back to the state machine.
+func (br *CircuitBreaker) ClearForced() { br.setForced(NotForced) }
+
+// Forced returns the operator override currently in effect.
+func (br *CircuitBreaker) Forced() ForcedMode {
+	return ForcedMode(br.forced.Load())
+}
+
+func (br *CircuitBreaker) setForced(m ForcedMode) {
+	if ForcedMode(br.forced.Swap(int32(m))) == m {
+		return
+	}
+	if l := br.logger.Load(); l != nil {
+		l.LogAttrs(context.Background(), slog.LevelWarn, "circuit breaker forced mode changed",
+			slog.String("breaker", br.name),
+			slog.String("forced", m.String()),
+			slog.String("state", br.getState().String()),
+		)
+	}
+}
@@ -2,7 +2,10 @@ module github.com/afk-ankit/sparkgap
 
 go 1.24.4
 
-require github.com/jedib0t/go-pretty/v6 v6.6.8
+require (
+	github.com/jedib0t/go-pretty/v6 v6.6.8
+	gopkg.in/yaml.v3 v3.0.1
+)
 
 require (
 	github.com/mattn/go-runewidth v0.0.16 // indirect
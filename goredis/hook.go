@@ -0,0 +1,120 @@
+/*
+Package goredis routes go-redis commands through sparkgap breakers, so a cache outage fails
+fast instead of stacking up dial and read timeouts behind it.
+
+	rdb.AddHook(goredis.NewHook(cb))
+
+For cluster clients, PerNode gives every node its own breaker, so one failing shard does not
+cut off the healthy ones:
+
+	cluster.OnNewNode(goredis.PerNode(func(addr string) *sparkgap.CircuitBreaker { ... }))
+*/
+package goredis
+
+import (
+	"context"
+	"errors"
+	"net"
+
+	"github.com/afk-ankit/sparkgap"
+	"github.com/redis/go-redis/v9"
+)
+
+/*
+IsFailure is the default classifier: redis.Nil (a missing key) and caller cancellations are
+not failures, nor are error replies from the server such as WRONGTYPE, which show the server
+is up. Dial errors, timeouts and broken connections are.
+*/
+func IsFailure(err error) bool {
+	switch {
+	case errors.Is(err, redis.Nil), errors.Is(err, context.Canceled):
+		return false
+	}
+	var reply redis.Error
+	return !errors.As(err, &reply)
+}
+
+// Hook is a redis.Hook running dials, commands and pipelines through a breaker.
+type Hook struct {
+	br        *sparkgap.CircuitBreaker
+	isFailure sparkgap.Classifier
+}
+
+// Option configures NewHook.
+type Option func(*Hook)
+
+// WithClassifier replaces IsFailure as the classifier deciding which errors count against the breaker.
+func WithClassifier(c sparkgap.Classifier) Option {
+	return func(h *Hook) { h.isFailure = c }
+}
+
+// NewHook returns a Hook for br.
+func NewHook(br *sparkgap.CircuitBreaker, opts ...Option) *Hook {
+	h := &Hook{br: br, isFailure: IsFailure}
+	for _, opt := range opts {
+		opt(h)
+	}
+	return h
+}
+
+/*
+PerNode returns a callback for redis.ClusterClient.OnNewNode that adds a Hook to every node
+client, using the breaker newBreaker returns for the node's address.
+*/
+func PerNode(newBreaker func(addr string) *sparkgap.CircuitBreaker, opts ...Option) func(*redis.Client) {
+	return func(rdb *redis.Client) {
+		rdb.AddHook(NewHook(newBreaker(rdb.Options().Addr), opts...))
+	}
+}
+
+// guard runs fn through the breaker, hiding errors that are not failures from it.
+func (h *Hook) guard(ctx context.Context, fn func(ctx context.Context) error) error {
+	var callErr error
+	err := h.br.DoContext(ctx, func(ctx context.Context) error {
+		callErr = fn(ctx)
+		if callErr != nil && !h.isFailure(callErr) {
+			return nil
+		}
+		return callErr
+	})
+	if callErr != nil {
+		return callErr
+	}
+	return err
+}
+
+func (h *Hook) DialHook(next redis.DialHook) redis.DialHook {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		var conn net.Conn
+		err := h.guard(ctx, func(ctx context.Context) error {
+			var err error
+			conn, err = next(ctx, network, addr)
+			return err
+		})
+		return conn, err
+	}
+}
+
+func (h *Hook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		err := h.guard(ctx, func(ctx context.Context) error { return next(ctx, cmd) })
+		if _, rejected := sparkgap.Reason(err); rejected {
+			cmd.SetErr(err)
+		}
+		return err
+	}
+}
+
+func (h *Hook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		err := h.guard(ctx, func(ctx context.Context) error { return next(ctx, cmds) })
+		if _, rejected := sparkgap.Reason(err); rejected {
+			for _, cmd := range cmds {
+				cmd.SetErr(err)
+			}
+		}
+		return err
+	}
+}
+
+var _ redis.Hook = (*Hook)(nil)
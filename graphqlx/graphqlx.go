@@ -0,0 +1,155 @@
+/*
+Package graphqlx protects GraphQL clients with a sparkgap breaker. Many GraphQL failures arrive
+as 200 responses with an errors array, which a plain HTTP classifier counts as successes, so
+Transport also reads the errors of every response and counts those with a failure code, such as
+INTERNAL_SERVER_ERROR in extensions.code, against the breaker. It decorates the HTTP client of
+any GraphQL client library:
+
+	client := graphql.NewClient(url, &http.Client{Transport: graphqlx.Transport(br, nil, graphqlx.Default)})
+*/
+package graphqlx
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/afk-ankit/sparkgap"
+	"github.com/afk-ankit/sparkgap/httpx"
+)
+
+// Error is one entry of the errors array of a GraphQL response.
+type Error struct {
+	Message    string         `json:"message"`
+	Path       []any          `json:"path,omitempty"`
+	Extensions map[string]any `json:"extensions,omitempty"`
+}
+
+// Code returns extensions.code, or "" if the error has none.
+func (e Error) Code() string {
+	code, _ := e.Extensions["code"].(string)
+	return code
+}
+
+// ResponseError is counted against the breaker for a response whose errors c counts as a failure.
+type ResponseError struct {
+	Errors []Error
+}
+
+func (e *ResponseError) Error() string {
+	msgs := make([]string, len(e.Errors))
+	for i, ge := range e.Errors {
+		msgs[i] = ge.Message
+	}
+	return "graphql: " + strings.Join(msgs, "; ")
+}
+
+/*
+Classifier decides whether a GraphQL response counts against the breaker. Transport errors and
+failure statuses are judged by HTTP, as in the httpx package; responses that pass are failing if
+any of their errors has one of FailureCodes.
+*/
+type Classifier struct {
+	// HTTP classifies the transport errors and statuses.
+	HTTP httpx.Classifier
+	// FailureCodes are the extensions.code values counted as failures. Nil means
+	// INTERNAL_SERVER_ERROR, SERVICE_UNAVAILABLE and TIMEOUT.
+	FailureCodes []string
+	// UncodedFailure also counts errors without a code as failures, for servers that send none.
+	UncodedFailure bool
+}
+
+// Default counts transport errors, 5xx and 429 responses, and errors with the default failure codes.
+var Default = Classifier{}
+
+var defaultFailureCodes = []string{"INTERNAL_SERVER_ERROR", "SERVICE_UNAVAILABLE", "TIMEOUT"}
+
+// Failed reports whether errs, the errors array of a response, make it a failure.
+func (c Classifier) Failed(errs []Error) bool {
+	codes := c.FailureCodes
+	if codes == nil {
+		codes = defaultFailureCodes
+	}
+	for _, e := range errs {
+		code := e.Code()
+		if code == "" && c.UncodedFailure || code != "" && slices.Contains(codes, code) {
+			return true
+		}
+	}
+	return false
+}
+
+/*
+Transport returns a RoundTripper sending every request through br with base, or
+http.DefaultTransport if base is nil. Responses are read in full to find their errors and are
+returned to the caller unchanged, failures included; rejections are returned as the
+*sparkgap.RejectionError. As with httpx.Transport, the breaker's Timeout is not applied.
+*/
+func Transport(br *sparkgap.CircuitBreaker, base http.RoundTripper, c Classifier) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &transport{br: br, base: base, c: c}
+}
+
+type transport struct {
+	br   *sparkgap.CircuitBreaker
+	base http.RoundTripper
+	c    Classifier
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var (
+		resp    *http.Response
+		callErr error
+	)
+	err := t.br.DoContext(req.Context(), func(context.Context) error {
+		resp, callErr = t.base.RoundTrip(req)
+		if t.c.HTTP.IsFailure(resp, callErr) {
+			return t.c.HTTP.Check(resp, callErr)
+		}
+		if callErr != nil {
+			return nil
+		}
+		var errs []Error
+		errs, callErr = t.readErrors(resp)
+		if callErr != nil {
+			return callErr
+		}
+		if t.c.Failed(errs) {
+			return &ResponseError{Errors: errs}
+		}
+		return nil
+	})
+	if callErr != nil {
+		// A RoundTripper returns no response with an error; a body that failed to read is closed.
+		return nil, callErr
+	}
+	if resp != nil {
+		return resp, nil
+	}
+	return nil, err
+}
+
+// readErrors buffers the body of resp so it can still be read, and returns its errors array.
+func (t *transport) readErrors(resp *http.Response) ([]Error, error) {
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("graphql: reading response: %w", err)
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	var payload struct {
+		Errors []Error `json:"errors"`
+	}
+	if json.Unmarshal(body, &payload) != nil {
+		// Not a GraphQL response, e.g. a proxy's error page; the status has been judged already.
+		return nil, nil
+	}
+	return payload.Errors, nil
+}
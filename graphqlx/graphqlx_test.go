@@ -0,0 +1,127 @@
+package graphqlx_test
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/afk-ankit/sparkgap"
+	"github.com/afk-ankit/sparkgap/graphqlx"
+)
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) { return f(r) }
+
+type failingBody struct{}
+
+func (failingBody) Read([]byte) (int, error) { return 0, errors.New("connection reset") }
+func (failingBody) Close() error             { return nil }
+
+func TestRoundTripUnreadableBody(t *testing.T) {
+	br, err := sparkgap.NewCircuitBreaker("graphql", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer br.Close()
+	base := roundTripFunc(func(*http.Request) (*http.Response, error) {
+		return &http.Response{StatusCode: http.StatusOK, Body: failingBody{}}, nil
+	})
+	req, _ := http.NewRequest(http.MethodPost, "http://example.test/graphql", strings.NewReader(`{}`))
+	resp, err := graphqlx.Transport(br, base, graphqlx.Classifier{}).RoundTrip(req)
+	if err == nil || resp != nil {
+		t.Fatalf("RoundTrip = %v, %v; want no response and the read error", resp, err)
+	}
+}
+
+func serve(status int, body string) http.RoundTripper {
+	return roundTripFunc(func(*http.Request) (*http.Response, error) {
+		return &http.Response{StatusCode: status, Body: io.NopCloser(strings.NewReader(body))}, nil
+	})
+}
+
+func post(t *testing.T, rt http.RoundTripper) (*http.Response, error) {
+	t.Helper()
+	req, _ := http.NewRequest(http.MethodPost, "http://example.test/graphql", strings.NewReader(`{"query":"{ me }"}`))
+	return rt.RoundTrip(req)
+}
+
+func TestTransport(t *testing.T) {
+	cases := []struct {
+		name   string
+		status int
+		body   string
+		failed bool
+	}{
+		{"data", http.StatusOK, `{"data":{"me":"x"}}`, false},
+		{"user error", http.StatusOK, `{"errors":[{"message":"bad","extensions":{"code":"BAD_USER_INPUT"}}]}`, false},
+		{"server error code", http.StatusOK, `{"errors":[{"message":"boom","extensions":{"code":"INTERNAL_SERVER_ERROR"}}]}`, true},
+		{"uncoded error", http.StatusOK, `{"errors":[{"message":"boom"}]}`, false},
+		{"5xx status", http.StatusServiceUnavailable, `upstream down`, true},
+		{"not GraphQL", http.StatusOK, `<html>`, false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			br, err := sparkgap.NewCircuitBreaker("graphql", &sparkgap.BreakerConfig{FailureThreshold: 1})
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer br.Close()
+			resp, err := post(t, graphqlx.Transport(br, serve(tc.status, tc.body), graphqlx.Default))
+			if err != nil {
+				t.Fatalf("RoundTrip: %v", err)
+			}
+			body, _ := io.ReadAll(resp.Body)
+			if string(body) != tc.body || resp.StatusCode != tc.status {
+				t.Fatalf("got %d %q, want the response unchanged", resp.StatusCode, body)
+			}
+			if got := br.State() == sparkgap.StateOpen; got != tc.failed {
+				t.Fatalf("counted as failure: %t, want %t", got, tc.failed)
+			}
+		})
+	}
+}
+
+func TestTransportRejectsWhileOpen(t *testing.T) {
+	br, err := sparkgap.NewCircuitBreaker("graphql", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer br.Close()
+	br.Trip()
+	called := false
+	base := roundTripFunc(func(*http.Request) (*http.Response, error) {
+		called = true
+		return nil, errors.New("unreachable")
+	})
+	if _, err := post(t, graphqlx.Transport(br, base, graphqlx.Default)); !errors.Is(err, sparkgap.ErrOpen) || called {
+		t.Fatalf("RoundTrip while open = %v (sent %t), want ErrOpen without sending", err, called)
+	}
+}
+
+func TestClassifierFailed(t *testing.T) {
+	coded := func(code string) graphqlx.Error {
+		return graphqlx.Error{Message: "x", Extensions: map[string]any{"code": code}}
+	}
+	cases := []struct {
+		name string
+		c    graphqlx.Classifier
+		errs []graphqlx.Error
+		want bool
+	}{
+		{"no errors", graphqlx.Default, nil, false},
+		{"default failure code", graphqlx.Default, []graphqlx.Error{coded("BAD_USER_INPUT"), coded("TIMEOUT")}, true},
+		{"other code", graphqlx.Default, []graphqlx.Error{coded("FORBIDDEN")}, false},
+		{"custom codes", graphqlx.Classifier{FailureCodes: []string{"FORBIDDEN"}}, []graphqlx.Error{coded("FORBIDDEN")}, true},
+		{"custom codes replace defaults", graphqlx.Classifier{FailureCodes: []string{"FORBIDDEN"}}, []graphqlx.Error{coded("TIMEOUT")}, false},
+		{"uncoded", graphqlx.Default, []graphqlx.Error{{Message: "x"}}, false},
+		{"UncodedFailure", graphqlx.Classifier{UncodedFailure: true}, []graphqlx.Error{{Message: "x"}}, true},
+	}
+	for _, tc := range cases {
+		if got := tc.c.Failed(tc.errs); got != tc.want {
+			t.Errorf("%s: Failed = %t, want %t", tc.name, got, tc.want)
+		}
+	}
+}
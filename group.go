@@ -0,0 +1,261 @@
+package sparkgap
+
+import (
+	"cmp"
+	"fmt"
+	"hash/fnv"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// groupVirtualNodes is how many points every shard gets on the hash ring, to spread keys evenly.
+const groupVirtualNodes = 64
+
+// GroupConfig configures a Group beyond the BreakerConfig of its breakers.
+type GroupConfig struct {
+	/*
+		Shards, when set, bounds the group to that many breakers: keys are spread over them by
+		consistent hashing instead of each getting its own, so high-cardinality keys such as
+		user IDs or URLs cannot grow the group without bound. Keys sharing a shard share its
+		state, so one failing key can trip the shard for the others. Changing Shards between
+		deployments moves only about 1/Shards of the keys to another shard.
+	*/
+	Shards int
+	// IdleTTL, when set, evicts breakers that have not been handed out by Get for that long, so
+	// long-running services do not keep breakers for hosts or tenants they no longer talk to.
+	// Only Closed breakers without calls in flight are evicted; others are kept until they recover.
+	// Callers should call Get for every call rather than hold on to a breaker, which fails with
+	// ErrClosed once evicted.
+	IdleTTL time.Duration
+	// OnEvict, if set, is called with the key (or shard-N) and breaker of every evicted
+	// breaker, e.g. to unregister it. The breaker is closed after OnEvict returns.
+	OnEvict func(key string, br *CircuitBreaker)
+}
+
+/*
+Group hands out breakers per key, such as a host, tenant or user, creating them from one
+BreakerConfig on first use, so a failing key is isolated from the others. It is safe for
+concurrent use.
+
+	hosts, err := sparkgap.NewGroup("hosts", cfg, nil)
+	err = hosts.Get(req.URL.Host).Do(call)
+
+If the BreakerConfig has Metrics set, the group reports its size as the gauge
+sparkgap.group.size, tagged group:<name>.
+*/
+type Group struct {
+	name    string
+	cfg     BreakerConfig
+	ring    *hashRing
+	clock   Clock
+	ttl     time.Duration
+	onEvict func(key string, br *CircuitBreaker)
+	sweep   Timer
+	// sizeTags are the tags of the size gauge.
+	sizeTags []string
+
+	mu       sync.Mutex
+	breakers map[string]*groupEntry
+	closed   bool
+}
+
+type groupEntry struct {
+	br       *CircuitBreaker
+	lastUsed time.Time
+}
+
+/*
+NewGroup returns an empty Group. Breakers are named name/key, or name/shard-N with
+GroupConfig.Shards set. cfg is validated as by NewCircuitBreaker; gc may be nil.
+*/
+func NewGroup(name string, cfg *BreakerConfig, gc *GroupConfig) (*Group, error) {
+	var c BreakerConfig
+	if cfg != nil {
+		c = *cfg
+	}
+	if err := validate(&c); err != nil {
+		return nil, err
+	}
+	if gc != nil && gc.IdleTTL < 0 {
+		return nil, fmt.Errorf("%w: IdleTTL must not be negative, got %s", ErrInvalidConfig, gc.IdleTTL)
+	}
+	c.Labels = maps.Clone(c.Labels)
+	g := &Group{name: name, cfg: c, clock: c.Clock, breakers: make(map[string]*groupEntry)}
+	if g.clock == nil {
+		g.clock = realClock{}
+	}
+	if c.Metrics != nil {
+		g.sizeTags = append([]string{"group:" + name}, labelTags(c.Labels)...)
+	}
+	if gc != nil {
+		if gc.Shards > 0 {
+			g.ring = newHashRing(gc.Shards)
+		}
+		g.ttl, g.onEvict = gc.IdleTTL, gc.OnEvict
+	}
+	if g.ttl > 0 {
+		g.sweep = g.clock.AfterFunc(g.sweepInterval(), g.evictIdle)
+	}
+	return g, nil
+}
+
+// sweepInterval is how often idle breakers are looked for, so they are evicted within 1.5 IdleTTL.
+func (g *Group) sweepInterval() time.Duration {
+	return max(g.ttl/2, time.Millisecond)
+}
+
+// Name returns the group's name.
+func (g *Group) Name() string {
+	return g.name
+}
+
+// Get returns the breaker for key, creating it if needed.
+func (g *Group) Get(key string) *CircuitBreaker {
+	id := key
+	if g.ring != nil {
+		id = "shard-" + strconv.Itoa(g.ring.shard(key))
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	e, ok := g.breakers[id]
+	if !ok {
+		// g.cfg was validated by NewGroup, and merging valid fleet defaults keeps it valid.
+		br, _ := newCircuitBreaker(g.name+"/"+id, g.cfg, false)
+		e = &groupEntry{br: br}
+		g.breakers[id] = e
+		g.reportSizeLocked()
+	}
+	if g.ttl > 0 {
+		e.lastUsed = g.clock.Now()
+	}
+	return e.br
+}
+
+// evictIdle runs on the sweep timer, closing breakers idle for IdleTTL.
+func (g *Group) evictIdle() {
+	now := g.clock.Now()
+	type evicted struct {
+		key string
+		br  *CircuitBreaker
+	}
+	var out []evicted
+	g.mu.Lock()
+	if g.closed {
+		g.mu.Unlock()
+		return
+	}
+	for key, e := range g.breakers {
+		if now.Sub(e.lastUsed) < g.ttl || e.br.State() != StateClosed || e.br.conc.inFlight.Load() > 0 {
+			continue
+		}
+		delete(g.breakers, key)
+		out = append(out, evicted{key, e.br})
+	}
+	if len(out) > 0 {
+		g.reportSizeLocked()
+	}
+	g.sweep.Reset(g.sweepInterval())
+	g.mu.Unlock()
+	for _, ev := range out {
+		if g.onEvict != nil {
+			g.onEvict(ev.key, ev.br)
+		}
+		ev.br.Close()
+	}
+}
+
+func (g *Group) reportSizeLocked() {
+	if g.sizeTags != nil {
+		g.cfg.Metrics.Gauge("sparkgap.group.size", float64(len(g.breakers)), g.sizeTags)
+	}
+}
+
+// Len returns the number of breakers in the group.
+func (g *Group) Len() int {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return len(g.breakers)
+}
+
+// All returns the group's breakers ordered by name, e.g. to register them for the admin API.
+func (g *Group) All() []*CircuitBreaker {
+	g.mu.Lock()
+	all := make([]*CircuitBreaker, 0, len(g.breakers))
+	for _, e := range g.breakers {
+		all = append(all, e.br)
+	}
+	g.mu.Unlock()
+	slices.SortFunc(all, func(a, b *CircuitBreaker) int { return strings.Compare(a.name, b.name) })
+	return all
+}
+
+/*
+Close closes every breaker in the group, without calling OnEvict, and stops evicting idle
+breakers. Later calls to Get create new breakers, which are no longer evicted.
+*/
+func (g *Group) Close() error {
+	g.mu.Lock()
+	breakers := g.breakers
+	g.breakers = make(map[string]*groupEntry)
+	g.closed = true
+	if g.sweep != nil {
+		g.sweep.Stop()
+	}
+	g.reportSizeLocked()
+	g.mu.Unlock()
+	for _, e := range breakers {
+		e.br.Close()
+	}
+	return nil
+}
+
+// hashRing maps keys onto shards by consistent hashing.
+type hashRing struct {
+	points []uint64
+	shards []int
+}
+
+func newHashRing(n int) *hashRing {
+	type point struct {
+		hash  uint64
+		shard int
+	}
+	points := make([]point, 0, n*groupVirtualNodes)
+	for s := range n {
+		for v := range groupVirtualNodes {
+			points = append(points, point{hashKey(strconv.Itoa(s) + "#" + strconv.Itoa(v)), s})
+		}
+	}
+	slices.SortFunc(points, func(a, b point) int { return cmp.Compare(a.hash, b.hash) })
+	r := &hashRing{points: make([]uint64, len(points)), shards: make([]int, len(points))}
+	for i, p := range points {
+		r.points[i], r.shards[i] = p.hash, p.shard
+	}
+	return r
+}
+
+// shard returns the shard owning the first point at or after key's hash, wrapping around.
+func (r *hashRing) shard(key string) int {
+	i, _ := slices.BinarySearch(r.points, hashKey(key))
+	if i == len(r.points) {
+		i = 0
+	}
+	return r.shards[i]
+}
+
+// hashKey hashes s with FNV-1a, stable across processes, then mixes the bits so similar keys
+// such as user-1 and user-2 land far apart on the ring.
+func hashKey(s string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(s))
+	x := h.Sum64()
+	x ^= x >> 30
+	x *= 0xbf58476d1ce4e5b9
+	x ^= x >> 27
+	x *= 0x94d049bb133111eb
+	return x ^ x>>31
+}
@@ -0,0 +1,79 @@
+package sparkgap
+
+import (
+	"context"
+	"time"
+)
+
+// CauseHealthProbe marks transitions triggered by a successful HealthProbe.
+const CauseHealthProbe Cause = "health_probe"
+
+// healthProbe runs BreakerConfig.HealthProbe while the breaker is Open.
+type healthProbe struct {
+	fn       func(ctx context.Context) error
+	interval time.Duration
+	timeout  time.Duration
+	closes   bool
+	// running is guarded by the breaker's mu.
+	running bool
+}
+
+func newHealthProbe(c *BreakerConfig) *healthProbe {
+	if c.HealthProbe == nil {
+		return nil
+	}
+	return &healthProbe{
+		fn:       c.HealthProbe,
+		interval: c.HealthProbeInterval,
+		timeout:  c.HealthProbeTimeout,
+		closes:   c.HealthProbeCloses,
+	}
+}
+
+/*
+runHealthProbe calls the health probe once the open period is over. A successful probe moves
+the breaker to Half-Open, or straight to Closed with HealthProbeCloses; a failed one keeps it
+Open and schedules the next probe after HealthProbeInterval.
+*/
+func (br *CircuitBreaker) runHealthProbe() {
+	h := br.health
+	br.mu.Lock()
+	if br.state != StateOpen || br.closed.Load() {
+		br.unlockAndNotify()
+		return
+	}
+	if h.running {
+		// A trip re-armed the timer while a probe is still out; try again later.
+		br.armRetryLocked(h.interval)
+		br.unlockAndNotify()
+		return
+	}
+	h.running = true
+	due := br.retryAt
+	br.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), h.timeout)
+	start := br.clock.Now()
+	err := h.fn(ctx)
+	elapsed := br.clock.Now().Sub(start)
+	cancel()
+
+	br.mu.Lock()
+	defer br.unlockAndNotify()
+	h.running = false
+	// Drop the result if the breaker was tripped, probed or reset while the probe ran.
+	if br.state != StateOpen || br.closed.Load() || !br.retryAt.Equal(due) {
+		return
+	}
+	br.emitLocked(Event{Kind: EventProbeResult, From: StateOpen, To: StateOpen, Err: err, Elapsed: elapsed})
+	if err != nil {
+		br.armRetryLocked(h.interval)
+		return
+	}
+	br.cause = CauseHealthProbe
+	if h.closes {
+		br.closeLocked()
+	} else {
+		br.halfOpenLocked()
+	}
+}
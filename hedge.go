@@ -0,0 +1,61 @@
+package sparkgap
+
+import (
+	"context"
+	"time"
+)
+
+/*
+Hedge calls fn through br and, if it has not succeeded after delay, fires a second attempt,
+returning the first successful result. An attempt failing before delay fires the second one
+straight away; if both fail, the error of the last one to finish is returned. A breaker
+rejection of the first attempt is returned without hedging.
+
+Both attempts flow through br, so each is counted once. The slower attempt is left to finish
+in the background rather than cancelled, so giving up on it is not recorded as a failure;
+cancel ctx to stop it.
+*/
+func Hedge[T any](ctx context.Context, br *Breaker[T], delay time.Duration, fn func(ctx context.Context) (T, error)) (T, error) {
+	type result struct {
+		res T
+		err error
+	}
+	results := make(chan result, 2)
+	attempt := func() {
+		res, err := br.ExecuteContext(ctx, fn)
+		results <- result{res, err}
+	}
+	go attempt()
+
+	hedge := br.clock.After(delay)
+	pending, hedged := 1, false
+	var last result
+	for {
+		select {
+		case r := <-results:
+			pending--
+			if r.err == nil {
+				return r.res, nil
+			}
+			last = r
+			if _, rejected := Reason(r.err); !hedged && !rejected {
+				hedged = true
+				pending++
+				go attempt()
+			}
+			if pending == 0 {
+				return last.res, last.err
+			}
+		case <-hedge:
+			hedge = nil
+			if !hedged {
+				hedged = true
+				pending++
+				go attempt()
+			}
+		case <-ctx.Done():
+			var zero T
+			return zero, ctx.Err()
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package sparkgap
+
+import (
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// CauseParent marks trips passed down from a parent breaker.
+const CauseParent Cause = "parent"
+
+// children is the set of breakers created with Child.
+type children struct {
+	mu sync.Mutex
+	m  map[*CircuitBreaker]struct{}
+}
+
+func (c *children) add(br *CircuitBreaker) {
+	c.mu.Lock()
+	if c.m == nil {
+		c.m = make(map[*CircuitBreaker]struct{})
+	}
+	c.m[br] = struct{}{}
+	c.mu.Unlock()
+}
+
+func (c *children) remove(br *CircuitBreaker) {
+	c.mu.Lock()
+	delete(c.m, br)
+	c.mu.Unlock()
+}
+
+func (c *children) list() []*CircuitBreaker {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	out := make([]*CircuitBreaker, 0, len(c.m))
+	for br := range c.m {
+		out = append(out, br)
+	}
+	return out
+}
+
+/*
+Child creates a breaker nested under br, e.g. one per endpoint under a per-host parent. A parent
+trip opens every child, and a child rejects calls with ReasonOpen for as long as any ancestor is
+open or forced open. Every call made through a child is also counted by its ancestors, which
+can therefore trip on failures spread across many endpoints; while an ancestor is Half-Open
+those calls act as its probes. cfg is validated as by NewCircuitBreaker.
+*/
+func (br *CircuitBreaker) Child(name string, cfg *BreakerConfig) (*CircuitBreaker, error) {
+	child, err := NewCircuitBreaker(name, cfg)
+	if err != nil {
+		return nil, err
+	}
+	child.parent = br
+	br.children.add(child)
+	return child, nil
+}
+
+// Parent returns the breaker br was created under with Child, or nil.
+func (br *CircuitBreaker) Parent() *CircuitBreaker {
+	return br.parent
+}
+
+// Children returns the breakers created under br that have not been shut down, ordered by name.
+func (br *CircuitBreaker) Children() []*CircuitBreaker {
+	out := br.children.list()
+	slices.SortFunc(out, func(a, b *CircuitBreaker) int { return strings.Compare(a.name, b.name) })
+	return out
+}
+
+// ancestorBlocks reports whether a parent, grandparent and so on currently rejects calls.
+func (br *CircuitBreaker) ancestorBlocks() bool {
+	for p := br.parent; p != nil; p = p.parent {
+		switch p.Forced() {
+		case ForcedOpen:
+			return true
+		case NotForced:
+			if p.getState() == StateOpen {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// rollUp counts a child's call outcome against br as if it had been made through br.
+func (br *CircuitBreaker) rollUp(now time.Time, failed bool, err error, elapsed time.Duration) {
+	mode := br.Forced()
+	if mode == Disabled || br.closed.Load() {
+		return
+	}
+	adm := br.currentAdmission()
+	if adm.state == StateOpen {
+		// The call was admitted before br tripped.
+		return
+	}
+	adm.forced = mode == ForcedClosed
+	br.record(adm, !failed, err)
+	br.stats.record(now, failed, elapsed)
+}
+
+// tripChildren passes a trip of br down to its children; it runs after br.mu is released.
+func (br *CircuitBreaker) tripChildren() {
+	for _, c := range br.children.list() {
+		c.trip(CauseParent)
+	}
+}
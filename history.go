@@ -0,0 +1,90 @@
+package sparkgap
+
+import "time"
+
+const defaultHistorySize = 32
+
+// Cause says what triggered a state transition.
+type Cause string
+
+const (
+	CauseFailureThreshold  Cause = "failure_threshold"
+	CauseCategoryThreshold Cause = "category_threshold"
+	CauseSlowCalls         Cause = "slow_calls"
+	CauseRetryInterval     Cause = "retry_interval"
+	CauseMaxOpenDuration   Cause = "max_open_duration"
+	CauseProbesSucceeded   Cause = "probes_succeeded"
+	CauseProbesFailed      Cause = "probes_failed"
+	CauseSnoozeEnded       Cause = "snooze_ended"
+	CauseManual            Cause = "manual"
+	CausePeer              Cause = "peer"
+	CauseRestore           Cause = "restore"
+)
+
+// Transition is one entry of a breaker's state history.
+type Transition struct {
+	Time  time.Time `json:"time"`
+	From  State     `json:"from"`
+	To    State     `json:"to"`
+	Cause Cause     `json:"cause,omitempty"`
+	// The counters as they stood just before the transition.
+	FailureCount         uint32 `json:"failure_count"`
+	HalfOpenSuccessCount uint32 `json:"half_open_success_count"`
+	HalfOpenFailureCount uint32 `json:"half_open_failure_count"`
+	ConsecutiveTrips     uint32 `json:"consecutive_trips"`
+}
+
+// history is a ring buffer of the most recent transitions, guarded by the breaker's mu.
+type history struct {
+	entries []Transition
+	next    int
+	full    bool
+}
+
+func newHistory(size int) *history {
+	if size <= 0 {
+		size = defaultHistorySize
+	}
+	return &history{entries: make([]Transition, size)}
+}
+
+func (h *history) add(t Transition) {
+	h.entries[h.next] = t
+	h.next = (h.next + 1) % len(h.entries)
+	if h.next == 0 {
+		h.full = true
+	}
+}
+
+func (h *history) list() []Transition {
+	if !h.full {
+		return append([]Transition(nil), h.entries[:h.next]...)
+	}
+	return append(append([]Transition(nil), h.entries[h.next:]...), h.entries[:h.next]...)
+}
+
+// recordTransitionLocked adds a transition caused by br.cause to the history.
+func (br *CircuitBreaker) recordTransitionLocked(from, to State) {
+	v := br.readCounters()
+	br.history.add(Transition{
+		Time:                 br.lastTransition,
+		From:                 from,
+		To:                   to,
+		Cause:                br.cause,
+		FailureCount:         v.failureCount,
+		HalfOpenSuccessCount: v.halfOpenSuccessCount,
+		HalfOpenFailureCount: v.halfOpenFailureCount,
+		ConsecutiveTrips:     br.trips,
+	})
+}
+
+/*
+History returns the most recent state transitions, oldest first, with what triggered each and
+the counters at the time, so flapping can be reconstructed after the fact. BreakerConfig.HistorySize
+sets how many are kept (default 32).
+*/
+func (br *CircuitBreaker) History() []Transition {
+	br.mu.RLock()
+	defer br.mu.RUnlock()
+	return br.history.list()
+}
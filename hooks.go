@@ -0,0 +1,109 @@
+package sparkgap
+
+import (
+	"context"
+	"time"
+)
+
+/*
+CallDetails describes a call admitted by a breaker, for the BeforeCall and AfterCall hooks.
+CallInfo gives the breaker, the state the call was admitted in and any operator override.
+*/
+type CallDetails struct {
+	CallInfo
+	// Probe is set for calls admitted as Half-Open probes.
+	Probe bool
+	// Priority is the priority set on the call's context with WithPriority.
+	Priority Priority
+	// Attempt is the attempt number set on the call's context with WithAttempt, 1 if none was set.
+	Attempt int
+}
+
+// Outcome is how a breaker counted a call.
+type Outcome int
+
+const (
+	OutcomeSuccess Outcome = iota
+	OutcomeFailure
+	// OutcomeIgnored marks calls counted neither way, such as cancellations; see CountCanceled.
+	OutcomeIgnored
+)
+
+func (o Outcome) String() string {
+	switch o {
+	case OutcomeSuccess:
+		return "success"
+	case OutcomeFailure:
+		return "failure"
+	case OutcomeIgnored:
+		return "ignored"
+	default:
+		return "unknown"
+	}
+}
+
+// CallResult is what AfterCall learns about a finished call.
+type CallResult struct {
+	Outcome Outcome
+	// Err is the error the call returned. It is always nil for calls reported through Allow.
+	Err     error
+	Elapsed time.Duration
+}
+
+// callHooks holds the BeforeCall and AfterCall hooks of a breaker.
+type callHooks struct {
+	before func(ctx context.Context, d CallDetails)
+	after  func(ctx context.Context, d CallDetails, r CallResult)
+}
+
+func newCallHooks(c *BreakerConfig) *callHooks {
+	if c.BeforeCall == nil && c.AfterCall == nil {
+		return nil
+	}
+	return &callHooks{before: c.BeforeCall, after: c.AfterCall}
+}
+
+// hook prepares c for the hooks and runs BeforeCall.
+func (c *call) hook(ctx context.Context) {
+	br := c.br
+	c.ctx = ctx
+	c.details = CallDetails{
+		CallInfo: CallInfo{Breaker: br.name, State: c.adm.state, Forced: br.Forced()},
+		Probe:    c.adm.state == StateHalfOpen,
+		Priority: PriorityFrom(ctx),
+		Attempt:  AttemptFrom(ctx),
+	}
+	if br.hooks.before != nil {
+		br.hooks.before(ctx, c.details)
+	}
+}
+
+// afterHook runs AfterCall, if set, for a call that finished at now.
+func (c call) afterHook(now time.Time, outcome Outcome, err error) {
+	if h := c.br.hooks; h != nil && h.after != nil {
+		h.after(c.ctx, c.details, CallResult{Outcome: outcome, Err: err, Elapsed: now.Sub(c.start)})
+	}
+}
+
+// ignore ends c without counting it; only AfterCall learns about it.
+func (c call) ignore(err error) {
+	if c.adm.bypassed || c.br.hooks == nil {
+		return
+	}
+	c.afterHook(c.br.clock.Now(), OutcomeIgnored, err)
+}
+
+type attemptKey struct{}
+
+// WithAttempt returns a copy of ctx marking calls made with it as attempt n, e.g. of a retry loop.
+func WithAttempt(ctx context.Context, n int) context.Context {
+	return context.WithValue(ctx, attemptKey{}, n)
+}
+
+// AttemptFrom returns the attempt number set with WithAttempt, or 1 if none was set.
+func AttemptFrom(ctx context.Context) int {
+	if n, ok := ctx.Value(attemptKey{}).(int); ok && n > 0 {
+		return n
+	}
+	return 1
+}
@@ -0,0 +1,111 @@
+package sparkgap_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/afk-ankit/sparkgap"
+	"github.com/afk-ankit/sparkgap/sparkgaptest"
+)
+
+type hookCall struct {
+	details sparkgap.CallDetails
+	result  sparkgap.CallResult
+}
+
+// recordHooks sets BeforeCall and AfterCall on cfg, returning the calls they saw.
+func recordHooks(cfg *sparkgap.BreakerConfig) (before *[]sparkgap.CallDetails, after *[]hookCall) {
+	before, after = new([]sparkgap.CallDetails), new([]hookCall)
+	cfg.BeforeCall = func(_ context.Context, d sparkgap.CallDetails) {
+		*before = append(*before, d)
+	}
+	cfg.AfterCall = func(_ context.Context, d sparkgap.CallDetails, r sparkgap.CallResult) {
+		*after = append(*after, hookCall{d, r})
+	}
+	return before, after
+}
+
+func TestCallHooks(t *testing.T) {
+	cases := []struct {
+		name    string
+		ctx     context.Context
+		fn      func(clock *sparkgaptest.FakeClock) error
+		outcome sparkgap.Outcome
+		attempt int
+	}{
+		{"success", context.Background(), func(c *sparkgaptest.FakeClock) error { c.Advance(time.Second); return nil }, sparkgap.OutcomeSuccess, 1},
+		{"failure", context.Background(), func(c *sparkgaptest.FakeClock) error { c.Advance(time.Second); return errTest }, sparkgap.OutcomeFailure, 1},
+		{"cancellation", context.Background(), func(c *sparkgaptest.FakeClock) error { c.Advance(time.Second); return context.Canceled }, sparkgap.OutcomeIgnored, 1},
+		{"retry attempt", sparkgap.WithAttempt(context.Background(), 3), func(c *sparkgaptest.FakeClock) error { c.Advance(time.Second); return nil }, sparkgap.OutcomeSuccess, 3},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			var cfg sparkgap.BreakerConfig
+			before, after := recordHooks(&cfg)
+			br, clock := newTestBreaker(t, cfg)
+			_ = br.DoContext(tc.ctx, func(context.Context) error { return tc.fn(clock) })
+
+			if len(*before) != 1 || len(*after) != 1 {
+				t.Fatalf("hooks ran %d times before and %d after, want once each", len(*before), len(*after))
+			}
+			d, r := (*after)[0].details, (*after)[0].result
+			if d != (*before)[0] {
+				t.Errorf("AfterCall details %+v differ from BeforeCall's %+v", d, (*before)[0])
+			}
+			if d.Breaker != br.Name() || d.State != sparkgap.StateClosed || d.Probe || d.Attempt != tc.attempt {
+				t.Errorf("details = %+v, want breaker %q, Closed, no probe, attempt %d", d, br.Name(), tc.attempt)
+			}
+			if r.Outcome != tc.outcome || r.Elapsed != time.Second {
+				t.Errorf("result = %+v, want outcome %s after 1s", r, tc.outcome)
+			}
+		})
+	}
+}
+
+func TestCallHooksProbe(t *testing.T) {
+	cfg := sparkgap.BreakerConfig{RetryInterval: time.Second}
+	_, after := recordHooks(&cfg)
+	br, clock := newTestBreaker(t, cfg)
+	br.Trip()
+	clock.Advance(time.Second)
+	_ = br.Do(succeed)
+	if len(*after) != 1 {
+		t.Fatalf("AfterCall ran %d times, want once", len(*after))
+	}
+	if d := (*after)[0].details; !d.Probe || d.State != sparkgap.StateHalfOpen {
+		t.Fatalf("details = %+v, want a Half-Open probe", d)
+	}
+}
+
+func TestCallHooksSkipRejections(t *testing.T) {
+	var cfg sparkgap.BreakerConfig
+	before, after := recordHooks(&cfg)
+	br, _ := newTestBreaker(t, cfg)
+	br.Trip()
+	if err := br.Do(succeed); !errors.Is(err, sparkgap.ErrOpen) {
+		t.Fatalf("Do = %v, want ErrOpen", err)
+	}
+	br.Disable()
+	_ = br.Do(succeed)
+	if len(*before) != 0 || len(*after) != 0 {
+		t.Fatalf("hooks ran %d times before and %d after for rejected and bypassed calls, want none", len(*before), len(*after))
+	}
+}
+
+func TestAttemptFrom(t *testing.T) {
+	cases := []struct {
+		ctx  context.Context
+		want int
+	}{
+		{context.Background(), 1},
+		{sparkgap.WithAttempt(context.Background(), 2), 2},
+		{sparkgap.WithAttempt(context.Background(), 0), 1},
+	}
+	for _, tc := range cases {
+		if got := sparkgap.AttemptFrom(tc.ctx); got != tc.want {
+			t.Errorf("AttemptFrom = %d, want %d", got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,192 @@
+/*
+Package httpx classifies the outcome of HTTP calls for sparkgap breakers, so every team does
+not reimplement which responses and errors say the server is unhealthy. It works with any
+client built on net/http. Use a Classifier as the success predicate of a typed breaker:
+
+	br := sparkgap.InitBreaker[*http.Response]("api", nil).WithIsSuccessful(httpx.IsSuccessful)
+
+or protect every request of a client, including clients such as resty that take an
+*http.Client or a transport, with Transport:
+
+	client := &http.Client{Transport: httpx.Transport(br, nil, httpx.Default)}
+*/
+package httpx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"syscall"
+
+	"github.com/afk-ankit/sparkgap"
+)
+
+// Error categories for sparkgap.BreakerConfig.CategoryThresholds, as returned by Categorize.
+const (
+	CategoryTimeout           sparkgap.ErrorCategory = "timeout"
+	CategoryConnectionReset   sparkgap.ErrorCategory = "conn_reset"
+	CategoryConnectionRefused sparkgap.ErrorCategory = "conn_refused"
+	CategoryServerError       sparkgap.ErrorCategory = "5xx"
+	CategoryThrottled         sparkgap.ErrorCategory = "429"
+)
+
+// StatusRange is an inclusive range of HTTP status codes.
+type StatusRange struct {
+	Min, Max int
+}
+
+// Contains reports whether code is within r.
+func (r StatusRange) Contains(code int) bool {
+	return code >= r.Min && code <= r.Max
+}
+
+var (
+	// ServerErrors is every 5xx status.
+	ServerErrors = StatusRange{Min: 500, Max: 599}
+	// TooManyRequests is status 429, sent by servers shedding load.
+	TooManyRequests = StatusRange{Min: http.StatusTooManyRequests, Max: http.StatusTooManyRequests}
+)
+
+/*
+Classifier decides whether a response/error pair returned by an HTTP client counts against the
+breaker. Transport errors count as failures, except cancellations by the caller; a response
+counts as a failure if its status is in FailureStatuses.
+*/
+type Classifier struct {
+	// FailureStatuses are the statuses counted as failures. Nil means 5xx and 429; use an
+	// empty, non-nil slice to count every response as a success.
+	FailureStatuses []StatusRange
+	// CountCanceled also counts requests cancelled by the caller's context as failures.
+	CountCanceled bool
+}
+
+// Default counts transport errors other than cancellations, 5xx responses and 429 as failures.
+var Default = Classifier{}
+
+// IsFailure reports whether resp and err, as returned by a client's Do, count as a failure.
+func (c Classifier) IsFailure(resp *http.Response, err error) bool {
+	if err != nil {
+		return c.CountCanceled || !errors.Is(err, context.Canceled)
+	}
+	return resp != nil && c.FailureStatus(resp.StatusCode)
+}
+
+// IsSuccessful is the negation of IsFailure, in the form Breaker.WithIsSuccessful expects.
+func (c Classifier) IsSuccessful(resp *http.Response, err error) bool {
+	return !c.IsFailure(resp, err)
+}
+
+// FailureStatus reports whether a response with status code counts as a failure.
+func (c Classifier) FailureStatus(code int) bool {
+	ranges := c.FailureStatuses
+	if ranges == nil {
+		ranges = []StatusRange{ServerErrors, TooManyRequests}
+	}
+	for _, r := range ranges {
+		if r.Contains(code) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsFailure classifies with Default.
+func IsFailure(resp *http.Response, err error) bool { return Default.IsFailure(resp, err) }
+
+// IsSuccessful classifies with Default.
+func IsSuccessful(resp *http.Response, err error) bool { return Default.IsSuccessful(resp, err) }
+
+/*
+StatusError reports a response whose status counts as a failure. Check returns it so untyped
+breakers, which only see errors, can count such responses.
+*/
+type StatusError struct {
+	StatusCode int
+	Status     string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("unhealthy HTTP response: %s", e.Status)
+}
+
+/*
+Check turns a response/error pair into an error for sparkgap's untyped Do: err itself, a
+*StatusError for a response c counts as a failure, or nil. The response is left to the caller.
+*/
+func (c Classifier) Check(resp *http.Response, err error) error {
+	if err != nil {
+		return err
+	}
+	if resp != nil && c.FailureStatus(resp.StatusCode) {
+		return &StatusError{StatusCode: resp.StatusCode, Status: resp.Status}
+	}
+	return nil
+}
+
+/*
+Categorize is a sparkgap.ErrorClassifier for transport errors and the errors Check returns:
+timeouts, connection resets and refusals, 5xx and 429 responses get their own categories, so
+they can be given their own thresholds. Other errors are uncategorized.
+*/
+func Categorize(err error) sparkgap.ErrorCategory {
+	var se *StatusError
+	if errors.As(err, &se) {
+		switch {
+		case se.StatusCode == http.StatusTooManyRequests:
+			return CategoryThrottled
+		case ServerErrors.Contains(se.StatusCode):
+			return CategoryServerError
+		}
+		return ""
+	}
+	var ne net.Error
+	switch {
+	case errors.Is(err, context.DeadlineExceeded), errors.As(err, &ne) && ne.Timeout():
+		return CategoryTimeout
+	case errors.Is(err, syscall.ECONNRESET), errors.Is(err, syscall.EPIPE):
+		return CategoryConnectionReset
+	case errors.Is(err, syscall.ECONNREFUSED):
+		return CategoryConnectionRefused
+	}
+	return ""
+}
+
+/*
+Transport returns a RoundTripper sending every request through br with base, or
+http.DefaultTransport if base is nil. Responses c counts as failures are reported to the breaker
+but still returned to the caller, like any other response; rejections are returned as the
+*sparkgap.RejectionError, which http.Client wraps in a *url.Error. The breaker's Timeout would
+cut off reading the body, so it is not applied: set http.Client.Timeout instead.
+*/
+func Transport(br *sparkgap.CircuitBreaker, base http.RoundTripper, c Classifier) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &transport{br: br, base: base, c: c}
+}
+
+type transport struct {
+	br   *sparkgap.CircuitBreaker
+	base http.RoundTripper
+	c    Classifier
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var (
+		resp    *http.Response
+		callErr error
+	)
+	err := t.br.DoContext(req.Context(), func(context.Context) error {
+		resp, callErr = t.base.RoundTrip(req)
+		if !t.c.IsFailure(resp, callErr) {
+			return nil
+		}
+		return t.c.Check(resp, callErr)
+	})
+	if resp != nil || callErr != nil {
+		return resp, callErr
+	}
+	return nil, err
+}
@@ -0,0 +1,137 @@
+package httpx_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"syscall"
+	"testing"
+
+	"github.com/afk-ankit/sparkgap"
+	"github.com/afk-ankit/sparkgap/httpx"
+)
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) { return f(r) }
+
+func status(code int) *http.Response {
+	return &http.Response{StatusCode: code, Status: http.StatusText(code)}
+}
+
+func TestClassifier(t *testing.T) {
+	cases := []struct {
+		name string
+		c    httpx.Classifier
+		resp *http.Response
+		err  error
+		want bool
+	}{
+		{"ok", httpx.Default, status(http.StatusOK), nil, false},
+		{"client error", httpx.Default, status(http.StatusNotFound), nil, false},
+		{"server error", httpx.Default, status(http.StatusBadGateway), nil, true},
+		{"throttled", httpx.Default, status(http.StatusTooManyRequests), nil, true},
+		{"transport error", httpx.Default, nil, syscall.ECONNREFUSED, true},
+		{"canceled", httpx.Default, nil, fmt.Errorf("get: %w", context.Canceled), false},
+		{"CountCanceled", httpx.Classifier{CountCanceled: true}, nil, context.Canceled, true},
+		{"custom statuses", httpx.Classifier{FailureStatuses: []httpx.StatusRange{{Min: 404, Max: 404}}}, status(http.StatusNotFound), nil, true},
+		{"no statuses", httpx.Classifier{FailureStatuses: []httpx.StatusRange{}}, status(http.StatusInternalServerError), nil, false},
+	}
+	for _, tc := range cases {
+		if got := tc.c.IsFailure(tc.resp, tc.err); got != tc.want {
+			t.Errorf("%s: IsFailure = %t, want %t", tc.name, got, tc.want)
+		}
+		if got := tc.c.IsSuccessful(tc.resp, tc.err); got == tc.want {
+			t.Errorf("%s: IsSuccessful = %t, want %t", tc.name, got, !tc.want)
+		}
+	}
+}
+
+func TestCheckAndCategorize(t *testing.T) {
+	cases := []struct {
+		name string
+		resp *http.Response
+		err  error
+		want sparkgap.ErrorCategory
+	}{
+		{"ok", status(http.StatusOK), nil, ""},
+		{"5xx", status(http.StatusServiceUnavailable), nil, httpx.CategoryServerError},
+		{"429", status(http.StatusTooManyRequests), nil, httpx.CategoryThrottled},
+		{"timeout", nil, context.DeadlineExceeded, httpx.CategoryTimeout},
+		{"reset", nil, fmt.Errorf("read: %w", syscall.ECONNRESET), httpx.CategoryConnectionReset},
+		{"refused", nil, syscall.ECONNREFUSED, httpx.CategoryConnectionRefused},
+		{"other", nil, errors.New("tls: bad certificate"), ""},
+	}
+	for _, tc := range cases {
+		err := httpx.Default.Check(tc.resp, tc.err)
+		if tc.resp != nil && tc.want == "" {
+			if err != nil {
+				t.Errorf("%s: Check = %v, want nil for a healthy response", tc.name, err)
+			}
+			continue
+		}
+		if got := httpx.Categorize(err); got != tc.want {
+			t.Errorf("%s: Categorize = %q, want %q", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestTransport(t *testing.T) {
+	code := http.StatusOK
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(code)
+	}))
+	defer srv.Close()
+	br, err := sparkgap.NewCircuitBreaker("api", &sparkgap.BreakerConfig{FailureThreshold: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer br.Close()
+	client := &http.Client{Transport: httpx.Transport(br, nil, httpx.Default)}
+
+	get := func() (int, error) {
+		resp, err := client.Get(srv.URL)
+		if err != nil {
+			return 0, err
+		}
+		resp.Body.Close()
+		return resp.StatusCode, nil
+	}
+	if got, err := get(); got != http.StatusOK || err != nil {
+		t.Fatalf("GET = %d, %v", got, err)
+	}
+
+	// Failing responses are returned to the caller and counted by the breaker.
+	code = http.StatusServiceUnavailable
+	for range 2 {
+		if got, err := get(); got != code || err != nil {
+			t.Fatalf("GET = %d, %v; want the %d response", got, err, code)
+		}
+	}
+	if st := br.State(); st != sparkgap.StateOpen {
+		t.Fatalf("state = %s, want Open", st)
+	}
+	if _, err := get(); !errors.Is(err, sparkgap.ErrOpen) {
+		t.Fatalf("GET while open = %v, want ErrOpen", err)
+	}
+}
+
+func TestTransportSkipsCanceled(t *testing.T) {
+	br, err := sparkgap.NewCircuitBreaker("api", &sparkgap.BreakerConfig{FailureThreshold: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer br.Close()
+	base := roundTripFunc(func(r *http.Request) (*http.Response, error) { return nil, r.Context().Err() })
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "http://example.test", nil)
+	if _, err := httpx.Transport(br, base, httpx.Default).RoundTrip(req); !errors.Is(err, context.Canceled) {
+		t.Fatalf("RoundTrip = %v, want context.Canceled", err)
+	}
+	if st := br.State(); st != sparkgap.StateClosed {
+		t.Fatalf("state = %s, want Closed: cancellations are neutral", st)
+	}
+}
@@ -0,0 +1,30 @@
+package sparkgap
+
+import (
+	"maps"
+	"slices"
+)
+
+// Labels returns a copy of the labels the breaker was configured with, or nil.
+func (br *CircuitBreaker) Labels() map[string]string {
+	return maps.Clone(br.labels)
+}
+
+// labelTags renders labels as "key:value" tags, ordered by key.
+func labelTags(labels map[string]string) []string {
+	tags := make([]string, 0, len(labels))
+	for _, k := range slices.Sorted(maps.Keys(labels)) {
+		tags = append(tags, k+":"+labels[k])
+	}
+	return tags
+}
+
+// MatchLabels reports whether labels has every key of selector with the same value.
+func MatchLabels(labels, selector map[string]string) bool {
+	for k, v := range selector {
+		if got, ok := labels[k]; !ok || got != v {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,143 @@
+package sparkgap
+
+import (
+	"math/bits"
+	"sync"
+	"time"
+)
+
+const (
+	defaultSlowCallRatePercent uint32 = 50
+	defaultSlowCallWindow             = time.Minute
+	defaultSlowCallMinCalls    uint32 = 10
+
+	latencyBuckets = 60
+	// histogramBins covers durations from 1µs to about 2^40µs in powers of two.
+	histogramBins = 41
+)
+
+// LatencyStats summarizes the durations of calls admitted over the slow-call window.
+type LatencyStats struct {
+	Window    time.Duration
+	Calls     uint64
+	SlowCalls uint64
+	// SlowCallPercent is SlowCalls as a percentage of Calls, or 0 without traffic.
+	SlowCallPercent float64
+	// P99 is the upper bound of the histogram bin holding the 99th percentile duration.
+	P99 time.Duration
+}
+
+type latencyBucket struct {
+	start int64
+	calls uint64
+	slow  uint64
+	hist  [histogramBins]uint64
+}
+
+/*
+latencyWindow keeps a duration histogram and slow-call count per fixed-width time bucket
+across the slow-call window, so both roll off as the window moves.
+*/
+type latencyWindow struct {
+	mu          sync.Mutex
+	threshold   time.Duration
+	ratePercent uint32
+	minCalls    uint32
+	window      time.Duration
+	resolution  time.Duration
+	buckets     [latencyBuckets]latencyBucket
+}
+
+func newLatencyWindow(c *BreakerConfig) *latencyWindow {
+	if c.SlowCallThreshold <= 0 {
+		return nil
+	}
+	return &latencyWindow{
+		threshold:   c.SlowCallThreshold,
+		ratePercent: c.SlowCallRatePercent,
+		minCalls:    c.SlowCallMinCalls,
+		window:      c.SlowCallWindow,
+		resolution:  max(c.SlowCallWindow/latencyBuckets, time.Millisecond),
+	}
+}
+
+func histogramBin(d time.Duration) int {
+	us := uint64(max(d/time.Microsecond, 1))
+	return min(bits.Len64(us-1), histogramBins-1)
+}
+
+/*
+record adds a call that took d and reports whether slow calls now make up more than the
+configured share of at least minCalls calls in the window.
+*/
+func (w *latencyWindow) record(now time.Time, d time.Duration) bool {
+	slot := now.UnixNano() / int64(w.resolution)
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	b := &w.buckets[slot%latencyBuckets]
+	if b.start != slot {
+		*b = latencyBucket{start: slot}
+	}
+	b.calls++
+	b.hist[histogramBin(d)]++
+	if d <= w.threshold {
+		return false
+	}
+	b.slow++
+	s := w.statsLocked(slot)
+	return s.Calls >= uint64(w.minCalls) && s.SlowCalls*100 > s.Calls*uint64(w.ratePercent)
+}
+
+// reset forgets every recorded call, so slow calls from before a trip cannot trip the breaker again.
+func (w *latencyWindow) reset() {
+	if w == nil {
+		return
+	}
+	w.mu.Lock()
+	w.buckets = [latencyBuckets]latencyBucket{}
+	w.mu.Unlock()
+}
+
+func (w *latencyWindow) stats(now time.Time) *LatencyStats {
+	if w == nil {
+		return nil
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	s := w.statsLocked(now.UnixNano() / int64(w.resolution))
+	return &s
+}
+
+func (w *latencyWindow) statsLocked(slot int64) LatencyStats {
+	s := LatencyStats{Window: w.window}
+	var hist [histogramBins]uint64
+	for i := range w.buckets {
+		b := &w.buckets[i]
+		if b.start <= slot-latencyBuckets || b.start > slot {
+			continue
+		}
+		s.Calls += b.calls
+		s.SlowCalls += b.slow
+		for j, n := range b.hist {
+			hist[j] += n
+		}
+	}
+	if s.Calls == 0 {
+		return s
+	}
+	s.SlowCallPercent = float64(s.SlowCalls) * 100 / float64(s.Calls)
+	s.P99 = percentile(&hist, s.Calls, 99)
+	return s
+}
+
+// percentile returns the upper bound of the bin holding the p-th percentile of total durations.
+func percentile(hist *[histogramBins]uint64, total, p uint64) time.Duration {
+	rank := (total*p + 99) / 100
+	var seen uint64
+	for j, n := range hist {
+		if seen += n; seen >= rank {
+			return time.Duration(uint64(1)<<j) * time.Microsecond
+		}
+	}
+	return 0
+}
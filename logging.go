@@ -0,0 +1,116 @@
+package sparkgap
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+/*
+WithLogger makes the breaker emit structured log records through l: state transitions at Info
+(trips at Warn), and probe results and rejections at Debug. Every record carries the breaker
+name and, where relevant, the old and new state and the current counters. A nil l disables
+logging. It returns br so it can be chained onto the constructor.
+*/
+func (br *CircuitBreaker) WithLogger(l *slog.Logger) *CircuitBreaker {
+	br.logger.Store(l)
+	return br
+}
+
+func (br *CircuitBreaker) logEvent(ev Event) {
+	l := br.logger.Load()
+	if l == nil {
+		return
+	}
+	switch ev.Kind {
+	case EventStateChange:
+		level, msg := slog.LevelInfo, "circuit breaker state changed"
+		if ev.To == StateOpen {
+			level, msg = slog.LevelWarn, "circuit breaker tripped"
+		}
+		l.LogAttrs(context.Background(), level, msg,
+			slog.String("breaker", ev.Breaker),
+			slog.String("from", ev.From.String()),
+			slog.String("to", ev.To.String()),
+			slog.String("cause", string(ev.Cause)),
+			br.countersAttr(),
+		)
+	case EventProbeResult:
+		// Only health probes are queued through the outbox; live probes are logged by logProbe.
+		if !l.Enabled(context.Background(), slog.LevelDebug) {
+			return
+		}
+		attrs := []slog.Attr{
+			slog.String("breaker", ev.Breaker),
+			slog.Bool("success", ev.Err == nil),
+			slog.Duration("elapsed", ev.Elapsed),
+		}
+		if ev.Err != nil {
+			attrs = append(attrs, slog.String("error", ev.Err.Error()))
+		}
+		l.LogAttrs(context.Background(), slog.LevelDebug, "circuit breaker health probe result", attrs...)
+	case EventSnoozeEnded:
+		l.LogAttrs(context.Background(), slog.LevelInfo, "circuit breaker snooze ended",
+			slog.String("breaker", ev.Breaker),
+			slog.String("state", ev.To.String()),
+			br.countersAttr(),
+		)
+	}
+}
+
+func (br *CircuitBreaker) logProbe(success bool, succ, fail uint32) {
+	l := br.logger.Load()
+	if l == nil || !l.Enabled(context.Background(), slog.LevelDebug) {
+		return
+	}
+	l.LogAttrs(context.Background(), slog.LevelDebug, "circuit breaker probe result",
+		slog.String("breaker", br.name),
+		slog.Bool("success", success),
+		slog.Uint64("half_open_success_count", uint64(succ)),
+		slog.Uint64("half_open_failure_count", uint64(fail)),
+		slog.Uint64("half_open_max_probes", uint64(br.counter.halfOpenMaxProbes)),
+	)
+}
+
+func (br *CircuitBreaker) logRejection(reason ReasonCode) {
+	l := br.logger.Load()
+	if l == nil || !l.Enabled(context.Background(), slog.LevelDebug) {
+		return
+	}
+	l.LogAttrs(context.Background(), slog.LevelDebug, "circuit breaker rejected call",
+		slog.String("breaker", br.name),
+		slog.String("reason", string(reason)),
+		slog.String("state", br.getState().String()),
+	)
+}
+
+func (br *CircuitBreaker) logRejectionSummary(ev Event) {
+	l := br.logger.Load()
+	if l == nil {
+		return
+	}
+	var reasons []any
+	for _, reason := range sortedReasons(ev.Rejections) {
+		reasons = append(reasons, slog.Uint64(string(reason), ev.Rejections[reason]))
+	}
+	msg := fmt.Sprintf("circuit breaker rejected %d calls in last %s", ev.Rejected, ev.Elapsed.Round(time.Millisecond))
+	l.LogAttrs(context.Background(), slog.LevelInfo, msg,
+		slog.String("breaker", br.name),
+		slog.String("state", ev.To.String()),
+		slog.Uint64("rejected", ev.Rejected),
+		slog.Duration("interval", ev.Elapsed),
+		slog.Group("reasons", reasons...),
+	)
+}
+
+func (br *CircuitBreaker) countersAttr() slog.Attr {
+	snap := br.Snapshot()
+	return slog.Group("counters",
+		slog.Uint64("failure_count", uint64(snap.FailureCount)),
+		slog.Uint64("failure_threshold", uint64(snap.FailureThreshold)),
+		slog.Uint64("half_open_success_count", uint64(snap.HalfOpenSuccessCount)),
+		slog.Uint64("half_open_failure_count", uint64(snap.HalfOpenFailureCount)),
+		slog.Uint64("consecutive_trips", uint64(snap.ConsecutiveTrips)),
+	)
+}
@@ -0,0 +1,105 @@
+package sparkgap
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+/*
+MetricsSink receives breaker metrics for a metrics pipeline such as StatsD or Datadog; see the
+statsd package. Set BreakerConfig.Metrics to have a breaker report:
+
+	sparkgap.calls             count, tagged outcome:success or outcome:failure
+	sparkgap.call.duration     timing of every admitted call
+	sparkgap.rejections        count, tagged reason:<ReasonCode>
+	sparkgap.transitions       count, tagged from, to and cause
+	sparkgap.state             gauge: 0 Closed, 1 Open, 2 Half-Open, 3 Throttled
+	sparkgap.inflight          gauge, on saturation
+	sparkgap.sli               gauge per SLIWindows entry, tagged window:<duration>, at most once a second
+
+Every metric is tagged breaker:<name> and with the breaker's Labels. Tags are "key:value" strings and must not be retained
+or modified. Methods are called synchronously from the breaker, so they should not block.
+*/
+type MetricsSink interface {
+	Count(name string, delta int64, tags []string)
+	Gauge(name string, value float64, tags []string)
+	Timing(name string, d time.Duration, tags []string)
+}
+
+// metricsReporter turns breaker events into MetricsSink calls.
+type metricsReporter struct {
+	sink MetricsSink
+	// base is the breaker tag; success and failure are prebuilt so calls don't allocate tags.
+	base             []string
+	success, failure []string
+	// sli is reported after calls, at most once per sliEvery; sliTags holds the tags of its windows.
+	sli     *sliWindow
+	sliTags [][]string
+	sliNext atomic.Int64
+}
+
+// sliEvery bounds how often the SLI gauges are reported, since reading the windows is not free.
+const sliEvery = time.Second
+
+func newMetricsReporter(name string, labels map[string]string, sink MetricsSink) *metricsReporter {
+	base := append([]string{"breaker:" + name}, labelTags(labels)...)
+	return &metricsReporter{
+		sink:    sink,
+		base:    base,
+		success: append(base[:len(base):len(base)], "outcome:success"),
+		failure: append(base[:len(base):len(base)], "outcome:failure"),
+	}
+}
+
+func (m *metricsReporter) report(ev Event) {
+	switch ev.Kind {
+	case EventCallSuccess, EventCallFailure:
+		tags := m.success
+		if ev.Kind == EventCallFailure {
+			tags = m.failure
+		}
+		m.sink.Count("sparkgap.calls", 1, tags)
+		m.sink.Timing("sparkgap.call.duration", ev.Elapsed, m.base)
+		m.reportSLI(ev.Time)
+	case EventShortCircuit:
+		reason, _ := Reason(ev.Err)
+		m.sink.Count("sparkgap.rejections", 1, m.with("reason:"+string(reason)))
+	case EventRejectionSummary:
+		for reason, n := range ev.Rejections {
+			m.sink.Count("sparkgap.rejections", int64(n), m.with("reason:"+string(reason)))
+		}
+	case EventStateChange:
+		m.sink.Count("sparkgap.transitions", 1, m.with("from:"+ev.From.String(), "to:"+ev.To.String(), "cause:"+string(ev.Cause)))
+		m.sink.Gauge("sparkgap.state", float64(ev.To), m.base)
+	case EventSaturation:
+		m.sink.Gauge("sparkgap.inflight", float64(ev.InFlight), m.base)
+	}
+}
+
+// withSLI makes m report the SLI windows of w.
+func (m *metricsReporter) withSLI(w *sliWindow) {
+	if w == nil {
+		return
+	}
+	m.sli = w
+	for _, win := range w.windows {
+		m.sliTags = append(m.sliTags, m.with("window:"+win.String()))
+	}
+}
+
+func (m *metricsReporter) reportSLI(now time.Time) {
+	if m.sli == nil {
+		return
+	}
+	next := m.sliNext.Load()
+	if now.UnixNano() < next || !m.sliNext.CompareAndSwap(next, now.Add(sliEvery).UnixNano()) {
+		return
+	}
+	for i, s := range m.sli.read(now) {
+		m.sink.Gauge("sparkgap.sli", s.Ratio, m.sliTags[i])
+	}
+}
+
+func (m *metricsReporter) with(tags ...string) []string {
+	return append(m.base[:len(m.base):len(m.base)], tags...)
+}
@@ -0,0 +1,138 @@
+package sparkgap_test
+
+import (
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/afk-ankit/sparkgap"
+)
+
+type metric struct {
+	kind, name string
+	value      float64
+	tags       []string
+}
+
+// recordingSink is a MetricsSink keeping every metric it is sent.
+type recordingSink struct {
+	mu      sync.Mutex
+	metrics []metric
+}
+
+func (s *recordingSink) add(m metric) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	m.tags = slices.Clone(m.tags)
+	s.metrics = append(s.metrics, m)
+}
+
+func (s *recordingSink) Count(name string, delta int64, tags []string) {
+	s.add(metric{"count", name, float64(delta), tags})
+}
+
+func (s *recordingSink) Gauge(name string, value float64, tags []string) {
+	s.add(metric{"gauge", name, value, tags})
+}
+
+func (s *recordingSink) Timing(name string, d time.Duration, tags []string) {
+	s.add(metric{"timing", name, float64(d), tags})
+}
+
+// last returns the last metric called name carrying every tag in tags.
+func (s *recordingSink) last(name string, tags ...string) (metric, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := len(s.metrics) - 1; i >= 0; i-- {
+		m := s.metrics[i]
+		if m.name == name && !slices.ContainsFunc(tags, func(t string) bool { return !slices.Contains(m.tags, t) }) {
+			return m, true
+		}
+	}
+	return metric{}, false
+}
+
+func TestMetricsReporter(t *testing.T) {
+	sink := &recordingSink{}
+	br, _ := newTestBreaker(t, sparkgap.BreakerConfig{FailureThreshold: 1, Metrics: sink, Labels: map[string]string{"team": "core"}})
+	_ = br.Do(succeed)
+	_ = br.Do(fail)
+	_ = br.Do(succeed)
+
+	cases := []struct {
+		name  string
+		tags  []string
+		value float64
+	}{
+		{"sparkgap.calls", []string{"outcome:success", "team:core"}, 1},
+		{"sparkgap.calls", []string{"outcome:failure"}, 1},
+		{"sparkgap.transitions", []string{"from:Closed", "to:Open"}, 1},
+		{"sparkgap.state", nil, float64(sparkgap.StateOpen)},
+		{"sparkgap.rejections", []string{"reason:open"}, 1},
+	}
+	for _, tc := range cases {
+		m, ok := sink.last(tc.name, tc.tags...)
+		if !ok || m.value != tc.value {
+			t.Errorf("%s %v = %+v (found %t), want %g", tc.name, tc.tags, m, ok, tc.value)
+		}
+	}
+}
+
+func TestSnoozeKeepsMetricsAndRecorder(t *testing.T) {
+	sink := &recordingSink{}
+	rec := sparkgap.NewRingRecorder(16)
+	br, _ := newTestBreaker(t, sparkgap.BreakerConfig{FailureThreshold: 1, Metrics: sink, FlightRecorder: rec})
+	var delivered []sparkgap.Event
+	br.Subscribe(func(ev sparkgap.Event) { delivered = append(delivered, ev) })
+
+	br.Snooze(time.Hour)
+	_ = br.Do(fail)
+	if st := br.State(); st != sparkgap.StateOpen {
+		t.Fatalf("state = %s, want Open", st)
+	}
+	if m, ok := sink.last("sparkgap.state"); !ok || m.value != float64(sparkgap.StateOpen) {
+		t.Fatalf("sparkgap.state gauge = %+v (found %t), want Open", m, ok)
+	}
+	if !slices.ContainsFunc(rec.Events(), func(ev sparkgap.Event) bool {
+		return ev.Kind == sparkgap.EventStateChange && ev.To == sparkgap.StateOpen
+	}) {
+		t.Fatalf("FlightRecorder missed the trip: %v", rec.Events())
+	}
+	if len(delivered) != 0 {
+		t.Fatalf("subscriber got %d events while snoozed, want none", len(delivered))
+	}
+}
+
+func TestSLIGauges(t *testing.T) {
+	sink := &recordingSink{}
+	br, clock := newTestBreaker(t, sparkgap.BreakerConfig{
+		FailureThreshold: 100,
+		Metrics:          sink,
+		SLIWindows:       []time.Duration{time.Minute, time.Hour},
+	})
+	_ = br.Do(fail)
+	clock.Advance(2 * time.Minute)
+	for range 3 {
+		_ = br.Do(succeed)
+	}
+	if m, _ := sink.last("sparkgap.sli", "window:1h0m0s"); m.value != 0.5 {
+		t.Fatalf("sparkgap.sli = %g, want 0.5: later calls within a second are not reported", m.value)
+	}
+	clock.Advance(time.Second)
+	_ = br.Do(succeed)
+
+	cases := []struct {
+		window string
+		want   float64
+	}{
+		{"window:1m0s", 1},
+		{"window:1h0m0s", 0.8},
+	}
+	for _, tc := range cases {
+		m, ok := sink.last("sparkgap.sli", tc.window)
+		if !ok || m.value != tc.want {
+			t.Errorf("sparkgap.sli %s = %+v (found %t), want %g", tc.window, m, ok, tc.want)
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package sparkgap
+
+import "sync/atomic"
+
+// Count names one of the counts a breaker keeps in its MetricsStore.
+type Count int
+
+const (
+	// CountFailures is the number of consecutive failures in Closed state.
+	CountFailures Count = iota
+	// CountProbeSuccesses and CountProbeFailures are the results of the current Half-Open window.
+	CountProbeSuccesses
+	CountProbeFailures
+
+	numCounts
+)
+
+func (c Count) String() string {
+	switch c {
+	case CountFailures:
+		return "failures"
+	case CountProbeSuccesses:
+		return "probe_successes"
+	case CountProbeFailures:
+		return "probe_failures"
+	default:
+		return "unknown"
+	}
+}
+
+/*
+MetricsStore is the backend holding the counts a breaker trips and recovers on. The default
+keeps them in memory; other implementations can keep them in Redis, memcached or shared memory
+so several processes count towards the same breaker. Failure weights, category counts and the
+SLI, stats and latency windows always stay in memory.
+*/
+type MetricsStore interface {
+	// Counters returns the counts of the breaker called name. It is called once, when the
+	// breaker is created.
+	Counters(name string) Counters
+}
+
+/*
+Counters holds the counts of one breaker. Load is called on every successful call, and Add
+and Store on every failure and probe, so implementations must be fast: a networked backend
+should batch or cache rather than make a round trip per call. Methods cannot fail; a backend
+that is unreachable should fall back to counting locally.
+*/
+type Counters interface {
+	Load(c Count) uint32
+	// Add adds delta to c and returns the new count.
+	Add(c Count, delta uint32) uint32
+	Store(c Count, v uint32)
+}
+
+// MemoryStore is the in-memory MetricsStore used when BreakerConfig.MetricsStore is nil.
+type MemoryStore struct{}
+
+func (MemoryStore) Counters(string) Counters { return new(memoryCounters) }
+
+type memoryCounters [numCounts]atomic.Uint32
+
+func (m *memoryCounters) Load(c Count) uint32              { return m[c].Load() }
+func (m *memoryCounters) Add(c Count, delta uint32) uint32 { return m[c].Add(delta) }
+func (m *memoryCounters) Store(c Count, v uint32)          { m[c].Store(v) }
@@ -0,0 +1,126 @@
+package sparkgap
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// persistedState is the format written by Persist.
+type persistedState struct {
+	Name             string    `json:"name"`
+	State            State     `json:"state"`
+	RetryAt          time.Time `json:"retry_at,omitzero"`
+	ConsecutiveTrips uint32    `json:"consecutive_trips"`
+	FailureCount     uint32    `json:"failure_count"`
+}
+
+// Persist writes the breaker's state, retry time and trip counters to w as JSON, for Restore.
+func (br *CircuitBreaker) Persist(w io.Writer) error {
+	br.mu.RLock()
+	p := persistedState{
+		Name:             br.name,
+		State:            br.state,
+		ConsecutiveTrips: br.trips,
+		FailureCount:     br.readCounters().failureCount,
+	}
+	if br.state == StateOpen {
+		p.RetryAt = br.retryAt
+	}
+	br.mu.RUnlock()
+	return json.NewEncoder(w).Encode(p)
+}
+
+/*
+Restore reads state written by Persist, e.g. before a process restart, so a breaker that was
+open stays open until its original retry time instead of hammering a still-broken dependency.
+A breaker persisted Open whose retry time has passed, or persisted Half-Open, starts a fresh
+Half-Open window straight away.
+*/
+func (br *CircuitBreaker) Restore(r io.Reader) error {
+	var p persistedState
+	if err := json.NewDecoder(r).Decode(&p); err != nil {
+		return err
+	}
+	br.mu.Lock()
+	defer br.unlockAndNotify()
+	if br.closed.Load() {
+		return ErrClosed
+	}
+	br.trips = p.ConsecutiveTrips
+	switch p.State {
+	case StateOpen, StateHalfOpen:
+		br.cause = CauseRestore
+		br.adoptOpenLocked(max(p.RetryAt.Sub(br.clock.Now()), 0))
+	default:
+		br.counter.mu.Lock()
+		br.counter.counts.Store(CountFailures, p.FailureCount)
+		br.counter.mu.Unlock()
+	}
+	return nil
+}
+
+/*
+FileStore is a StateStore keeping each breaker's state in its own JSON file under a directory,
+so setting it as StateStore persists trips automatically and restores them when the breaker is
+created again after a restart.
+*/
+type FileStore struct {
+	Dir string
+}
+
+// NewFileStore returns a FileStore writing to dir, creating it if needed.
+func NewFileStore(dir string) (*FileStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &FileStore{Dir: dir}, nil
+}
+
+func (f *FileStore) path(name string) string {
+	return filepath.Join(f.Dir, filepath.Base(name)+".json")
+}
+
+// Load implements StateStore.
+func (f *FileStore) Load(_ context.Context, name string) (SharedState, bool, error) {
+	var s SharedState
+	data, err := os.ReadFile(f.path(name))
+	if errors.Is(err, fs.ErrNotExist) {
+		return s, false, nil
+	}
+	if err != nil {
+		return s, false, err
+	}
+	if err := json.Unmarshal(data, &s); err != nil {
+		return s, false, err
+	}
+	return s, true, nil
+}
+
+// Save implements StateStore. It replaces the file atomically, so a crash never leaves it half written.
+func (f *FileStore) Save(_ context.Context, name string, s SharedState) error {
+	data, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(f.Dir, filepath.Base(name)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), f.path(name))
+}
+
+var _ StateStore = (*FileStore)(nil)
@@ -0,0 +1,100 @@
+package sparkgap
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+)
+
+const defaultShedFailurePercent uint32 = 50
+
+/*
+Priority ranks calls for shedding; see BreakerConfig.ShedPriority. Calls carry it in their
+context, set with WithPriority, and calls without one are PriorityNormal.
+*/
+type Priority int
+
+const (
+	// PriorityCritical calls are never shed.
+	PriorityCritical Priority = iota + 1
+	PriorityNormal
+	PriorityLow
+)
+
+func (p Priority) String() string {
+	switch p {
+	case PriorityCritical:
+		return "critical"
+	case PriorityNormal:
+		return "normal"
+	case PriorityLow:
+		return "low"
+	default:
+		return fmt.Sprintf("Priority(%d)", int(p))
+	}
+}
+
+// MarshalText renders the priority by name.
+func (p Priority) MarshalText() ([]byte, error) {
+	return []byte(p.String()), nil
+}
+
+// UnmarshalText parses a name produced by MarshalText.
+func (p *Priority) UnmarshalText(text []byte) error {
+	switch string(text) {
+	case "critical":
+		*p = PriorityCritical
+	case "normal":
+		*p = PriorityNormal
+	case "low":
+		*p = PriorityLow
+	case "":
+		*p = 0
+	default:
+		return fmt.Errorf("unknown priority %q", text)
+	}
+	return nil
+}
+
+type priorityKey struct{}
+
+// WithPriority returns a copy of ctx making the calls it is passed to have priority p.
+func WithPriority(ctx context.Context, p Priority) context.Context {
+	return context.WithValue(ctx, priorityKey{}, p)
+}
+
+// PriorityFrom returns the priority of calls made with ctx, PriorityNormal if none was set.
+func PriorityFrom(ctx context.Context) Priority {
+	if p, ok := ctx.Value(priorityKey{}).(Priority); ok && p >= PriorityCritical && p <= PriorityLow {
+		return p
+	}
+	return PriorityNormal
+}
+
+// sheds reports whether a call admitted in adm with the priority of ctx should be shed.
+func (br *CircuitBreaker) sheds(ctx context.Context, adm admission) bool {
+	if br.shedPriority == 0 || adm.forced || PriorityFrom(ctx) < br.shedPriority {
+		return false
+	}
+	return br.degraded(adm.state)
+}
+
+/*
+degraded reports whether the breaker is Half-Open or Throttled, or Closed with consecutive
+failures (or their weight) at ShedFailurePercent of FailureThreshold.
+*/
+func (br *CircuitBreaker) degraded(st State) bool {
+	switch st {
+	case StateHalfOpen, StateThrottled:
+		return true
+	case StateClosed:
+		c := &br.counter
+		limit := uint64(atomic.LoadUint32(&c.failureThreshold)) * uint64(br.shedFailurePercent)
+		if br.weigh != nil {
+			return c.failureWeight.Load()*100 >= limit*weightScale
+		}
+		n := c.counts.Load(CountFailures)
+		return n > 0 && uint64(n)*100 >= limit
+	}
+	return false
+}
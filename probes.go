@@ -0,0 +1,95 @@
+package sparkgap
+
+import (
+	"container/list"
+	"context"
+	"sync"
+)
+
+const defaultHalfOpenQueueSize = 64
+
+/*
+probeSlots limits how many Half-Open probes, or with MaxConcurrent how many other calls, may be
+in flight at once. In fair mode callers that find every slot taken wait in a bounded FIFO
+queue and are handed slots in arrival order; otherwise they are rejected straight away.
+*/
+type probeSlots struct {
+	mu       sync.Mutex
+	limit    uint32
+	inFlight uint32
+	fair     bool
+	maxQueue int
+	queue    list.List // of chan struct{}
+	// full is returned to callers that get no slot.
+	full error
+}
+
+func newProbeSlots(limit uint32, fair bool, queueSize int) *probeSlots {
+	if limit == 0 {
+		return nil
+	}
+	if queueSize <= 0 {
+		queueSize = defaultHalfOpenQueueSize
+	}
+	return &probeSlots{limit: limit, fair: fair, maxQueue: queueSize, full: ErrProbeQuotaExceeded}
+}
+
+// newCallSlots returns the MaxConcurrent limit, queueing up to queueSize callers.
+func newCallSlots(limit uint32, queueSize int) *probeSlots {
+	if limit == 0 {
+		return nil
+	}
+	return &probeSlots{limit: limit, fair: queueSize > 0, maxQueue: queueSize, full: ErrBulkheadFull}
+}
+
+// acquire takes a slot, waiting for one in fair mode until ctx is done.
+func (p *probeSlots) acquire(ctx context.Context) error {
+	if p == nil {
+		return nil
+	}
+	p.mu.Lock()
+	if p.inFlight < p.limit && p.queue.Len() == 0 {
+		p.inFlight++
+		p.mu.Unlock()
+		return nil
+	}
+	if !p.fair || p.queue.Len() >= p.maxQueue {
+		p.mu.Unlock()
+		return p.full
+	}
+	ready := make(chan struct{})
+	elem := p.queue.PushBack(ready)
+	p.mu.Unlock()
+
+	select {
+	case <-ready:
+		return nil
+	case <-ctx.Done():
+		p.mu.Lock()
+		select {
+		case <-ready:
+			// The slot was handed over while we were giving up; pass it on.
+			p.mu.Unlock()
+			p.release()
+		default:
+			p.queue.Remove(elem)
+			p.mu.Unlock()
+		}
+		return ctx.Err()
+	}
+}
+
+// release frees a slot, handing it directly to the longest waiting caller if there is one.
+func (p *probeSlots) release() {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if front := p.queue.Front(); front != nil {
+		p.queue.Remove(front)
+		close(front.Value.(chan struct{}))
+		return
+	}
+	p.inFlight--
+}
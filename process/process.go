@@ -0,0 +1,109 @@
+/*
+Package process guards external subprocesses with a sparkgap breaker, so CLIs that shell out
+to flaky tools fail fast once the tool keeps failing instead of spawning it over and over.
+*/
+package process
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"slices"
+	"syscall"
+	"time"
+
+	"github.com/afk-ankit/sparkgap"
+)
+
+// Result describes a finished subprocess.
+type Result struct {
+	// Stdout and Stderr hold the captured output unless the command had its own writers.
+	Stdout   []byte
+	Stderr   []byte
+	ExitCode int
+	Duration time.Duration
+}
+
+/*
+ExitError reports a subprocess that exited with a non-zero status or was killed by a signal.
+It unwraps to the underlying *exec.ExitError.
+*/
+type ExitError struct {
+	Code int
+	// Signal is the signal that killed the process, or zero if it exited on its own.
+	Signal syscall.Signal
+	Stderr []byte
+	err    *exec.ExitError
+}
+
+func (e *ExitError) Error() string {
+	if e.Signal != 0 {
+		return fmt.Sprintf("process killed by signal: %v", e.Signal)
+	}
+	return fmt.Sprintf("process exited with status %d", e.Code)
+}
+
+func (e *ExitError) Unwrap() error { return e.err }
+
+/*
+Run builds a command with build, passing it a context that is cancelled after timeout
+(if positive), and runs it through br. Failing to start, a non-zero exit and death by signal
+are all returned as errors, so they count as failures unless br's IsFailure classifier (see
+ExitCodes and Signaled) says otherwise. If the breaker rejects the call the command is not
+built at all.
+*/
+func Run(ctx context.Context, br *sparkgap.Breaker[Result], timeout time.Duration, build func(ctx context.Context) *exec.Cmd) (Result, error) {
+	return br.ExecuteContext(ctx, func(ctx context.Context) (Result, error) {
+		if timeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, timeout)
+			defer cancel()
+		}
+		cmd := build(ctx)
+		var stdout, stderr bytes.Buffer
+		if cmd.Stdout == nil {
+			cmd.Stdout = &stdout
+		}
+		if cmd.Stderr == nil {
+			cmd.Stderr = &stderr
+		}
+
+		start := time.Now()
+		err := cmd.Run()
+		res := Result{
+			Stdout:   stdout.Bytes(),
+			Stderr:   stderr.Bytes(),
+			Duration: time.Since(start),
+		}
+		if cmd.ProcessState != nil {
+			res.ExitCode = cmd.ProcessState.ExitCode()
+		}
+
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			ee := &ExitError{Code: exitErr.ExitCode(), Stderr: res.Stderr, err: exitErr}
+			if ws, ok := exitErr.Sys().(syscall.WaitStatus); ok && ws.Signaled() {
+				ee.Signal = ws.Signal()
+			}
+			if ctxErr := ctx.Err(); ctxErr != nil {
+				return res, fmt.Errorf("%w: %w", ctxErr, ee)
+			}
+			return res, ee
+		}
+		return res, err
+	})
+}
+
+// ExitCodes returns a classifier matching processes that exited with one of codes.
+func ExitCodes(codes ...int) sparkgap.Classifier {
+	return sparkgap.As(func(e *ExitError) bool {
+		return e.Signal == 0 && slices.Contains(codes, e.Code)
+	})
+}
+
+// Signaled returns a classifier matching processes that were killed by a signal.
+func Signaled() sparkgap.Classifier {
+	return sparkgap.As(func(e *ExitError) bool { return e.Signal != 0 })
+}
@@ -0,0 +1,95 @@
+package process_test
+
+import (
+	"context"
+	"errors"
+	"os/exec"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/afk-ankit/sparkgap"
+	"github.com/afk-ankit/sparkgap/process"
+)
+
+func shell(script string) func(ctx context.Context) *exec.Cmd {
+	return func(ctx context.Context) *exec.Cmd { return exec.CommandContext(ctx, "sh", "-c", script) }
+}
+
+func newBreaker(t *testing.T, cfg *sparkgap.BreakerConfig) *sparkgap.Breaker[process.Result] {
+	t.Helper()
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("no sh in PATH")
+	}
+	br, err := sparkgap.NewBreaker[process.Result](t.Name(), cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { br.Close() })
+	return br
+}
+
+func TestRun(t *testing.T) {
+	br := newBreaker(t, &sparkgap.BreakerConfig{FailureThreshold: 1})
+	res, err := process.Run(context.Background(), br, 0, shell("echo out; echo err >&2"))
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if string(res.Stdout) != "out\n" || string(res.Stderr) != "err\n" || res.ExitCode != 0 {
+		t.Fatalf("Run = %+v, want captured output and exit code 0", res)
+	}
+
+	_, err = process.Run(context.Background(), br, 0, shell("echo broken >&2; exit 3"))
+	var ee *process.ExitError
+	if !errors.As(err, &ee) || ee.Code != 3 || ee.Signal != 0 || string(ee.Stderr) != "broken\n" {
+		t.Fatalf("Run = %v, want an ExitError with code 3 and the stderr", err)
+	}
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) {
+		t.Fatal("ExitError does not unwrap to *exec.ExitError")
+	}
+
+	built := false
+	_, err = process.Run(context.Background(), br, 0, func(ctx context.Context) *exec.Cmd {
+		built = true
+		return exec.CommandContext(ctx, "true")
+	})
+	if !errors.Is(err, sparkgap.ErrOpen) || built {
+		t.Fatalf("Run while open = %v (built %t), want ErrOpen without building the command", err, built)
+	}
+}
+
+func TestRunTimeout(t *testing.T) {
+	br := newBreaker(t, nil)
+	_, err := process.Run(context.Background(), br, 10*time.Millisecond, shell("exec sleep 5"))
+	var ee *process.ExitError
+	if !errors.Is(err, context.DeadlineExceeded) || !errors.As(err, &ee) || ee.Signal != syscall.SIGKILL {
+		t.Fatalf("Run = %v, want DeadlineExceeded wrapping an ExitError killed by SIGKILL", err)
+	}
+}
+
+func TestClassifiers(t *testing.T) {
+	cases := []struct {
+		name    string
+		classes sparkgap.Classifier
+		script  string
+		failure bool
+	}{
+		{"ExitCodes match", process.ExitCodes(2, 3), "exit 3", true},
+		{"ExitCodes miss", process.ExitCodes(2, 3), "exit 1", false},
+		{"Not ExitCodes", sparkgap.Not(process.ExitCodes(1)), "exit 1", false},
+		{"Signaled", process.Signaled(), "kill -9 $$", true},
+		{"Signaled skips exits", process.Signaled(), "exit 1", false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			br := newBreaker(t, &sparkgap.BreakerConfig{FailureThreshold: 1, IsFailure: tc.classes})
+			if _, err := process.Run(context.Background(), br, 0, shell(tc.script)); err == nil {
+				t.Fatal("Run succeeded, want the process error returned either way")
+			}
+			if got := br.State() == sparkgap.StateOpen; got != tc.failure {
+				t.Fatalf("counted as failure: %t, want %t", got, tc.failure)
+			}
+		})
+	}
+}
@@ -0,0 +1,218 @@
+package sparkgap
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultProfile is the profile used by breakers a FileConfig does not map to one.
+const DefaultProfile = "default"
+
+// Duration is a time.Duration written as a string such as "5s" in config files.
+type Duration time.Duration
+
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(time.Duration(d).String()), nil
+}
+
+func (d *Duration) UnmarshalText(text []byte) error {
+	v, err := time.ParseDuration(string(text))
+	if err != nil {
+		return err
+	}
+	*d = Duration(v)
+	return nil
+}
+
+/*
+Profile is a named set of breaker settings in a config file. Zero fields take the same
+defaults as in BreakerConfig.
+*/
+type Profile struct {
+	FailureThreshold          uint32       `json:"failure_threshold,omitempty" yaml:"failure_threshold,omitempty"`
+	RetryInterval             Duration     `json:"retry_interval,omitempty" yaml:"retry_interval,omitempty"`
+	RetryJitter               JitterMode   `json:"retry_jitter,omitempty" yaml:"retry_jitter,omitempty"`
+	MaxOpenDuration           Duration     `json:"max_open_duration,omitempty" yaml:"max_open_duration,omitempty"`
+	HalfOpenMaxProbes         uint32       `json:"half_open_max_probes,omitempty" yaml:"half_open_max_probes,omitempty"`
+	HalfOpenMaxFailurePercent uint32       `json:"half_open_max_failure_percent,omitempty" yaml:"half_open_max_failure_percent,omitempty"`
+	HalfOpenMode              HalfOpenMode `json:"half_open_mode,omitempty" yaml:"half_open_mode,omitempty"`
+	SuccessThreshold          uint32       `json:"success_threshold,omitempty" yaml:"success_threshold,omitempty"`
+	HalfOpenMaxConcurrent     uint32       `json:"half_open_max_concurrent,omitempty" yaml:"half_open_max_concurrent,omitempty"`
+	MaxConcurrent             uint32       `json:"max_concurrent,omitempty" yaml:"max_concurrent,omitempty"`
+	MaxConcurrentQueue        int          `json:"max_concurrent_queue,omitempty" yaml:"max_concurrent_queue,omitempty"`
+	ShedPriority              Priority     `json:"shed_priority,omitempty" yaml:"shed_priority,omitempty"`
+	ShedFailurePercent        uint32       `json:"shed_failure_percent,omitempty" yaml:"shed_failure_percent,omitempty"`
+	WarmUpDuration            Duration     `json:"warm_up_duration,omitempty" yaml:"warm_up_duration,omitempty"`
+	WarmUpStartPercent        uint32       `json:"warm_up_start_percent,omitempty" yaml:"warm_up_start_percent,omitempty"`
+	Timeout                   Duration     `json:"timeout,omitempty" yaml:"timeout,omitempty"`
+	SlowCallThreshold         Duration     `json:"slow_call_threshold,omitempty" yaml:"slow_call_threshold,omitempty"`
+	SlowCallRatePercent       uint32       `json:"slow_call_rate_percent,omitempty" yaml:"slow_call_rate_percent,omitempty"`
+	SlowCallWindow            Duration     `json:"slow_call_window,omitempty" yaml:"slow_call_window,omitempty"`
+	SLIWindows                []Duration   `json:"sli_windows,omitempty" yaml:"sli_windows,omitempty"`
+	StatsWindow               Duration     `json:"stats_window,omitempty" yaml:"stats_window,omitempty"`
+	DeadlinePercentile        uint32       `json:"deadline_percentile,omitempty" yaml:"deadline_percentile,omitempty"`
+	CountCanceled             bool         `json:"count_canceled,omitempty" yaml:"count_canceled,omitempty"`
+	RejectionSummaryInterval  Duration     `json:"rejection_summary_interval,omitempty" yaml:"rejection_summary_interval,omitempty"`
+	// Labels are given to every breaker using the profile.
+	Labels map[string]string `json:"labels,omitempty" yaml:"labels,omitempty"`
+}
+
+// BreakerConfig returns the BreakerConfig described by p.
+func (p Profile) BreakerConfig() *BreakerConfig {
+	cfg := &BreakerConfig{
+		FailureThreshold:          p.FailureThreshold,
+		RetryInterval:             time.Duration(p.RetryInterval),
+		RetryJitter:               p.RetryJitter,
+		MaxOpenDuration:           time.Duration(p.MaxOpenDuration),
+		HalfOpenMaxProbes:         p.HalfOpenMaxProbes,
+		HalfOpenMaxFailurePercent: p.HalfOpenMaxFailurePercent,
+		HalfOpenMode:              p.HalfOpenMode,
+		SuccessThreshold:          p.SuccessThreshold,
+		HalfOpenMaxConcurrent:     p.HalfOpenMaxConcurrent,
+		MaxConcurrent:             p.MaxConcurrent,
+		MaxConcurrentQueue:        p.MaxConcurrentQueue,
+		ShedPriority:              p.ShedPriority,
+		ShedFailurePercent:        p.ShedFailurePercent,
+		WarmUpDuration:            time.Duration(p.WarmUpDuration),
+		WarmUpStartPercent:        p.WarmUpStartPercent,
+		Timeout:                   time.Duration(p.Timeout),
+		SlowCallThreshold:         time.Duration(p.SlowCallThreshold),
+		SlowCallRatePercent:       p.SlowCallRatePercent,
+		SlowCallWindow:            time.Duration(p.SlowCallWindow),
+		StatsWindow:               time.Duration(p.StatsWindow),
+		DeadlinePercentile:        p.DeadlinePercentile,
+		CountCanceled:             p.CountCanceled,
+		RejectionSummaryInterval:  time.Duration(p.RejectionSummaryInterval),
+		Labels:                    maps.Clone(p.Labels),
+	}
+	for _, w := range p.SLIWindows {
+		cfg.SLIWindows = append(cfg.SLIWindows, time.Duration(w))
+	}
+	return cfg
+}
+
+/*
+FileConfig is the content of a breaker config file: named profiles, and the breakers to
+create with the profile each uses. For example, in YAML:
+
+	profiles:
+	  default:
+	    failure_threshold: 5
+	    retry_interval: 5s
+	  fragile:
+	    failure_threshold: 2
+	    retry_interval: 30s
+	breakers:
+	  payments: fragile
+	  search: default
+*/
+type FileConfig struct {
+	Profiles map[string]Profile `json:"profiles" yaml:"profiles"`
+	// Breakers maps breaker names to profile names.
+	Breakers map[string]string `json:"breakers" yaml:"breakers"`
+}
+
+/*
+LoadConfig reads a FileConfig from path, as JSON if the file name ends in .json and as YAML
+otherwise. Unknown keys are rejected, and so are breakers mapped to a profile that does not
+exist or profiles that NewBreaker would refuse; those errors wrap ErrInvalidConfig.
+*/
+func LoadConfig(path string) (*FileConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var fc FileConfig
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.DisallowUnknownFields()
+		err = dec.Decode(&fc)
+	} else {
+		dec := yaml.NewDecoder(bytes.NewReader(data))
+		dec.KnownFields(true)
+		if err = dec.Decode(&fc); errors.Is(err, io.EOF) {
+			err = nil
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s: %v", ErrInvalidConfig, path, err)
+	}
+	if err := fc.validate(); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return &fc, nil
+}
+
+func (fc *FileConfig) validate() error {
+	for _, name := range slices.Sorted(maps.Keys(fc.Profiles)) {
+		cfg := fc.Profiles[name].BreakerConfig()
+		if err := validate(cfg); err != nil {
+			return fmt.Errorf("profile %q: %w", name, err)
+		}
+	}
+	for _, name := range slices.Sorted(maps.Keys(fc.Breakers)) {
+		if _, err := fc.BreakerConfig(name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+/*
+BreakerConfig returns the config for the breaker called name: its mapped profile, or
+DefaultProfile if it is not mapped. A breaker that is neither mapped nor covered by a
+default profile gets a nil config, meaning all defaults.
+*/
+func (fc *FileConfig) BreakerConfig(name string) (*BreakerConfig, error) {
+	profile, mapped := fc.Breakers[name]
+	if !mapped || profile == "" {
+		profile = DefaultProfile
+	}
+	p, ok := fc.Profiles[profile]
+	if !ok {
+		if mapped && profile != DefaultProfile {
+			return nil, fmt.Errorf("%w: breaker %q uses unknown profile %q", ErrInvalidConfig, name, profile)
+		}
+		return nil, nil
+	}
+	return p.BreakerConfig(), nil
+}
+
+/*
+NewRegistryFromConfig creates and registers an untyped breaker for every breaker in fc. Its
+profiles are also defined as templates, so more breakers can be created from them with
+NewFromTemplate.
+*/
+func NewRegistryFromConfig(fc *FileConfig) (*Registry, error) {
+	r := NewRegistry()
+	for name, p := range fc.Profiles {
+		if err := r.DefineTemplate(name, p.BreakerConfig()); err != nil {
+			return nil, err
+		}
+	}
+	for _, name := range slices.Sorted(maps.Keys(fc.Breakers)) {
+		cfg, err := fc.BreakerConfig(name)
+		if err != nil {
+			return nil, err
+		}
+		cb, err := NewCircuitBreaker(name, cfg)
+		if err != nil {
+			return nil, fmt.Errorf("breaker %q: %w", name, err)
+		}
+		if err := r.Register(cb); err != nil {
+			return nil, err
+		}
+	}
+	return r, nil
+}
@@ -0,0 +1,97 @@
+package sparkgap
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// Replica is one copy of a replicated dependency, guarded by its own breaker.
+type Replica[T any] struct {
+	Breaker *Breaker[T]
+	Call    func(ctx context.Context) (T, error)
+}
+
+/*
+QuorumError reports that fewer than Need replicas succeeded. Errs holds the error of every
+failed or rejected replica, in replica order.
+*/
+type QuorumError struct {
+	Need      int
+	Succeeded int
+	Errs      []error
+}
+
+func (e *QuorumError) Error() string {
+	return fmt.Sprintf("quorum not reached: %d of %d required replicas succeeded", e.Succeeded, e.Need)
+}
+
+func (e *QuorumError) Unwrap() []error { return e.Errs }
+
+/*
+Quorum calls every replica concurrently through its own breaker and succeeds when at least need
+of them succeed, returning the successful values in replica order. Replicas whose breaker is
+open count as failed without being called. The aggregate outcome is reported to parent, which
+may be nil; if parent is tripped no replica is called and a rejection is returned.
+*/
+func Quorum[T any](ctx context.Context, parent Condition, need int, replicas ...Replica[T]) ([]T, error) {
+	if need <= 0 || need > len(replicas) {
+		return nil, fmt.Errorf("quorum of %d impossible with %d replicas", need, len(replicas))
+	}
+	if parent != nil && parent.Tripped() {
+		return nil, reject(conditionName(parent), ReasonOpen, ErrOpen)
+	}
+
+	type outcome struct {
+		val T
+		err error
+	}
+	// The replicas are expected to share a Clock; the first one times the quorum for parent.
+	clock := replicas[0].Breaker.clock
+	start := clock.Now()
+	outcomes := make([]outcome, len(replicas))
+	var wg sync.WaitGroup
+	for i, r := range replicas {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			v, err := r.Breaker.ExecuteContext(ctx, r.Call)
+			outcomes[i] = outcome{v, err}
+		}()
+	}
+	wg.Wait()
+
+	var vals []T
+	var errs []error
+	for _, o := range outcomes {
+		if o.err != nil {
+			errs = append(errs, o.err)
+			continue
+		}
+		vals = append(vals, o.val)
+	}
+
+	var err error
+	if len(vals) < need {
+		err = &QuorumError{Need: need, Succeeded: len(vals), Errs: errs}
+	}
+	if parent != nil {
+		parent.Observe(err, clock.Now().Sub(start))
+	}
+	if err != nil {
+		return vals, err
+	}
+	return vals, nil
+}
+
+// AllOf is Quorum requiring every replica to succeed.
+func AllOf[T any](ctx context.Context, parent Condition, replicas ...Replica[T]) ([]T, error) {
+	return Quorum(ctx, parent, len(replicas), replicas...)
+}
+
+func conditionName(c Condition) string {
+	if n, ok := c.(interface{ Name() string }); ok {
+		return n.Name()
+	}
+	return "quorum"
+}
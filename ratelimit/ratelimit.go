@@ -0,0 +1,83 @@
+/*
+Package ratelimit provides a token-bucket rate limiter that rejects calls with the same
+*sparkgap.RejectionError the breaker uses, so it can be layered in front of one with sparkgap.Chain.
+*/
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/afk-ankit/sparkgap"
+)
+
+// Config configures a Limiter.
+type Config struct {
+	// Rate is the sustained number of calls allowed per second.
+	Rate float64
+	// Burst is the bucket size, i.e. how many calls may be made at once after a quiet period. Zero means 1.
+	Burst int
+	// Clock refills the bucket. Nil uses the wall clock.
+	Clock sparkgap.Clock
+}
+
+/*
+Limiter is a token bucket holding up to Burst tokens and refilled at Rate tokens per second.
+Every admitted call takes one token; calls finding the bucket empty are rejected with a
+*sparkgap.RejectionError wrapping sparkgap.ErrRateLimited.
+*/
+type Limiter struct {
+	name  string
+	rate  float64
+	burst float64
+	now   func() time.Time
+
+	mu     sync.Mutex
+	tokens float64
+	last   time.Time
+}
+
+// New returns a Limiter named name with a full bucket; the name is reported in rejections.
+func New(name string, cfg Config) *Limiter {
+	if name == "" {
+		name = "ratelimit"
+	}
+	if cfg.Burst <= 0 {
+		cfg.Burst = 1
+	}
+	now := time.Now
+	if cfg.Clock != nil {
+		now = cfg.Clock.Now
+	}
+	return &Limiter{name: name, rate: cfg.Rate, burst: float64(cfg.Burst), now: now, tokens: float64(cfg.Burst), last: now()}
+}
+
+// Name returns the limiter's name.
+func (l *Limiter) Name() string { return l.name }
+
+// Allow takes a token if one is available and reports whether it did.
+func (l *Limiter) Allow() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := l.now()
+	if elapsed := now.Sub(l.last); elapsed > 0 {
+		l.tokens = min(l.burst, l.tokens+elapsed.Seconds()*l.rate)
+	}
+	l.last = now
+	if l.tokens < 1 {
+		return false
+	}
+	l.tokens--
+	return true
+}
+
+// DoContext runs fn if a token is available and rejects the call otherwise. It makes a Limiter a sparkgap.Policy.
+func (l *Limiter) DoContext(ctx context.Context, fn func(ctx context.Context) error) error {
+	if !l.Allow() {
+		return &sparkgap.RejectionError{Breaker: l.name, Reason: sparkgap.ReasonRateLimited, Err: sparkgap.ErrRateLimited}
+	}
+	return fn(ctx)
+}
+
+var _ sparkgap.Policy = (*Limiter)(nil)
@@ -0,0 +1,72 @@
+package ratelimit_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/afk-ankit/sparkgap"
+	"github.com/afk-ankit/sparkgap/ratelimit"
+	"github.com/afk-ankit/sparkgap/sparkgaptest"
+)
+
+func TestAllow(t *testing.T) {
+	clock := sparkgaptest.NewFakeClock(time.Now())
+	l := ratelimit.New("api", ratelimit.Config{Rate: 2, Burst: 3, Clock: clock})
+	steps := []struct {
+		advance time.Duration
+		want    []bool
+	}{
+		{0, []bool{true, true, true, false}},          // a full bucket allows a burst
+		{500 * time.Millisecond, []bool{true, false}}, // refills at Rate
+		{time.Hour, []bool{true, true, true, false}},  // never holds more than Burst
+	}
+	for i, s := range steps {
+		clock.Advance(s.advance)
+		for j, want := range s.want {
+			if got := l.Allow(); got != want {
+				t.Fatalf("step %d, call %d: Allow = %t, want %t", i, j, got, want)
+			}
+		}
+	}
+}
+
+func TestDoContext(t *testing.T) {
+	clock := sparkgaptest.NewFakeClock(time.Now())
+	l := ratelimit.New("api", ratelimit.Config{Rate: 1, Clock: clock})
+	calls := 0
+	fn := func(context.Context) error { calls++; return nil }
+
+	if err := l.DoContext(context.Background(), fn); err != nil {
+		t.Fatalf("first call: %v", err)
+	}
+	err := l.DoContext(context.Background(), fn)
+	var rej *sparkgap.RejectionError
+	if !errors.As(err, &rej) || !errors.Is(err, sparkgap.ErrRateLimited) {
+		t.Fatalf("call over the rate = %v, want a RejectionError wrapping ErrRateLimited", err)
+	}
+	if rej.Breaker != "api" || rej.Reason != sparkgap.ReasonRateLimited {
+		t.Fatalf("rejection = %+v, want breaker api and reason %q", rej, sparkgap.ReasonRateLimited)
+	}
+	if calls != 1 {
+		t.Fatalf("fn ran %d times, want once", calls)
+	}
+}
+
+func TestRejectionsSkipBreaker(t *testing.T) {
+	clock := sparkgaptest.NewFakeClock(time.Now())
+	l := ratelimit.New("api", ratelimit.Config{Rate: 1, Clock: clock})
+	br, err := sparkgap.NewCircuitBreaker("api", &sparkgap.BreakerConfig{FailureThreshold: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer br.Close()
+	p := sparkgap.Chain[int](l, br)
+	for range 3 {
+		_, _ = p.Execute(func() (int, error) { return 1, nil })
+	}
+	if s := br.Snapshot(); s.State != sparkgap.StateClosed || s.TotalFailures != 0 {
+		t.Fatalf("breaker %s with %d failures after rate-limited calls, want Closed with none", s.State, s.TotalFailures)
+	}
+}
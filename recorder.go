@@ -0,0 +1,138 @@
+package sparkgap
+
+import (
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+)
+
+/*
+Recorder stores breaker events for later diagnosis. Set BreakerConfig.FlightRecorder to have a
+breaker hand it every event it emits; implementations decide how much to keep and where, e.g.
+a bounded RingRecorder in memory or a WriterRecorder appending to a file.
+Record is called synchronously from the breaker, so it should not block for long.
+*/
+type Recorder interface {
+	Record(ev Event) error
+}
+
+// RecorderFunc adapts a function to the Recorder interface.
+type RecorderFunc func(ev Event) error
+
+func (f RecorderFunc) Record(ev Event) error { return f(ev) }
+
+/*
+RingRecorder keeps the most recent events in memory, overwriting the oldest once full.
+*/
+type RingRecorder struct {
+	mu    sync.Mutex
+	buf   []Event
+	next  int
+	full  bool
+	kinds map[EventKind]bool
+}
+
+/*
+NewRingRecorder returns a RingRecorder holding up to size events. If kinds are given only
+events of those kinds are kept, e.g. EventStateChange to bound memory to transitions.
+*/
+func NewRingRecorder(size int, kinds ...EventKind) *RingRecorder {
+	r := &RingRecorder{buf: make([]Event, max(size, 1))}
+	if len(kinds) > 0 {
+		r.kinds = make(map[EventKind]bool, len(kinds))
+		for _, k := range kinds {
+			r.kinds[k] = true
+		}
+	}
+	return r
+}
+
+func (r *RingRecorder) Record(ev Event) error {
+	if r.kinds != nil && !r.kinds[ev.Kind] {
+		return nil
+	}
+	r.mu.Lock()
+	r.buf[r.next] = ev
+	r.next = (r.next + 1) % len(r.buf)
+	if r.next == 0 {
+		r.full = true
+	}
+	r.mu.Unlock()
+	return nil
+}
+
+// Events returns the recorded events, oldest first.
+func (r *RingRecorder) Events() []Event {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.full {
+		return append([]Event(nil), r.buf[:r.next]...)
+	}
+	out := make([]Event, 0, len(r.buf))
+	out = append(out, r.buf[r.next:]...)
+	return append(out, r.buf[:r.next]...)
+}
+
+/*
+WriterRecorder appends every event as a JSON line to an io.Writer such as an *os.File, or a
+RotatingFile to bound the log's size and age.
+*/
+type WriterRecorder struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// NewWriterRecorder returns a WriterRecorder writing to w.
+func NewWriterRecorder(w io.Writer) *WriterRecorder {
+	return &WriterRecorder{w: w}
+}
+
+func (r *WriterRecorder) Record(ev Event) error {
+	line, err := json.Marshal(ev)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	_, err = r.w.Write(line)
+	return err
+}
+
+// MarshalJSON renders the event with snake_case keys and its error as a string.
+func (ev Event) MarshalJSON() ([]byte, error) {
+	out := struct {
+		Kind     EventKind `json:"kind"`
+		Breaker  string    `json:"breaker"`
+		Time     time.Time `json:"time"`
+		From     State     `json:"from"`
+		To       State     `json:"to"`
+		Err      string    `json:"error,omitempty"`
+		Elapsed  string    `json:"elapsed,omitempty"`
+		InFlight int64     `json:"in_flight,omitempty"`
+		Cause    Cause     `json:"cause,omitempty"`
+		Rejected uint64    `json:"rejected,omitempty"`
+		// Rejections is keyed by reason.
+		Rejections map[ReasonCode]uint64 `json:"rejections,omitempty"`
+		Labels     map[string]string     `json:"labels,omitempty"`
+	}{
+		Kind:       ev.Kind,
+		Breaker:    ev.Breaker,
+		Time:       ev.Time,
+		From:       ev.From,
+		To:         ev.To,
+		InFlight:   ev.InFlight,
+		Cause:      ev.Cause,
+		Rejected:   ev.Rejected,
+		Rejections: ev.Rejections,
+		Labels:     ev.Labels,
+	}
+	if ev.Err != nil {
+		out.Err = ev.Err.Error()
+	}
+	if ev.Elapsed > 0 {
+		out.Elapsed = ev.Elapsed.String()
+	}
+	return json.Marshal(out)
+}
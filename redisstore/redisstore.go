@@ -0,0 +1,200 @@
+/*
+Package redisstore is a Redis-backed sparkgap.StateStore, so replicas of a service share trip
+state for their dependencies. It speaks the Redis protocol directly over a single connection
+and needs no client library.
+*/
+package redisstore
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/afk-ankit/sparkgap"
+)
+
+const (
+	defaultPrefix      = "sparkgap:"
+	defaultTTL         = 24 * time.Hour
+	defaultDialTimeout = 5 * time.Second
+)
+
+// Options configures a Store.
+type Options struct {
+	// Password, if set, is sent with AUTH on connect.
+	Password string
+	// DB is the logical database selected on connect.
+	DB int
+	// Prefix is prepended to breaker names to form keys. Empty means "sparkgap:".
+	Prefix string
+	// TTL expires keys that are no longer saved, e.g. after a breaker is renamed. Zero means 24h.
+	TTL time.Duration
+	// DialTimeout bounds connecting when the context has no earlier deadline. Zero means 5s.
+	DialTimeout time.Duration
+}
+
+// Store saves breaker state as JSON strings under Prefix+name.
+type Store struct {
+	addr string
+	opts Options
+
+	mu   sync.Mutex
+	conn net.Conn
+	rd   *bufio.Reader
+}
+
+// New returns a Store for the Redis server at addr ("host:port"). It connects lazily.
+func New(addr string, opts Options) *Store {
+	if opts.Prefix == "" {
+		opts.Prefix = defaultPrefix
+	}
+	if opts.TTL <= 0 {
+		opts.TTL = defaultTTL
+	}
+	if opts.DialTimeout <= 0 {
+		opts.DialTimeout = defaultDialTimeout
+	}
+	return &Store{addr: addr, opts: opts}
+}
+
+// Load implements sparkgap.StateStore.
+func (s *Store) Load(ctx context.Context, name string) (sparkgap.SharedState, bool, error) {
+	var st sparkgap.SharedState
+	reply, err := s.do(ctx, "GET", s.opts.Prefix+name)
+	if err != nil || reply == nil {
+		return st, false, err
+	}
+	if err := json.Unmarshal(reply, &st); err != nil {
+		return st, false, fmt.Errorf("redisstore: decoding %q: %w", name, err)
+	}
+	return st, true, nil
+}
+
+// Save implements sparkgap.StateStore.
+func (s *Store) Save(ctx context.Context, name string, st sparkgap.SharedState) error {
+	data, err := json.Marshal(st)
+	if err != nil {
+		return err
+	}
+	_, err = s.do(ctx, "SET", s.opts.Prefix+name, string(data), "PX", strconv.FormatInt(s.opts.TTL.Milliseconds(), 10))
+	return err
+}
+
+// Close closes the connection, if any. The Store reconnects on its next use.
+func (s *Store) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.dropLocked()
+}
+
+// do sends one command and returns its bulk reply, or nil for a nil or status reply.
+func (s *Store) do(ctx context.Context, args ...string) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.conn == nil {
+		if err := s.connectLocked(ctx); err != nil {
+			return nil, err
+		}
+	}
+	reply, err := s.roundTripLocked(ctx, args)
+	var redisErr Error
+	if err != nil && !errors.As(err, &redisErr) {
+		// The connection may be mid-reply; start afresh next time.
+		s.dropLocked()
+	}
+	return reply, err
+}
+
+func (s *Store) connectLocked(ctx context.Context) error {
+	d := net.Dialer{Timeout: s.opts.DialTimeout}
+	conn, err := d.DialContext(ctx, "tcp", s.addr)
+	if err != nil {
+		return fmt.Errorf("redisstore: %w", err)
+	}
+	s.conn, s.rd = conn, bufio.NewReader(conn)
+	if s.opts.Password != "" {
+		if _, err := s.roundTripLocked(ctx, []string{"AUTH", s.opts.Password}); err != nil {
+			s.dropLocked()
+			return err
+		}
+	}
+	if s.opts.DB != 0 {
+		if _, err := s.roundTripLocked(ctx, []string{"SELECT", strconv.Itoa(s.opts.DB)}); err != nil {
+			s.dropLocked()
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Store) dropLocked() error {
+	if s.conn == nil {
+		return nil
+	}
+	err := s.conn.Close()
+	s.conn, s.rd = nil, nil
+	return err
+}
+
+func (s *Store) roundTripLocked(ctx context.Context, args []string) ([]byte, error) {
+	// A context without a deadline yields the zero time, clearing any earlier one.
+	deadline, _ := ctx.Deadline()
+	if err := s.conn.SetDeadline(deadline); err != nil {
+		return nil, err
+	}
+	buf := fmt.Appendf(nil, "*%d\r\n", len(args))
+	for _, a := range args {
+		buf = fmt.Appendf(buf, "$%d\r\n%s\r\n", len(a), a)
+	}
+	if _, err := s.conn.Write(buf); err != nil {
+		return nil, fmt.Errorf("redisstore: %w", err)
+	}
+	return readReply(s.rd)
+}
+
+// Error is an error reply from the server.
+type Error string
+
+func (e Error) Error() string { return "redisstore: " + string(e) }
+
+// readReply reads a status, error, integer or bulk string reply.
+func readReply(rd *bufio.Reader) ([]byte, error) {
+	line, err := rd.ReadString('\n')
+	if err != nil {
+		return nil, fmt.Errorf("redisstore: %w", err)
+	}
+	if len(line) < 3 || line[len(line)-2] != '\r' {
+		return nil, fmt.Errorf("redisstore: malformed reply %q", line)
+	}
+	kind, body := line[0], line[1:len(line)-2]
+	switch kind {
+	case '+', ':':
+		return nil, nil
+	case '-':
+		return nil, Error(body)
+	case '$':
+		n, err := strconv.Atoi(body)
+		if err != nil {
+			return nil, fmt.Errorf("redisstore: malformed bulk length %q", body)
+		}
+		if n < 0 {
+			return nil, nil
+		}
+		data := make([]byte, n+2)
+		if _, err := io.ReadFull(rd, data); err != nil {
+			return nil, fmt.Errorf("redisstore: %w", err)
+		}
+		return data[:n], nil
+	default:
+		return nil, fmt.Errorf("redisstore: unexpected reply type %q", kind)
+	}
+}
+
+var _ sparkgap.StateStore = (*Store)(nil)
@@ -0,0 +1,237 @@
+package redisstore_test
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/afk-ankit/sparkgap"
+	"github.com/afk-ankit/sparkgap/redisstore"
+)
+
+// fakeRedis serves GET, SET, AUTH and SELECT from memory and records every command.
+type fakeRedis struct {
+	ln       net.Listener
+	password string
+
+	mu    sync.Mutex
+	data  map[string]string
+	cmds  []string
+	conns int
+}
+
+func newFakeRedis(t *testing.T, password string) *fakeRedis {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Skipf("no TCP: %v", err)
+	}
+	r := &fakeRedis{ln: ln, password: password, data: make(map[string]string)}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			r.mu.Lock()
+			r.conns++
+			r.mu.Unlock()
+			go r.serve(conn)
+		}
+	}()
+	return r
+}
+
+func (r *fakeRedis) addr() string { return r.ln.Addr().String() }
+
+func (r *fakeRedis) commands() string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return strings.Join(r.cmds, "; ")
+}
+
+func (r *fakeRedis) serve(conn net.Conn) {
+	defer conn.Close()
+	rd := bufio.NewReader(conn)
+	for {
+		args, err := readCommand(rd)
+		if err != nil {
+			return
+		}
+		r.mu.Lock()
+		r.cmds = append(r.cmds, strings.Join(args, " "))
+		var reply string
+		switch strings.ToUpper(args[0]) {
+		case "AUTH":
+			reply = "+OK\r\n"
+			if args[1] != r.password {
+				reply = "-WRONGPASS invalid password\r\n"
+			}
+		case "SELECT":
+			reply = "+OK\r\n"
+		case "GET":
+			v, ok := r.data[args[1]]
+			reply = "$-1\r\n"
+			if ok {
+				reply = fmt.Sprintf("$%d\r\n%s\r\n", len(v), v)
+			}
+		case "SET":
+			r.data[args[1]] = args[2]
+			reply = "+OK\r\n"
+		default:
+			reply = "-ERR unknown command\r\n"
+		}
+		r.mu.Unlock()
+		if _, err := io.WriteString(conn, reply); err != nil {
+			return
+		}
+	}
+}
+
+func readCommand(rd *bufio.Reader) ([]string, error) {
+	line, err := rd.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(strings.TrimPrefix(line, "*")))
+	if err != nil {
+		return nil, err
+	}
+	args := make([]string, n)
+	for i := range args {
+		if _, err := rd.ReadString('\n'); err != nil { // $len
+			return nil, err
+		}
+		arg, err := rd.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		args[i] = strings.TrimSuffix(arg, "\r\n")
+	}
+	return args, nil
+}
+
+func TestSaveLoad(t *testing.T) {
+	srv := newFakeRedis(t, "")
+	s := redisstore.New(srv.addr(), redisstore.Options{Prefix: "cb:", TTL: time.Minute})
+	defer s.Close()
+	ctx := context.Background()
+
+	if _, ok, err := s.Load(ctx, "db"); ok || err != nil {
+		t.Fatalf("Load of a missing key = %t, %v", ok, err)
+	}
+	want := sparkgap.SharedState{
+		State:     sparkgap.StateOpen,
+		RetryAt:   time.Date(2024, 1, 1, 0, 0, 30, 0, time.UTC),
+		UpdatedAt: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+	if err := s.Save(ctx, "db", want); err != nil {
+		t.Fatal(err)
+	}
+	got, ok, err := s.Load(ctx, "db")
+	if !ok || err != nil || got.State != want.State || !got.RetryAt.Equal(want.RetryAt) || !got.UpdatedAt.Equal(want.UpdatedAt) {
+		t.Fatalf("Load = %+v, %t, %v; want %+v", got, ok, err, want)
+	}
+	if cmds := srv.commands(); !strings.Contains(cmds, "SET cb:db ") || !strings.Contains(cmds, " PX 60000") {
+		t.Fatalf("commands = %s, want SET under the prefix with the TTL", cmds)
+	}
+}
+
+func TestConnect(t *testing.T) {
+	cases := []struct {
+		name    string
+		opts    redisstore.Options
+		wantErr bool
+		prelude string
+	}{
+		{"no auth", redisstore.Options{}, false, "GET sparkgap:db"},
+		{"auth and select", redisstore.Options{Password: "secret", DB: 2}, false, "AUTH secret; SELECT 2; GET sparkgap:db"},
+		{"wrong password", redisstore.Options{Password: "guess"}, true, "AUTH guess"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			srv := newFakeRedis(t, "secret")
+			s := redisstore.New(srv.addr(), tc.opts)
+			defer s.Close()
+			_, _, err := s.Load(context.Background(), "db")
+			var redisErr redisstore.Error
+			if tc.wantErr != errors.As(err, &redisErr) {
+				t.Fatalf("Load = %v, want a server error: %t", err, tc.wantErr)
+			}
+			if got := srv.commands(); got != tc.prelude {
+				t.Fatalf("commands = %q, want %q", got, tc.prelude)
+			}
+		})
+	}
+}
+
+func TestReconnect(t *testing.T) {
+	srv := newFakeRedis(t, "")
+	s := redisstore.New(srv.addr(), redisstore.Options{})
+	ctx := context.Background()
+	for range 2 {
+		if _, _, err := s.Load(ctx, "db"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := s.Load(ctx, "db"); err != nil {
+		t.Fatalf("Load after Close: %v", err)
+	}
+	s.Close()
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+	if srv.conns != 2 {
+		t.Fatalf("dialed %d times, want one connection reused and one after Close", srv.conns)
+	}
+}
+
+func TestUnreachable(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Skipf("no TCP: %v", err)
+	}
+	addr := ln.Addr().String()
+	ln.Close()
+	s := redisstore.New(addr, redisstore.Options{})
+	if _, _, err := s.Load(context.Background(), "db"); err == nil {
+		t.Fatal("Load from a closed port succeeded")
+	}
+}
+
+func TestSharesTrips(t *testing.T) {
+	srv := newFakeRedis(t, "")
+	newBreaker := func() *sparkgap.CircuitBreaker {
+		t.Helper()
+		store := redisstore.New(srv.addr(), redisstore.Options{})
+		t.Cleanup(func() { store.Close() })
+		br, err := sparkgap.NewCircuitBreaker("payments", &sparkgap.BreakerConfig{RetryInterval: time.Minute, StateStore: store})
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { br.Close() })
+		return br
+	}
+	newBreaker().Trip()
+	deadline := time.Now().Add(5 * time.Second)
+	for !strings.Contains(srv.commands(), "SET sparkgap:payments") {
+		if time.Now().After(deadline) {
+			t.Fatal("trip was never saved")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if st := newBreaker().State(); st != sparkgap.StateOpen {
+		t.Fatalf("replica started %s, want Open from the shared trip", st)
+	}
+}
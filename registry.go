@@ -0,0 +1,242 @@
+package sparkgap
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+)
+
+var (
+	// ErrDuplicateName is returned by Registry.Register when the name is already taken.
+	ErrDuplicateName = errors.New("breaker name already registered")
+	// ErrUnknownTemplate is returned by Registry.NewFromTemplate for a template that was never defined.
+	ErrUnknownTemplate = errors.New("unknown breaker template")
+)
+
+/*
+Managed is the type-independent view of a breaker, satisfied by *CircuitBreaker and every *Breaker[T], that
+registries and operational tooling work with.
+*/
+type Managed interface {
+	Name() string
+	Snapshot() BreakerSnapshot
+	Trip()
+	Reset()
+	ProbeNow() bool
+	ForceOpen()
+	ForceClosed()
+	Disable()
+	ClearForced()
+	Close() error
+	Subscribe(fn func(Event)) (unsubscribe func())
+}
+
+/*
+Registry keeps track of breakers by name so they can be inspected and controlled together,
+for example through the admin HTTP handler. It is safe for concurrent use.
+*/
+type Registry struct {
+	mu       sync.RWMutex
+	breakers map[string]Managed
+	// subs are registry-wide subscriptions; unsubs holds, per subscription, the functions
+	// undoing it on each breaker.
+	subs   map[int]func(Event)
+	unsubs map[int]map[string]func()
+	nextID int
+	// templates are the configs defined with DefineTemplate.
+	templates map[string]*BreakerConfig
+}
+
+// NewRegistry returns an empty Registry.
+func NewRegistry() *Registry {
+	return &Registry{
+		breakers:  make(map[string]Managed),
+		subs:      make(map[int]func(Event)),
+		unsubs:    make(map[int]map[string]func()),
+		templates: make(map[string]*BreakerConfig),
+	}
+}
+
+/*
+DefineTemplate stores a copy of cfg under name, such as "fast-internal" or "slow-external", for
+NewFromTemplate. cfg is validated as by NewCircuitBreaker. Defining a name again replaces the
+template for breakers created later; existing breakers keep their config.
+*/
+func (r *Registry) DefineTemplate(name string, cfg *BreakerConfig) error {
+	c := cfg.Clone()
+	if c == nil {
+		c = &BreakerConfig{}
+	}
+	if err := validate(c); err != nil {
+		return fmt.Errorf("template %q: %w", name, err)
+	}
+	r.mu.Lock()
+	r.templates[name] = c
+	r.mu.Unlock()
+	return nil
+}
+
+/*
+NewFromTemplate creates an untyped breaker called name from a copy of the template defined
+under template and registers it. It fails with ErrUnknownTemplate if there is no such template
+and with ErrDuplicateName if name is taken.
+*/
+func (r *Registry) NewFromTemplate(template, name string) (*CircuitBreaker, error) {
+	r.mu.RLock()
+	cfg, ok := r.templates[template]
+	r.mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownTemplate, template)
+	}
+	cb, err := newCircuitBreaker(name, *cfg.Clone(), true)
+	if err != nil {
+		return nil, fmt.Errorf("template %q: %w", template, err)
+	}
+	if err := r.Register(cb); err != nil {
+		cb.Close()
+		return nil, err
+	}
+	return cb, nil
+}
+
+// Register adds b to the registry. It fails with ErrDuplicateName if the name is taken.
+func (r *Registry) Register(b Managed) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.breakers[b.Name()]; ok {
+		return fmt.Errorf("%w: %q", ErrDuplicateName, b.Name())
+	}
+	r.breakers[b.Name()] = b
+	for id, fn := range r.subs {
+		r.unsubs[id][b.Name()] = b.Subscribe(fn)
+	}
+	return nil
+}
+
+// Unregister removes the breaker called name, reporting whether it was registered.
+func (r *Registry) Unregister(name string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	_, ok := r.breakers[name]
+	delete(r.breakers, name)
+	for _, unsubs := range r.unsubs {
+		if unsub, found := unsubs[name]; found {
+			unsub()
+			delete(unsubs, name)
+		}
+	}
+	return ok
+}
+
+/*
+Subscribe registers fn for the events of every breaker in the registry, including breakers
+registered later, and returns a function that removes it again.
+*/
+func (r *Registry) Subscribe(fn func(Event)) (unsubscribe func()) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	id := r.nextID
+	r.nextID++
+	r.subs[id] = fn
+	unsubs := make(map[string]func(), len(r.breakers))
+	for name, b := range r.breakers {
+		unsubs[name] = b.Subscribe(fn)
+	}
+	r.unsubs[id] = unsubs
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			r.mu.Lock()
+			defer r.mu.Unlock()
+			for _, unsub := range r.unsubs[id] {
+				unsub()
+			}
+			delete(r.subs, id)
+			delete(r.unsubs, id)
+		})
+	}
+}
+
+// Get returns the breaker called name.
+func (r *Registry) Get(name string) (Managed, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	b, ok := r.breakers[name]
+	return b, ok
+}
+
+/*
+CircuitBreaker returns the core of the breaker called name, e.g. one created by
+NewRegistryFromConfig, so callers can wrap it with Typed. It reports false if there is no such
+breaker or it is not a sparkgap breaker.
+*/
+func (r *Registry) CircuitBreaker(name string) (*CircuitBreaker, bool) {
+	b, ok := r.Get(name)
+	if !ok {
+		return nil, false
+	}
+	c, ok := b.(interface{ core() *CircuitBreaker })
+	if !ok {
+		return nil, false
+	}
+	return c.core(), true
+}
+
+// All returns every registered breaker ordered by name.
+func (r *Registry) All() []Managed {
+	r.mu.RLock()
+	all := make([]Managed, 0, len(r.breakers))
+	for _, b := range r.breakers {
+		all = append(all, b)
+	}
+	r.mu.RUnlock()
+	slices.SortFunc(all, func(a, b Managed) int { return strings.Compare(a.Name(), b.Name()) })
+	return all
+}
+
+// Snapshots returns a snapshot of every registered breaker ordered by name.
+func (r *Registry) Snapshots() []BreakerSnapshot {
+	all := r.All()
+	snaps := make([]BreakerSnapshot, len(all))
+	for i, b := range all {
+		snaps[i] = b.Snapshot()
+	}
+	return snaps
+}
+
+/*
+LogAll renders every registered breaker as one row of a table to w: its state, consecutive
+failures, lifetime failure rate and the time left until it probes, for services with too many
+dependencies to read a LogStateTo table each.
+*/
+func (r *Registry) LogAll(w io.Writer) {
+	tw := table.NewWriter()
+	tw.SetStyle(table.StyleRounded)
+	tw.AppendHeader(table.Row{"Breaker", "State", "Failures", "Failure rate", "Half-Open in"})
+	for _, s := range r.Snapshots() {
+		state := s.State.String()
+		if s.Forced != NotForced {
+			state = fmt.Sprintf("%s (forced %s)", s.State, s.Forced)
+		}
+		rate, probeIn := "-", "-"
+		if s.TotalCalls > 0 {
+			rate = fmt.Sprintf("%.1f%%", float64(s.TotalFailures)*100/float64(s.TotalCalls))
+		}
+		if s.State == StateOpen {
+			probeIn = s.UntilHalfOpen.Round(time.Millisecond).String()
+		}
+		tw.AppendRow(table.Row{s.Name, state, fmt.Sprintf("%d / %d", s.FailureCount, s.FailureThreshold), rate, probeIn})
+	}
+	fmt.Fprintln(w, tw.Render())
+}
+
+var (
+	_ Managed = (*CircuitBreaker)(nil)
+	_ Managed = (*Breaker[struct{}])(nil)
+)
@@ -0,0 +1,122 @@
+package sparkgap
+
+import (
+	"maps"
+	"slices"
+	"sync"
+	"time"
+)
+
+// rejectionCounts tallies rejected calls per ReasonCode.
+type rejectionCounts struct {
+	mu sync.Mutex
+	m  map[ReasonCode]uint64
+}
+
+func (r *rejectionCounts) add(reason ReasonCode) {
+	r.mu.Lock()
+	if r.m == nil {
+		r.m = make(map[ReasonCode]uint64)
+	}
+	r.m[reason]++
+	r.mu.Unlock()
+}
+
+func (r *rejectionCounts) snapshot() map[ReasonCode]uint64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return maps.Clone(r.m)
+}
+
+// reject counts a rejection for reason and returns the error handed back to the caller.
+func (br *CircuitBreaker) reject(reason ReasonCode, err error) *RejectionError {
+	br.rejections.add(reason)
+	now := br.clock.Now()
+	br.stats.reject(now)
+	rej := reject(br.name, reason, err)
+	if reason == ReasonOpen {
+		rej.RetryAfter = br.UntilHalfOpen()
+	}
+	if br.rejectSummary != nil {
+		br.summarizeRejection(now, reason)
+		return rej
+	}
+	br.logRejection(reason)
+	if br.hasSubscribers() {
+		st := br.getState()
+		br.publish(Event{Kind: EventShortCircuit, From: st, To: st, Err: rej})
+	}
+	return rej
+}
+
+// Rejections returns how many calls the breaker has rejected so far, broken down by reason.
+func (br *CircuitBreaker) Rejections() map[ReasonCode]uint64 {
+	m := br.rejections.snapshot()
+	if m == nil {
+		m = map[ReasonCode]uint64{}
+	}
+	return m
+}
+
+func sortedReasons(m map[ReasonCode]uint64) []ReasonCode {
+	return slices.Sorted(maps.Keys(m))
+}
+
+// rejectionSummary counts rejections between two EventRejectionSummary events.
+type rejectionSummary struct {
+	every time.Duration
+
+	mu     sync.Mutex
+	timer  Timer
+	since  time.Time
+	total  uint64
+	counts map[ReasonCode]uint64
+}
+
+func newRejectionSummary(every time.Duration) *rejectionSummary {
+	if every <= 0 {
+		return nil
+	}
+	return &rejectionSummary{every: every}
+}
+
+// summarizeRejection counts a rejection, arming the timer that flushes the summary if it is
+// the first of the interval.
+func (br *CircuitBreaker) summarizeRejection(now time.Time, reason ReasonCode) {
+	s := br.rejectSummary
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.total == 0 {
+		s.since = now
+		s.counts = make(map[ReasonCode]uint64)
+		if s.timer == nil {
+			s.timer = br.clock.AfterFunc(s.every, br.flushRejections)
+		} else {
+			s.timer.Reset(s.every)
+		}
+	}
+	s.total++
+	s.counts[reason]++
+}
+
+// flushRejections logs and publishes the rejections counted since the last summary, if any.
+func (br *CircuitBreaker) flushRejections() {
+	s := br.rejectSummary
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	if s.timer != nil {
+		s.timer.Stop()
+	}
+	total, counts, since := s.total, s.counts, s.since
+	s.total, s.counts = 0, nil
+	s.mu.Unlock()
+	if total == 0 {
+		return
+	}
+	st := br.getState()
+	ev := Event{Kind: EventRejectionSummary, From: st, To: st, Rejected: total, Rejections: counts, Elapsed: br.clock.Now().Sub(since)}
+	br.logRejectionSummary(ev)
+	br.publish(ev)
+}
@@ -0,0 +1,107 @@
+package sparkgap
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync/atomic"
+	"time"
+)
+
+/*
+UpdateConfig swaps the thresholds and intervals of a live breaker for those in cfg, as a single
+atomic change: FailureThreshold, RetryInterval, RetryBackoff, RetryJitter, MaxOpenDuration and
+the Half-Open settings (HalfOpenMaxProbes, HalfOpenMaxFailurePercent, HalfOpenFastFail,
+HalfOpenMaxFailures, HalfOpenMode and SuccessThreshold). Zero fields take their defaults, including
+those set with SetDefaultConfig, as in NewBreaker; other
+fields are ignored. Counters and the current state are kept, and an open breaker keeps its
+current retry time. It returns an error wrapping ErrInvalidConfig, and changes nothing, if cfg
+is invalid.
+*/
+func (br *CircuitBreaker) UpdateConfig(cfg *BreakerConfig) error {
+	var c BreakerConfig
+	if cfg != nil {
+		c = *cfg
+	}
+	mergeFleetDefaults(&c)
+	if err := validate(&c); err != nil {
+		return err
+	}
+	applyDefaults(&c)
+
+	br.mu.Lock()
+	defer br.mu.Unlock()
+	atomic.StoreUint32(&br.counter.failureThreshold, c.FailureThreshold)
+	br.counter.retryInterval = c.RetryInterval
+	br.counter.halfOpenMaxProbes = c.HalfOpenMaxProbes
+	br.counter.halfOpenMaxFailurePercent = c.HalfOpenMaxFailurePercent
+	br.counter.halfOpenMode = c.HalfOpenMode
+	br.counter.successThreshold = c.SuccessThreshold
+	br.counter.halfOpenFastFail = c.HalfOpenFastFail
+	br.counter.halfOpenMaxFailures = c.HalfOpenMaxFailures
+	br.backoff = c.RetryBackoff
+	br.jitter = c.RetryJitter
+	br.maxOpen = c.MaxOpenDuration
+	return nil
+}
+
+// defaultWatchInterval is how often WatchConfig polls when given no interval.
+const defaultWatchInterval = time.Second
+
+/*
+WatchConfig polls the config file at path every interval and, whenever it changes, applies
+the new profiles to the registered breakers it maps with UpdateConfig. Breakers the file names
+but the registry does not hold are skipped. Errors loading the file or applying a profile are
+passed to onError, if set, and leave the previous settings in place. An interval of zero or
+less polls every second. WatchConfig blocks until ctx is done and then returns ctx.Err().
+*/
+func (r *Registry) WatchConfig(ctx context.Context, path string, interval time.Duration, onError func(error)) error {
+	report := func(err error) {
+		if onError != nil {
+			onError(err)
+		}
+	}
+	if interval <= 0 {
+		interval = defaultWatchInterval
+	}
+	var last time.Time
+	if fi, err := os.Stat(path); err == nil {
+		last = fi.ModTime()
+	}
+	tick := time.NewTicker(interval)
+	defer tick.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-tick.C:
+		}
+		fi, err := os.Stat(path)
+		if err != nil {
+			report(err)
+			continue
+		}
+		if fi.ModTime().Equal(last) {
+			continue
+		}
+		last = fi.ModTime()
+		fc, err := LoadConfig(path)
+		if err != nil {
+			report(err)
+			continue
+		}
+		for name := range fc.Breakers {
+			cb, ok := r.CircuitBreaker(name)
+			if !ok {
+				continue
+			}
+			cfg, err := fc.BreakerConfig(name)
+			if err == nil {
+				err = cb.UpdateConfig(cfg)
+			}
+			if err != nil {
+				report(fmt.Errorf("breaker %q: %w", name, err))
+			}
+		}
+	}
+}
@@ -0,0 +1,85 @@
+/*
+Package retry retries calls through a sparkgap breaker with jittered exponential backoff.
+Every attempt is a separate breaker call, so each failure is counted exactly once, and retrying
+stops as soon as the breaker rejects an attempt instead of hammering an open circuit.
+*/
+package retry
+
+import (
+	"context"
+	"time"
+
+	"github.com/afk-ankit/sparkgap"
+)
+
+const (
+	defaultMaxAttempts = 3
+	defaultInitial     = 100 * time.Millisecond
+)
+
+// Policy configures Execute. The zero Policy makes 3 attempts, 100ms apart and doubling.
+type Policy struct {
+	// MaxAttempts is the total number of attempts, including the first. Zero means 3.
+	MaxAttempts int
+	// Backoff shapes the wait before each retry. A zero Initial means 100ms.
+	Backoff sparkgap.Backoff
+	// Retryable decides whether an error is worth another attempt. Nil retries every error.
+	// Breaker rejections and errors once ctx is done are never retried; an attempt cut off by
+	// the breaker's Timeout while ctx is alive is retried like any other error.
+	Retryable sparkgap.Classifier
+	// Clock times the waits between attempts. Nil uses the wall clock.
+	Clock sparkgap.Clock
+}
+
+/*
+Execute calls fn through br until it succeeds, returns an error p.Retryable rejects, the
+breaker rejects an attempt, ctx is done or p.MaxAttempts attempts have been made. It returns
+the result and error of the last attempt, or ctx.Err() if ctx ended a wait between attempts.
+*/
+func Execute[T any](ctx context.Context, br *sparkgap.Breaker[T], p Policy, fn func(ctx context.Context) (T, error)) (T, error) {
+	attempts := p.MaxAttempts
+	if attempts <= 0 {
+		attempts = defaultMaxAttempts
+	}
+	b := p.Backoff
+	if b.Initial <= 0 {
+		b.Initial = defaultInitial
+	}
+	var (
+		res T
+		err error
+	)
+	for n := 1; ; n++ {
+		res, err = br.ExecuteContext(sparkgap.WithAttempt(ctx, n), fn)
+		if err == nil || n >= attempts || !p.retryable(ctx, err) {
+			return res, err
+		}
+		if werr := p.wait(ctx, b.Interval(n)); werr != nil {
+			return res, werr
+		}
+	}
+}
+
+func (p *Policy) retryable(ctx context.Context, err error) bool {
+	if _, rejected := sparkgap.Reason(err); rejected || ctx.Err() != nil {
+		return false
+	}
+	return p.Retryable == nil || p.Retryable(err)
+}
+
+func (p *Policy) wait(ctx context.Context, d time.Duration) error {
+	var after <-chan time.Time
+	if p.Clock != nil {
+		after = p.Clock.After(d)
+	} else {
+		t := time.NewTimer(d)
+		defer t.Stop()
+		after = t.C
+	}
+	select {
+	case <-after:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
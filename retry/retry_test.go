@@ -0,0 +1,116 @@
+package retry_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/afk-ankit/sparkgap"
+	"github.com/afk-ankit/sparkgap/retry"
+)
+
+var errFlaky = errors.New("flaky")
+
+func newBreaker(t *testing.T, cfg *sparkgap.BreakerConfig) *sparkgap.Breaker[int] {
+	t.Helper()
+	br, err := sparkgap.NewBreaker[int]("retry", cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { br.Close() })
+	return br
+}
+
+var fast = retry.Policy{Backoff: sparkgap.Backoff{Initial: time.Millisecond}}
+
+func TestExecute(t *testing.T) {
+	cases := []struct {
+		name      string
+		policy    retry.Policy
+		failures  int
+		err       error
+		wantCalls int
+		wantErr   error
+	}{
+		{"first attempt succeeds", fast, 0, errFlaky, 1, nil},
+		{"succeeds on a retry", fast, 2, errFlaky, 3, nil},
+		{"gives up after MaxAttempts", fast, 5, errFlaky, 3, errFlaky},
+		{"MaxAttempts", retry.Policy{MaxAttempts: 5, Backoff: fast.Backoff}, 4, errFlaky, 5, nil},
+		{
+			"Retryable rejects the error",
+			retry.Policy{Backoff: fast.Backoff, Retryable: func(err error) bool { return !errors.Is(err, errFlaky) }},
+			5, errFlaky, 1, errFlaky,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			br := newBreaker(t, &sparkgap.BreakerConfig{FailureThreshold: 100})
+			calls := 0
+			res, err := retry.Execute(context.Background(), br, tc.policy, func(context.Context) (int, error) {
+				calls++
+				if calls <= tc.failures {
+					return 0, tc.err
+				}
+				return calls, nil
+			})
+			if calls != tc.wantCalls || !errors.Is(err, tc.wantErr) {
+				t.Fatalf("%d calls, error %v; want %d calls, error %v", calls, err, tc.wantCalls, tc.wantErr)
+			}
+			if err == nil && res != calls {
+				t.Fatalf("result %d, want %d", res, calls)
+			}
+		})
+	}
+}
+
+func TestExecuteStopsWhenBreakerOpens(t *testing.T) {
+	br := newBreaker(t, &sparkgap.BreakerConfig{FailureThreshold: 2})
+	calls := 0
+	_, err := retry.Execute(context.Background(), br, retry.Policy{MaxAttempts: 5, Backoff: fast.Backoff}, func(context.Context) (int, error) {
+		calls++
+		return 0, errFlaky
+	})
+	if calls != 2 || !errors.Is(err, sparkgap.ErrOpen) {
+		t.Fatalf("%d calls, error %v; want 2 calls, then ErrOpen", calls, err)
+	}
+}
+
+func TestExecuteRetriesBreakerTimeout(t *testing.T) {
+	br := newBreaker(t, &sparkgap.BreakerConfig{FailureThreshold: 100, Timeout: 5 * time.Millisecond})
+	calls := 0
+	_, err := retry.Execute(context.Background(), br, fast, func(ctx context.Context) (int, error) {
+		calls++
+		<-ctx.Done()
+		return 0, ctx.Err()
+	})
+	if calls != 3 || !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("%d calls, error %v; want 3 timed-out attempts", calls, err)
+	}
+}
+
+func TestExecuteStopsWhenCtxDone(t *testing.T) {
+	br := newBreaker(t, &sparkgap.BreakerConfig{FailureThreshold: 100})
+	ctx, cancel := context.WithCancel(context.Background())
+	calls := 0
+	_, err := retry.Execute(ctx, br, fast, func(context.Context) (int, error) {
+		calls++
+		cancel()
+		return 0, errFlaky
+	})
+	if calls != 1 || !errors.Is(err, errFlaky) {
+		t.Fatalf("%d calls, error %v; want 1 call", calls, err)
+	}
+}
+
+func TestExecuteAttemptNumbers(t *testing.T) {
+	br := newBreaker(t, &sparkgap.BreakerConfig{FailureThreshold: 100})
+	var attempts []int
+	_, _ = retry.Execute(context.Background(), br, fast, func(ctx context.Context) (int, error) {
+		attempts = append(attempts, sparkgap.AttemptFrom(ctx))
+		return 0, errFlaky
+	})
+	if len(attempts) != 3 || attempts[0] != 1 || attempts[2] != 3 {
+		t.Fatalf("attempts = %v, want [1 2 3]", attempts)
+	}
+}
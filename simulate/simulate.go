@@ -0,0 +1,147 @@
+/*
+Package simulate replays a scripted sequence of call outcomes against a breaker configuration
+in virtual time and reports the resulting state timeline, so thresholds and retry intervals can
+be tuned before they meet production traffic:
+
+	report, err := simulate.Run(cfg,
+		simulate.Succeed(100),
+		simulate.Fail(8),
+		simulate.Wait(10*time.Second),
+		simulate.Succeed(20),
+	)
+	fmt.Print(report)
+
+Runs are deterministic unless the configuration asks for randomness, through RetryJitter or
+RetryBackoff.Jitter.
+*/
+package simulate
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/afk-ankit/sparkgap"
+	"github.com/afk-ankit/sparkgap/sparkgaptest"
+)
+
+// ErrSimulated is the error returned by failing calls that were not given one.
+var ErrSimulated = errors.New("simulate: failure")
+
+// start is the virtual time every run starts at; reports give times relative to it.
+var start = time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+
+/*
+Step is one entry of a script: Wait lets virtual time pass, then Calls calls are made one after
+the other, each taking Latency and failing with Err if it is set.
+*/
+type Step struct {
+	Wait    time.Duration
+	Calls   int
+	Err     error
+	Latency time.Duration
+}
+
+// Succeed is a step of n successful calls.
+func Succeed(n int) Step { return Step{Calls: n} }
+
+// Fail is a step of n calls failing with ErrSimulated.
+func Fail(n int) Step { return Step{Calls: n, Err: ErrSimulated} }
+
+// Wait is a step letting d of virtual time pass without calls.
+func Wait(d time.Duration) Step { return Step{Wait: d} }
+
+// Slow is a step of n successful calls taking d each, for tuning SlowCallThreshold.
+func Slow(n int, d time.Duration) Step { return Step{Calls: n, Latency: d} }
+
+// Transition is a state change seen during a run.
+type Transition struct {
+	// At is the virtual time since the start of the run.
+	At       time.Duration
+	From, To sparkgap.State
+	Cause    sparkgap.Cause
+	// Call is the number of calls made so far, including one in progress.
+	Call int
+}
+
+// Report is the outcome of a run.
+type Report struct {
+	Timeline []Transition
+	// Calls counts the calls made, Failed those that ran and failed, Rejected those the breaker
+	// refused without running them.
+	Calls    int
+	Failed   int
+	Rejected int
+	// Duration is the virtual time the run took, and TimeIn how much of it was spent in each state.
+	Duration time.Duration
+	TimeIn   map[sparkgap.State]time.Duration
+	Final    sparkgap.State
+}
+
+/*
+Run creates a breaker from cfg, driven by a fake clock, and replays steps against it. cfg is
+copied and its Clock replaced. It returns an error if cfg is invalid.
+*/
+func Run(cfg sparkgap.BreakerConfig, steps ...Step) (*Report, error) {
+	clock := sparkgaptest.NewFakeClock(start)
+	cfg.Clock = clock
+	br, err := sparkgap.NewCircuitBreaker("simulation", &cfg)
+	if err != nil {
+		return nil, err
+	}
+	defer br.Close()
+
+	r := &Report{TimeIn: make(map[sparkgap.State]time.Duration)}
+	unsubscribe := br.Subscribe(func(ev sparkgap.Event) {
+		if ev.Kind == sparkgap.EventStateChange {
+			r.Timeline = append(r.Timeline, Transition{At: ev.Time.Sub(start), From: ev.From, To: ev.To, Cause: ev.Cause, Call: r.Calls})
+		}
+	})
+	defer unsubscribe()
+
+	for _, st := range steps {
+		clock.Advance(st.Wait)
+		for range st.Calls {
+			r.Calls++
+			err := br.Do(func() error {
+				clock.Advance(st.Latency)
+				return st.Err
+			})
+			var rej *sparkgap.RejectionError
+			switch {
+			case errors.As(err, &rej):
+				r.Rejected++
+			case err != nil:
+				r.Failed++
+			}
+		}
+	}
+	r.Duration = clock.Now().Sub(start)
+	r.Final = br.State()
+
+	state, since := sparkgap.StateClosed, time.Duration(0)
+	for _, tr := range r.Timeline {
+		r.TimeIn[state] += tr.At - since
+		state, since = tr.To, tr.At
+	}
+	r.TimeIn[state] += r.Duration - since
+	return r, nil
+}
+
+// String renders the report as a timeline followed by totals.
+func (r *Report) String() string {
+	var b strings.Builder
+	for _, tr := range r.Timeline {
+		fmt.Fprintf(&b, "%10s  call %-6d %s → %s", tr.At, tr.Call, tr.From, tr.To)
+		if tr.Cause != "" {
+			fmt.Fprintf(&b, " (%s)", tr.Cause)
+		}
+		b.WriteByte('\n')
+	}
+	fmt.Fprintf(&b, "%d calls over %s: %d failed, %d rejected; ended %s\n", r.Calls, r.Duration, r.Failed, r.Rejected, r.Final)
+	for _, s := range []sparkgap.State{sparkgap.StateClosed, sparkgap.StateThrottled, sparkgap.StateOpen, sparkgap.StateHalfOpen} {
+		fmt.Fprintf(&b, "%s for %s\n", s, r.TimeIn[s])
+	}
+	return b.String()
+}
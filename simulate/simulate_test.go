@@ -0,0 +1,72 @@
+package simulate_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/afk-ankit/sparkgap"
+	"github.com/afk-ankit/sparkgap/simulate"
+)
+
+func TestRun(t *testing.T) {
+	cfg := sparkgap.BreakerConfig{
+		FailureThreshold: 3,
+		RetryInterval:    10 * time.Second,
+		HalfOpenMode:     sparkgap.HalfOpenConsecutive,
+		SuccessThreshold: 2,
+	}
+	r, err := simulate.Run(cfg,
+		simulate.Succeed(5),
+		simulate.Fail(3),
+		simulate.Succeed(4),
+		simulate.Wait(10*time.Second),
+		simulate.Slow(2, time.Second),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []simulate.Transition{
+		{At: 0, From: sparkgap.StateClosed, To: sparkgap.StateOpen, Call: 8},
+		{At: 10 * time.Second, From: sparkgap.StateOpen, To: sparkgap.StateHalfOpen, Call: 12},
+		{At: 12 * time.Second, From: sparkgap.StateHalfOpen, To: sparkgap.StateClosed, Call: 14},
+	}
+	if len(r.Timeline) != len(want) {
+		t.Fatalf("timeline:\n%s", r)
+	}
+	for i, tr := range r.Timeline {
+		if tr.At != want[i].At || tr.From != want[i].From || tr.To != want[i].To || tr.Call != want[i].Call {
+			t.Errorf("transition %d = %+v, want %+v", i, tr, want[i])
+		}
+	}
+	if r.Calls != 14 || r.Failed != 3 || r.Rejected != 4 || r.Final != sparkgap.StateClosed || r.Duration != 12*time.Second {
+		t.Fatalf("totals: %d calls, %d failed, %d rejected, ended %s after %s", r.Calls, r.Failed, r.Rejected, r.Final, r.Duration)
+	}
+	if r.TimeIn[sparkgap.StateOpen] != 10*time.Second || r.TimeIn[sparkgap.StateHalfOpen] != 2*time.Second {
+		t.Fatalf("TimeIn = %v, want 10s Open and 2s Half-Open", r.TimeIn)
+	}
+	if s := r.String(); !strings.Contains(s, "14 calls over 12s: 3 failed, 4 rejected; ended Closed") {
+		t.Fatalf("String() =\n%s", s)
+	}
+}
+
+func TestRunCustomError(t *testing.T) {
+	errTimeout := errors.New("timeout")
+	r, err := simulate.Run(sparkgap.BreakerConfig{
+		FailureThreshold: 1,
+		IsFailure:        sparkgap.Is(errTimeout),
+	}, simulate.Fail(5), simulate.Step{Calls: 1, Err: errTimeout}, simulate.Succeed(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Failed != 6 || r.Rejected != 1 || r.Final != sparkgap.StateOpen || len(r.Timeline) != 1 || r.Timeline[0].Call != 6 {
+		t.Fatalf("report:\n%s", r)
+	}
+}
+
+func TestRunInvalidConfig(t *testing.T) {
+	if _, err := simulate.Run(sparkgap.BreakerConfig{RetryInterval: -time.Second}); !errors.Is(err, sparkgap.ErrInvalidConfig) {
+		t.Fatalf("Run = %v, want ErrInvalidConfig", err)
+	}
+}
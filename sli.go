@@ -0,0 +1,100 @@
+package sparkgap
+
+import (
+	"sync"
+	"time"
+)
+
+// maxSLIBuckets bounds the memory used by the SLI window regardless of how long the longest window is.
+const maxSLIBuckets = 3600
+
+/*
+SLI is the availability of a breaker's dependency over a trailing window: the share of calls
+that succeeded out of the calls the breaker admitted. Rejected calls are left out, so the SLI
+reflects the dependency rather than the breaker protecting it; see Rejections for those.
+*/
+type SLI struct {
+	Window     time.Duration `json:"window"`
+	Total      uint64        `json:"total"`
+	Successful uint64        `json:"successful"`
+	// Ratio is Successful/Total, or 1 when there was no traffic in the window.
+	Ratio float64 `json:"ratio"`
+}
+
+type sliBucket struct {
+	start      int64
+	total      uint64
+	successful uint64
+}
+
+// sliWindow counts outcomes into fixed-width time buckets covering the longest configured window.
+type sliWindow struct {
+	mu         sync.Mutex
+	windows    []time.Duration
+	resolution time.Duration
+	buckets    []sliBucket
+}
+
+func newSLIWindow(windows []time.Duration) *sliWindow {
+	var longest time.Duration
+	keep := make([]time.Duration, 0, len(windows))
+	for _, w := range windows {
+		if w <= 0 {
+			continue
+		}
+		keep = append(keep, w)
+		longest = max(longest, w)
+	}
+	if len(keep) == 0 {
+		return nil
+	}
+	resolution := max(time.Second, longest/maxSLIBuckets)
+	n := int((longest + resolution - 1) / resolution)
+	return &sliWindow{
+		windows:    keep,
+		resolution: resolution,
+		buckets:    make([]sliBucket, n),
+	}
+}
+
+func (w *sliWindow) record(now time.Time, success bool) {
+	if w == nil {
+		return
+	}
+	slot := now.UnixNano() / int64(w.resolution)
+	w.mu.Lock()
+	b := &w.buckets[slot%int64(len(w.buckets))]
+	if b.start != slot {
+		*b = sliBucket{start: slot}
+	}
+	b.total++
+	if success {
+		b.successful++
+	}
+	w.mu.Unlock()
+}
+
+func (w *sliWindow) read(now time.Time) []SLI {
+	if w == nil {
+		return nil
+	}
+	slot := now.UnixNano() / int64(w.resolution)
+	out := make([]SLI, len(w.windows))
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for i, win := range w.windows {
+		span := int64((win + w.resolution - 1) / w.resolution)
+		s := SLI{Window: win, Ratio: 1}
+		for _, b := range w.buckets {
+			if b.start > slot-span && b.start <= slot {
+				s.Total += b.total
+				s.Successful += b.successful
+			}
+		}
+		if s.Total > 0 {
+			s.Ratio = float64(s.Successful) / float64(s.Total)
+		}
+		out[i] = s
+	}
+	return out
+}
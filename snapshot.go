@@ -0,0 +1,276 @@
+package sparkgap
+
+import (
+	"encoding/json"
+	"sync/atomic"
+	"time"
+)
+
+/*
+BreakerSnapshot is a point-in-time copy of a breaker's state, counters and thresholds,
+meant for dashboards and structured logs.
+*/
+type BreakerSnapshot struct {
+	Name           string
+	State          State
+	LastTransition time.Time
+	// UntilHalfOpen is how long an open breaker waits before probing; zero in other states.
+	UntilHalfOpen time.Duration
+	// Forced is the operator override set with ForceOpen, ForceClosed or Disable, if any.
+	Forced ForcedMode
+	// Parent is the name of the breaker this one was created under with Child, if any.
+	Parent string
+	// Labels are the breaker's Labels.
+	Labels map[string]string
+
+	FailureCount     uint32
+	FailureThreshold uint32
+	// FailureWeight is the accumulated weight of consecutive failures; zero without a FailureWeigher.
+	FailureWeight float64
+	// FailuresByCategory holds the consecutive failures of each category in CategoryThresholds.
+	FailuresByCategory        map[ErrorCategory]uint32
+	RetryInterval             time.Duration
+	ConsecutiveTrips          uint32
+	HalfOpenMaxProbes         uint32
+	HalfOpenSuccessCount      uint32
+	HalfOpenFailureCount      uint32
+	HalfOpenMaxFailurePercent uint32
+	HalfOpenMode              HalfOpenMode
+	SuccessThreshold          uint32
+	Timeout                   time.Duration
+
+	// TotalCalls and TotalFailures count the calls admitted over the breaker's lifetime.
+	TotalCalls    uint64
+	TotalFailures uint64
+
+	// InFlight is the number of calls running now, MaxInFlight the highest number seen, and
+	// AvgInFlight the average number already running when a call started.
+	InFlight    int64
+	MaxInFlight int64
+	AvgInFlight float64
+
+	SnoozedUntil time.Time
+	Rejections   map[ReasonCode]uint64
+	// DroppedEvents is the number of events channels from Events have dropped.
+	DroppedEvents uint64
+	SLI           []SLI
+	// Latency is nil unless SlowCallThreshold is set.
+	Latency *LatencyStats
+}
+
+// Snapshot returns the current state of the breaker.
+func (br *CircuitBreaker) Snapshot() BreakerSnapshot {
+	br.mu.RLock()
+	now := br.clock.Now()
+	v := br.readCounters()
+	s := BreakerSnapshot{
+		Name:                      br.name,
+		State:                     br.state,
+		LastTransition:            br.lastTransition,
+		FailureCount:              v.failureCount,
+		FailureWeight:             float64(v.failureWeight) / weightScale,
+		FailuresByCategory:        v.categories,
+		FailureThreshold:          atomic.LoadUint32(&br.counter.failureThreshold),
+		RetryInterval:             br.counter.retryInterval,
+		ConsecutiveTrips:          br.trips,
+		HalfOpenMaxProbes:         br.counter.halfOpenMaxProbes,
+		HalfOpenSuccessCount:      v.halfOpenSuccessCount,
+		HalfOpenFailureCount:      v.halfOpenFailureCount,
+		HalfOpenMaxFailurePercent: br.counter.halfOpenMaxFailurePercent,
+		HalfOpenMode:              br.counter.halfOpenMode,
+		SuccessThreshold:          br.counter.successThreshold,
+		Timeout:                   br.timeout,
+		SnoozedUntil:              br.snoozedUntil,
+	}
+	s.UntilHalfOpen = br.untilHalfOpenLocked(now)
+	br.mu.RUnlock()
+
+	s.Forced = br.Forced()
+	if br.parent != nil {
+		s.Parent = br.parent.name
+	}
+	s.Labels = br.Labels()
+	s.TotalCalls = br.totalCalls.Load()
+	s.TotalFailures = br.totalFailures.Load()
+	s.InFlight = br.conc.inFlight.Load()
+	s.MaxInFlight = br.conc.max.Load()
+	s.AvgInFlight = br.conc.average()
+	s.Rejections = br.Rejections()
+	s.DroppedEvents = br.DroppedEvents()
+	s.SLI = br.sli.read(now)
+	s.Latency = br.latency.stats(now)
+	return s
+}
+
+type sliJSON struct {
+	Window     string  `json:"window"`
+	Total      uint64  `json:"total"`
+	Successful uint64  `json:"successful"`
+	Ratio      float64 `json:"ratio"`
+}
+
+type latencyJSON struct {
+	Window          string  `json:"window"`
+	Calls           uint64  `json:"calls"`
+	SlowCalls       uint64  `json:"slow_calls"`
+	SlowCallPercent float64 `json:"slow_call_percent"`
+	P99             string  `json:"p99"`
+}
+
+type snapshotJSON struct {
+	Name                      string                   `json:"name"`
+	State                     State                    `json:"state"`
+	Forced                    ForcedMode               `json:"forced,omitempty"`
+	Parent                    string                   `json:"parent,omitempty"`
+	Labels                    map[string]string        `json:"labels,omitempty"`
+	LastTransition            *time.Time               `json:"last_transition,omitempty"`
+	UntilHalfOpen             string                   `json:"until_half_open"`
+	FailureCount              uint32                   `json:"failure_count"`
+	FailureThreshold          uint32                   `json:"failure_threshold"`
+	FailureWeight             float64                  `json:"failure_weight,omitempty"`
+	FailuresByCategory        map[ErrorCategory]uint32 `json:"failures_by_category,omitempty"`
+	RetryInterval             string                   `json:"retry_interval"`
+	ConsecutiveTrips          uint32                   `json:"consecutive_trips"`
+	HalfOpenMaxProbes         uint32                   `json:"half_open_max_probes"`
+	HalfOpenSuccessCount      uint32                   `json:"half_open_success_count"`
+	HalfOpenFailureCount      uint32                   `json:"half_open_failure_count"`
+	HalfOpenMaxFailurePercent uint32                   `json:"half_open_max_failure_percent"`
+	HalfOpenMode              HalfOpenMode             `json:"half_open_mode"`
+	SuccessThreshold          uint32                   `json:"success_threshold"`
+	Timeout                   string                   `json:"timeout"`
+	TotalCalls                uint64                   `json:"total_calls"`
+	TotalFailures             uint64                   `json:"total_failures"`
+	InFlight                  int64                    `json:"in_flight"`
+	MaxInFlight               int64                    `json:"max_in_flight"`
+	AvgInFlight               float64                  `json:"avg_in_flight"`
+	SnoozedUntil              *time.Time               `json:"snoozed_until,omitempty"`
+	Rejections                map[ReasonCode]uint64    `json:"rejections"`
+	DroppedEvents             uint64                   `json:"dropped_events,omitempty"`
+	SLI                       []sliJSON                `json:"sli,omitempty"`
+	Latency                   *latencyJSON             `json:"latency,omitempty"`
+}
+
+// MarshalJSON renders the snapshot with snake_case keys and durations as strings such as "5s".
+func (s BreakerSnapshot) MarshalJSON() ([]byte, error) {
+	out := snapshotJSON{
+		Name:                      s.Name,
+		State:                     s.State,
+		Forced:                    s.Forced,
+		Parent:                    s.Parent,
+		Labels:                    s.Labels,
+		UntilHalfOpen:             s.UntilHalfOpen.String(),
+		FailureCount:              s.FailureCount,
+		FailureThreshold:          s.FailureThreshold,
+		FailureWeight:             s.FailureWeight,
+		FailuresByCategory:        s.FailuresByCategory,
+		RetryInterval:             s.RetryInterval.String(),
+		ConsecutiveTrips:          s.ConsecutiveTrips,
+		HalfOpenMaxProbes:         s.HalfOpenMaxProbes,
+		HalfOpenSuccessCount:      s.HalfOpenSuccessCount,
+		HalfOpenFailureCount:      s.HalfOpenFailureCount,
+		HalfOpenMaxFailurePercent: s.HalfOpenMaxFailurePercent,
+		HalfOpenMode:              s.HalfOpenMode,
+		SuccessThreshold:          s.SuccessThreshold,
+		Timeout:                   s.Timeout.String(),
+		TotalCalls:                s.TotalCalls,
+		TotalFailures:             s.TotalFailures,
+		InFlight:                  s.InFlight,
+		MaxInFlight:               s.MaxInFlight,
+		AvgInFlight:               s.AvgInFlight,
+		Rejections:                s.Rejections,
+		DroppedEvents:             s.DroppedEvents,
+	}
+	if !s.LastTransition.IsZero() {
+		out.LastTransition = &s.LastTransition
+	}
+	if !s.SnoozedUntil.IsZero() {
+		out.SnoozedUntil = &s.SnoozedUntil
+	}
+	if l := s.Latency; l != nil {
+		out.Latency = &latencyJSON{
+			Window:          l.Window.String(),
+			Calls:           l.Calls,
+			SlowCalls:       l.SlowCalls,
+			SlowCallPercent: l.SlowCallPercent,
+			P99:             l.P99.String(),
+		}
+	}
+	for _, sli := range s.SLI {
+		out.SLI = append(out.SLI, sliJSON{
+			Window:     sli.Window.String(),
+			Total:      sli.Total,
+			Successful: sli.Successful,
+			Ratio:      sli.Ratio,
+		})
+	}
+	return json.Marshal(out)
+}
+
+// UnmarshalJSON decodes the format produced by MarshalJSON, e.g. when reading the admin API.
+func (s *BreakerSnapshot) UnmarshalJSON(data []byte) error {
+	var in snapshotJSON
+	if err := json.Unmarshal(data, &in); err != nil {
+		return err
+	}
+	var err error
+	dur := func(v string) time.Duration {
+		if v == "" || err != nil {
+			return 0
+		}
+		var d time.Duration
+		d, err = time.ParseDuration(v)
+		return d
+	}
+	*s = BreakerSnapshot{
+		Name:                      in.Name,
+		State:                     in.State,
+		Forced:                    in.Forced,
+		Parent:                    in.Parent,
+		Labels:                    in.Labels,
+		UntilHalfOpen:             dur(in.UntilHalfOpen),
+		FailureCount:              in.FailureCount,
+		FailureThreshold:          in.FailureThreshold,
+		FailureWeight:             in.FailureWeight,
+		FailuresByCategory:        in.FailuresByCategory,
+		RetryInterval:             dur(in.RetryInterval),
+		ConsecutiveTrips:          in.ConsecutiveTrips,
+		HalfOpenMaxProbes:         in.HalfOpenMaxProbes,
+		HalfOpenSuccessCount:      in.HalfOpenSuccessCount,
+		HalfOpenFailureCount:      in.HalfOpenFailureCount,
+		HalfOpenMaxFailurePercent: in.HalfOpenMaxFailurePercent,
+		HalfOpenMode:              in.HalfOpenMode,
+		SuccessThreshold:          in.SuccessThreshold,
+		Timeout:                   dur(in.Timeout),
+		TotalCalls:                in.TotalCalls,
+		TotalFailures:             in.TotalFailures,
+		InFlight:                  in.InFlight,
+		MaxInFlight:               in.MaxInFlight,
+		AvgInFlight:               in.AvgInFlight,
+		Rejections:                in.Rejections,
+		DroppedEvents:             in.DroppedEvents,
+	}
+	if in.LastTransition != nil {
+		s.LastTransition = *in.LastTransition
+	}
+	if in.SnoozedUntil != nil {
+		s.SnoozedUntil = *in.SnoozedUntil
+	}
+	for _, sli := range in.SLI {
+		s.SLI = append(s.SLI, SLI{
+			Window:     dur(sli.Window),
+			Total:      sli.Total,
+			Successful: sli.Successful,
+			Ratio:      sli.Ratio,
+		})
+	}
+	if l := in.Latency; l != nil {
+		s.Latency = &LatencyStats{
+			Window:          dur(l.Window),
+			Calls:           l.Calls,
+			SlowCalls:       l.SlowCalls,
+			SlowCallPercent: l.SlowCallPercent,
+			P99:             dur(l.P99),
+		}
+	}
+	return err
+}
@@ -0,0 +1,68 @@
+package sparkgap
+
+import (
+	"time"
+)
+
+/*
+Snooze silences the breaker for d: calls are still counted and the breaker keeps changing
+state, but no events are delivered to subscribers. Metrics and the FlightRecorder still see
+every event, so they stay current. With SnoozeSuppressesTrips set the breaker
+also refuses to open while snoozed. When the period is over an EventSnoozeEnded reminder is
+emitted and, if trips were suppressed, the failure threshold is re-evaluated.
+Snoozing an already snoozed breaker extends or shortens the period; d <= 0 ends it now.
+*/
+func (br *CircuitBreaker) Snooze(d time.Duration) {
+	br.mu.Lock()
+	defer br.unlockAndNotify()
+	if d <= 0 || br.closed.Load() {
+		br.endSnoozeLocked()
+		return
+	}
+	br.snoozedUntil = br.clock.Now().Add(d)
+	if br.snooze == nil {
+		br.snooze = br.clock.AfterFunc(d, br.snoozeExpired)
+	} else {
+		br.snooze.Reset(d)
+	}
+}
+
+// SnoozedUntil returns when the current snooze ends, or the zero time if the breaker is not snoozed.
+func (br *CircuitBreaker) SnoozedUntil() time.Time {
+	br.mu.RLock()
+	defer br.mu.RUnlock()
+	return br.snoozedUntil
+}
+
+func (br *CircuitBreaker) snoozedLocked() bool {
+	return !br.snoozedUntil.IsZero()
+}
+
+// holdsTripsLocked reports whether a snooze currently prevents the breaker from opening.
+func (br *CircuitBreaker) holdsTripsLocked() bool {
+	return br.snoozeSuppressesTrips && br.snoozedLocked()
+}
+
+func (br *CircuitBreaker) snoozeExpired() {
+	br.mu.Lock()
+	defer br.unlockAndNotify()
+	if !br.snoozedLocked() || br.clock.Now().Before(br.snoozedUntil) {
+		return
+	}
+	br.endSnoozeLocked()
+}
+
+func (br *CircuitBreaker) endSnoozeLocked() {
+	if !br.snoozedLocked() {
+		return
+	}
+	br.snoozedUntil = time.Time{}
+	if br.snooze != nil {
+		br.snooze.Stop()
+	}
+	br.emitLocked(Event{Kind: EventSnoozeEnded, From: br.state, To: br.state})
+	if br.snoozeSuppressesTrips && (br.state == StateClosed || br.state == StateThrottled) && br.overThreshold() {
+		br.cause = CauseSnoozeEnded
+		br.openLocked()
+	}
+}
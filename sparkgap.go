@@ -5,7 +5,14 @@ It allows wrapping function calls to prevent cascading failures and supports fai
 package sparkgap
 
 import (
+	"context"
 	"fmt"
+	"io"
+	"log/slog"
+	"maps"
+	"math/rand/v2"
+	"os"
+	"slices"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -13,201 +20,908 @@ import (
 	"github.com/jedib0t/go-pretty/v6/table"
 )
 
-const (
-	stateClosed = iota
-	stateOpen
-	stateHalfOpen
-)
+type counter struct {
+	// counts holds the consecutive failures and Half-Open results; see MetricsStore.
+	counts                    Counters
+	failureThreshold          uint32
+	retryInterval             time.Duration
+	halfOpenMaxProbes         uint32
+	halfOpenMaxFailurePercent uint32
+	halfOpenMode              HalfOpenMode
+	successThreshold          uint32
+	halfOpenFastFail          bool
+	halfOpenMaxFailures       uint32
+	// mu is held by every update of the counts below, so they can be read consistently.
+	mu sync.Mutex
+	// failureWeight is the accumulated weight of consecutive failures, in thousandths, when a
+	// FailureWeigher is configured.
+	failureWeight atomic.Uint64
+}
 
-const (
-	defaultFailureThreshold          uint32 = 5
-	defaultHalfOpenProbes            uint32 = 10
-	defaultHalfOpenMaxFailurePercent uint32 = 30
-	defaultRetryInterval                    = 5 * time.Second
-)
+/*
+CircuitBreaker is the untyped core of a breaker: the state machine, counters and notifications.
+Protect calls with Do, or wrap it with Typed to protect calls returning values; one core can back
+any number of typed wrappers, so heterogeneous calls to the same dependency share one state.
+*/
+type CircuitBreaker struct {
+	name    string
+	counter counter
+	state   State
+	timeout time.Duration
+	sli     *sliWindow
+	latency *latencyWindow
+	stats   *statsWindow
+	// deadline, when set, rejects calls whose deadline is shorter than calls typically take.
+	deadline *deadlineGuard
+	clock    Clock
+	// retry is the single timer moving the breaker from Open to Half-Open. It is re-armed on
+	// every trip and stopped whenever the breaker leaves Open by other means.
+	retry   Timer
+	retryAt time.Time
+	backoff *Backoff
+	jitter  JitterMode
+	maxOpen time.Duration
+	// capped is set when maxOpen cut the current open period short.
+	capped bool
+	// lastOpen is the previous open period, which JitterDecorrelated grows from.
+	lastOpen time.Duration
+	// shared, when set, shares trips with peers through a StateStore.
+	shared *storeSync
+	// health, when set, probes the dependency itself instead of waiting for live traffic.
+	health *healthProbe
+	// adaptive, when set, retunes the failure threshold and retry interval on every trip.
+	adaptive *adaptiveState
+	// brownOut, when set, throttles a Closed breaker whose failure rate is elevated.
+	brownOut *brownOutState
+	// warmUp, when set, ramps traffic back up after the breaker recovers.
+	warmUp *warmUp
+	// lastTransition is when the breaker last changed state.
+	lastTransition time.Time
+	// trips counts consecutive trips since the breaker was last closed.
+	trips uint32
+	// probeWindow identifies the current Half-Open window so late probe results from an
+	// earlier window are not counted against it.
+	probeWindow uint64
+
+	snooze                Timer
+	snoozedUntil          time.Time
+	snoozeSuppressesTrips bool
+	// contextInfo adds a CallInfo to the context of every call.
+	contextInfo bool
+	// coalesce collapses identical Half-Open calls made with ExecuteKeyed.
+	coalesce bool
+	// shedPriority and below are shed while degraded; zero disables shedding.
+	shedPriority       Priority
+	shedFailurePercent uint32
+	// parent and children link breakers created with Child. passTrip, guarded by mu, asks
+	// unlockAndNotify to pass a trip down once mu is released.
+	parent   *CircuitBreaker
+	children children
+	passTrip bool
 
-type BreakerConfig struct {
-	FailureThreshold          uint32
-	RetryInterval             time.Duration
-	HalfOpenMaxProbes         uint32
-	HalfOpenMaxFailurePercent uint32
-	Timeout                   time.Duration
+	subs   subscribers
+	outbox []Event
+	probes *probeSlots
+	conc   concurrency
+	// calls caps the calls in flight outside Half-Open; see MaxConcurrent.
+	calls *probeSlots
+	// totalCalls and totalFailures count every admitted call over the breaker's lifetime.
+	totalCalls    atomic.Uint64
+	totalFailures atomic.Uint64
+	// hooks, when set, are run around every admitted call.
+	hooks *callHooks
+	// saturationMark is the in-flight count at which EventSaturation fires; zero disables it.
+	saturationMark int64
+	classify       Classifier
+	countCanceled  bool
+	categorize     ErrorClassifier
+	weigh          FailureWeigher
+	categories     map[ErrorCategory]*categoryCounter
+	closed         atomic.Bool
+	rejections     rejectionCounts
+	// rejectSummary, when set, aggregates rejections for RejectionSummaryInterval.
+	rejectSummary *rejectionSummary
+	logOut        io.Writer
+	logger        atomic.Pointer[slog.Logger]
+	// labels is never modified after construction, so events can share it.
+	labels map[string]string
+	// forced holds the ForcedMode set by an operator.
+	forced atomic.Int32
+	// current mirrors state so calls can read it with a single atomic load; it is only
+	// written under mu, alongside state.
+	current atomic.Int32
+	// history and cause are guarded by mu; cause is what triggers the transition in progress.
+	history *history
+	cause   Cause
+	mu      sync.RWMutex
 }
 
-func applyDefaults(c *BreakerConfig) {
-	if c.FailureThreshold == 0 {
-		c.FailureThreshold = defaultFailureThreshold
+func (br *CircuitBreaker) core() *CircuitBreaker { return br }
+
+// trip opens the breaker and arms the retry timer, unless a concurrent failure already did.
+func (br *CircuitBreaker) trip(cause Cause) {
+	br.mu.Lock()
+	defer br.unlockAndNotify()
+	if br.state == StateOpen || br.holdsTripsLocked() {
+		return
 	}
-	if c.RetryInterval <= 0 {
-		c.RetryInterval = defaultRetryInterval
+	br.cause = cause
+	br.openLocked()
+}
+
+func (br *CircuitBreaker) openLocked() {
+	br.setStateLocked(StateOpen)
+	br.resetHalfOpenCounts()
+	if br.closed.Load() {
+		return
 	}
-	if c.HalfOpenMaxProbes == 0 {
-		c.HalfOpenMaxProbes = defaultHalfOpenProbes
+	br.trips++
+	br.passTrip = true
+	br.adaptLocked(br.clock.Now(), true)
+	br.armRetryLocked(br.openIntervalLocked())
+	br.shareLocked()
+}
+
+// adoptOpenLocked opens the breaker for d because a peer tripped, without sharing it back.
+func (br *CircuitBreaker) adoptOpenLocked(d time.Duration) {
+	br.setStateLocked(StateOpen)
+	br.resetHalfOpenCounts()
+	br.armRetryLocked(d)
+}
+
+// armRetryLocked schedules the next retry in d, but no later than MaxOpenDuration after the
+// breaker opened.
+func (br *CircuitBreaker) armRetryLocked(d time.Duration) {
+	now := br.clock.Now()
+	br.capped = false
+	if br.maxOpen > 0 {
+		if left := max(br.lastTransition.Add(br.maxOpen).Sub(now), 0); d >= left {
+			d, br.capped = left, true
+		}
 	}
-	if c.HalfOpenMaxFailurePercent == 0 || c.HalfOpenMaxFailurePercent > 100 {
-		c.HalfOpenMaxFailurePercent = defaultHalfOpenMaxFailurePercent
+	br.retryAt = now.Add(d)
+	if br.retry == nil {
+		br.retry = br.clock.AfterFunc(d, br.retryExpired)
+	} else {
+		br.retry.Reset(d)
 	}
 }
 
-type counter struct {
-	failureCount              uint32
-	failureThreshold          uint32
-	retryInterval             time.Duration
-	halfOpenMaxProbes         uint32
-	halfOpenFailureCount      uint32
-	halfOpenSuccessCount      uint32
-	halfOpenMaxFailurePercent uint32
+// openIntervalLocked returns how long the current trip keeps the breaker open.
+func (br *CircuitBreaker) openIntervalLocked() time.Duration {
+	switch br.jitter {
+	case JitterFull:
+		return time.Duration(rand.Int64N(int64(br.backoffIntervalLocked()) + 1))
+	case JitterDecorrelated:
+		base, ceiling := br.counter.retryInterval, max(defaultBackoffMax, br.counter.retryInterval)
+		if b := br.backoff; b != nil {
+			base, ceiling = b.Initial, b.Max
+		}
+		prev := max(br.lastOpen, base)
+		br.lastOpen = min(ceiling, base+time.Duration(rand.Int64N(int64(3*prev-base)+1)))
+		return br.lastOpen
+	}
+	return br.backoffIntervalLocked()
 }
 
-type breaker[T any] struct {
-	name    string
-	counter counter
-	state   int
-	timeout time.Duration
-	mu      sync.RWMutex
+func (br *CircuitBreaker) backoffIntervalLocked() time.Duration {
+	if br.backoff == nil {
+		return br.counter.retryInterval
+	}
+	return br.backoff.interval(br.trips)
+}
+
+func (br *CircuitBreaker) halfOpenLocked() {
+	br.stopRetryLocked()
+	br.probeWindow++
+	br.setStateLocked(StateHalfOpen)
+	br.resetHalfOpenCounts()
+}
+
+func (br *CircuitBreaker) closeLocked() {
+	br.stopRetryLocked()
+	from := br.state
+	if (from == StateOpen || from == StateHalfOpen) && br.cause != CauseManual {
+		br.warmUp.start(br.clock.Now())
+	} else {
+		br.warmUp.stop()
+	}
+	br.setStateLocked(StateClosed)
+	br.trips = 0
+	br.lastOpen = 0
+	br.latency.reset()
+	br.brownOut.reset()
+	br.resetFailures()
+	br.resetHalfOpenCounts()
+	if from != StateClosed {
+		br.shareLocked()
+	}
+}
+
+func (br *CircuitBreaker) setStateLocked(to State) {
+	from := br.state
+	br.state = to
+	br.current.Store(int32(to))
+	if from != to {
+		br.lastTransition = br.clock.Now()
+		br.recordTransitionLocked(from, to)
+		br.emitLocked(Event{Kind: EventStateChange, From: from, To: to, Cause: br.cause})
+	}
+	br.cause = ""
 }
 
-func (br *breaker[T]) startRetry() {
-	go func() {
-		time.Sleep(br.counter.retryInterval)
-		br.setState(stateHalfOpen)
-	}()
+func (br *CircuitBreaker) stopRetryLocked() {
+	if br.retry != nil {
+		br.retry.Stop()
+	}
 }
 
-func (br *breaker[T]) setState(state int) {
+// retryExpired runs on the retry timer. A stale firing, e.g. one that raced with a Reset and
+// a fresh trip, is ignored because the new retry deadline has not been reached yet.
+func (br *CircuitBreaker) retryExpired() {
 	br.mu.Lock()
-	br.state = state
-	br.mu.Unlock()
+	if br.state != StateOpen || br.clock.Now().Before(br.retryAt) {
+		br.unlockAndNotify()
+		return
+	}
+	if br.capped {
+		br.cause = CauseMaxOpenDuration
+		br.halfOpenLocked()
+		br.unlockAndNotify()
+		return
+	}
+	if br.health != nil {
+		br.unlockAndNotify()
+		br.runHealthProbe()
+		return
+	}
+	br.cause = CauseRetryInterval
+	br.halfOpenLocked()
+	br.unlockAndNotify()
+}
+
+// Name returns the breaker's name.
+func (br *CircuitBreaker) Name() string {
+	return br.name
+}
+
+// State returns the breaker's current state.
+func (br *CircuitBreaker) State() State {
+	return br.getState()
+}
+
+func (br *CircuitBreaker) getState() State {
+	return State(br.current.Load())
 }
 
-func (br *breaker[T]) getState() int {
+/*
+UntilHalfOpen returns how long an open breaker waits before it starts probing, so callers can
+set Retry-After headers or schedule retries. It is zero in other states, and once the wait is
+over but a health probe has yet to succeed.
+*/
+func (br *CircuitBreaker) UntilHalfOpen() time.Duration {
 	br.mu.RLock()
 	defer br.mu.RUnlock()
-	return br.state
+	return br.untilHalfOpenLocked(br.clock.Now())
 }
 
-func stateToString(state int) string {
-	switch state {
-	case stateClosed:
-		return "Closed"
-	case stateOpen:
-		return "Open"
-	case stateHalfOpen:
-		return "Half-Open"
-	default:
-		return fmt.Sprintf("Unknown(%d)", state)
+func (br *CircuitBreaker) untilHalfOpenLocked(now time.Time) time.Duration {
+	if br.state != StateOpen || br.closed.Load() {
+		return 0
+	}
+	return max(br.retryAt.Sub(now), 0)
+}
+
+/*
+SetLogOutput sets the writer LogState renders to. A nil w restores the default, os.Stdout.
+*/
+func (br *CircuitBreaker) SetLogOutput(w io.Writer) {
+	br.mu.Lock()
+	br.logOut = w
+	br.mu.Unlock()
+}
+
+// LogState renders the breaker state table to the writer set with SetLogOutput, or stdout.
+func (br *CircuitBreaker) LogState() {
+	br.mu.RLock()
+	w := br.logOut
+	br.mu.RUnlock()
+	if w == nil {
+		w = os.Stdout
 	}
+	br.LogStateTo(w)
+}
+
+/*
+LogStateString prints the breaker state table like LogState.
+
+Deprecated: use LogState or LogStateTo.
+*/
+func (br *CircuitBreaker) LogStateString() {
+	br.LogState()
 }
 
-func (br *breaker[T]) LogStateString() {
-	st := br.getState()
-	failures := atomic.LoadUint32(&br.counter.failureCount)
-	hFail := atomic.LoadUint32(&br.counter.halfOpenFailureCount)
-	hSucc := atomic.LoadUint32(&br.counter.halfOpenSuccessCount)
+// LogStateTo renders the breaker state as a table to w.
+func (br *CircuitBreaker) LogStateTo(w io.Writer) {
+	snap := br.Snapshot()
 
 	tw := table.NewWriter()
 	tw.SetStyle(table.StyleRounded)
-	tw.AppendHeader(table.Row{"Circuit Breaker", br.name})
-	tw.AppendRow(table.Row{"State", stateToString(st)})
-	if br.timeout > 0 {
-		tw.AppendRow(table.Row{"Timeout", br.timeout})
-	}
-	tw.AppendRow(table.Row{"Failure (current/threshold)", fmt.Sprintf("%d / %d", failures, br.counter.failureThreshold)})
-	tw.AppendRow(table.Row{"Retry Interval", br.counter.retryInterval})
-	tw.AppendRow(table.Row{"Half-Open max probes", br.counter.halfOpenMaxProbes})
-	tw.AppendRow(table.Row{"Half-Open success count", hSucc})
-	tw.AppendRow(table.Row{"Half-Open failure count", hFail})
-	tw.AppendRow(table.Row{"Half-Open failure %", fmt.Sprintf("%d%%", hFail*100/br.counter.halfOpenMaxProbes)})
-	tw.AppendRow(table.Row{"Half-Open max failure %", fmt.Sprintf("%d%%", br.counter.halfOpenMaxFailurePercent)})
-
-	fmt.Println(tw.Render())
-}
-
-/*
-Execute wraps the provided function call with circuit breaker logic.
-It returns an error if the breaker is open, tracks failures and successes in half-open state,
-and resets failure count on successful calls in closed state.
-*/
-func (br *breaker[T]) Execute(fn func() (T, error)) (T, error) {
-	var zero T
-	switch br.getState() {
-	case stateOpen:
-		return zero, fmt.Errorf("circuit breaker is open")
-	case stateHalfOpen:
-		res, err := fn()
-		if err != nil {
-			br.recordHalfOpenResult(false)
-			return res, err
-		}
-		br.recordHalfOpenResult(true)
+	tw.AppendHeader(table.Row{"Circuit Breaker", snap.Name})
+	tw.AppendRow(table.Row{"State", snap.State.String()})
+	if snap.Forced != NotForced {
+		tw.AppendRow(table.Row{"Forced", snap.Forced.String()})
+	}
+	if snap.Parent != "" {
+		tw.AppendRow(table.Row{"Parent", snap.Parent})
+	}
+	if snap.State == StateOpen {
+		tw.AppendRow(table.Row{"Half-Open in", snap.UntilHalfOpen.Round(time.Millisecond)})
+	}
+	if snap.Timeout > 0 {
+		tw.AppendRow(table.Row{"Timeout", snap.Timeout})
+	}
+	tw.AppendRow(table.Row{"Failure (current/threshold)", fmt.Sprintf("%d / %d", snap.FailureCount, snap.FailureThreshold)})
+	if br.weigh != nil {
+		tw.AppendRow(table.Row{"Failure weight (current/threshold)", fmt.Sprintf("%.2f / %d", snap.FailureWeight, snap.FailureThreshold)})
+	}
+	for _, cat := range slices.Sorted(maps.Keys(snap.FailuresByCategory)) {
+		tw.AppendRow(table.Row{fmt.Sprintf("Failure (%s)", cat), snap.FailuresByCategory[cat]})
+	}
+	tw.AppendRow(table.Row{"Retry Interval", snap.RetryInterval})
+	tw.AppendRow(table.Row{"In-flight (now/max/avg)", fmt.Sprintf("%d / %d / %.1f", snap.InFlight, snap.MaxInFlight, snap.AvgInFlight)})
+	if snap.HalfOpenMode == HalfOpenConsecutive {
+		tw.AppendRow(table.Row{"Half-Open successes (current/threshold)", fmt.Sprintf("%d / %d", snap.HalfOpenSuccessCount, snap.SuccessThreshold)})
+	} else {
+		tw.AppendRow(table.Row{"Half-Open max probes", snap.HalfOpenMaxProbes})
+		tw.AppendRow(table.Row{"Half-Open success count", snap.HalfOpenSuccessCount})
+		tw.AppendRow(table.Row{"Half-Open failure count", snap.HalfOpenFailureCount})
+		tw.AppendRow(table.Row{"Half-Open failure %", fmt.Sprintf("%d%%", snap.HalfOpenFailureCount*100/snap.HalfOpenMaxProbes)})
+		tw.AppendRow(table.Row{"Half-Open max failure %", fmt.Sprintf("%d%%", snap.HalfOpenMaxFailurePercent)})
+	}
+	for _, reason := range sortedReasons(snap.Rejections) {
+		tw.AppendRow(table.Row{fmt.Sprintf("Rejected (%s)", reason), snap.Rejections[reason]})
+	}
+	for _, sli := range snap.SLI {
+		tw.AppendRow(table.Row{fmt.Sprintf("Availability (%s)", sli.Window), fmt.Sprintf("%.2f%% of %d", sli.Ratio*100, sli.Total)})
+	}
+	if l := snap.Latency; l != nil {
+		tw.AppendRow(table.Row{fmt.Sprintf("Slow calls (%s)", l.Window), fmt.Sprintf("%.2f%% of %d", l.SlowCallPercent, l.Calls)})
+		tw.AppendRow(table.Row{"Latency p99", l.P99})
+	}
+
+	fmt.Fprintln(w, tw.Render())
+}
+
+/*
+Do calls fn with circuit breaker logic. It returns a *RejectionError without calling fn if the
+breaker is open, tracks failures and successes in half-open state, and resets the failure count
+on successful calls in closed state.
+*/
+func (br *CircuitBreaker) Do(fn func() error) error {
+	_, err := execute(br, context.Background(), 0, func(context.Context) (struct{}, error) {
+		return struct{}{}, fn()
+	}, nil)
+	return err
+}
+
+/*
+DoContext is like Do but passes ctx to fn. ctx also bounds how long the call may
+wait for a Half-Open probe slot when HalfOpenFairness is enabled.
+*/
+func (br *CircuitBreaker) DoContext(ctx context.Context, fn func(ctx context.Context) error) error {
+	return br.DoTimeout(ctx, 0, fn)
+}
+
+/*
+DoTimeout is like DoContext but bounds the call by d instead of the breaker's Timeout, for
+endpoints with a latency budget of their own. d <= 0 falls back to Timeout.
+*/
+func (br *CircuitBreaker) DoTimeout(ctx context.Context, d time.Duration, fn func(ctx context.Context) error) error {
+	_, err := execute(br, ctx, d, func(ctx context.Context) (struct{}, error) {
+		return struct{}{}, fn(ctx)
+	}, nil)
+	return err
+}
+
+/*
+execute runs fn through br. It is generic so typed wrappers avoid boxing results.
+A positive timeout bounds fn's context in place of the breaker's Timeout, and a non-nil
+successful decides the outcome of fn in place of the breaker's Classifier.
+*/
+func execute[T any](br *CircuitBreaker, ctx context.Context, timeout time.Duration, fn func(ctx context.Context) (T, error), successful func(T, error) bool) (T, error) {
+	c, err := br.begin(ctx)
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+	defer c.release()
+	if br.contextInfo {
+		ctx = ContextWithCallInfo(ctx, CallInfo{Breaker: br.name, State: c.adm.state, Forced: br.Forced()})
+	}
+	if timeout <= 0 {
+		timeout = br.timeout
+	}
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	res, err := fn(ctx)
+	if br.neutral(err) {
+		c.ignore(err)
 		return res, err
-	case stateClosed:
-		res, err := fn()
-		if err != nil {
-			br.failure()
-			return res, err
+	}
+	var failed bool
+	if successful != nil {
+		failed = !successful(res, err)
+	} else {
+		failed = br.isFailure(err)
+	}
+	c.report(failed, err)
+	return res, err
+}
+
+/*
+Allow is the two-step form of Do for code that cannot be wrapped in a closure, such as
+callback-style or streaming paths. If the breaker admits the call, the caller must invoke
+done exactly once with its outcome; later invocations are ignored. Otherwise Allow returns
+the same *RejectionError Do would have.
+*/
+func (br *CircuitBreaker) Allow() (done func(success bool), err error) {
+	return br.AllowContext(context.Background())
+}
+
+// AllowContext is like Allow but ctx bounds how long it may wait for a Half-Open probe slot.
+func (br *CircuitBreaker) AllowContext(ctx context.Context) (done func(success bool), err error) {
+	c, err := br.begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var once sync.Once
+	return func(success bool) {
+		once.Do(func() {
+			c.report(!success, nil)
+			c.release()
+		})
+	}, nil
+}
+
+// call is an admitted call that has not reported its outcome yet.
+type call struct {
+	br    *CircuitBreaker
+	adm   admission
+	start time.Time
+	// ctx and details are only set for breakers with hooks.
+	ctx     context.Context
+	details CallDetails
+}
+
+// begin admits a call, taking a probe slot if the breaker is Half-Open.
+func (br *CircuitBreaker) begin(ctx context.Context) (call, error) {
+	adm, err := br.admit()
+	if err != nil {
+		return call{}, err
+	}
+	if adm.bypassed {
+		return call{br: br, adm: adm}, nil
+	}
+	// Half-Open probes skip the deadline check: the window may still hold the slow calls of the
+	// outage, and rejecting probes on them would keep a recovered dependency from closing.
+	if br.deadline != nil && adm.state != StateHalfOpen && br.deadlineTooShort(ctx) {
+		return call{}, br.reject(ReasonDeadlineTooShort, ErrDeadlineTooShort)
+	}
+	if br.sheds(ctx, adm) {
+		return call{}, br.reject(ReasonShed, ErrShed)
+	}
+	if br.warmingUp(ctx, adm) {
+		return call{}, br.reject(ReasonWarmingUp, ErrShed)
+	}
+	if adm.state == StateHalfOpen {
+		if err := br.acquireProbe(ctx); err != nil {
+			return call{}, err
+		}
+	} else if err := br.calls.acquire(ctx); err != nil {
+		if err == ErrBulkheadFull {
+			return call{}, br.reject(ReasonBulkheadFull, err)
+		}
+		return call{}, err
+	}
+	br.callStarted()
+	c := call{br: br, adm: adm}
+	if br.latency != nil || br.stats != nil || br.parent != nil || br.hooks != nil || br.hasSubscribers() {
+		c.start = br.clock.Now()
+	}
+	if br.hooks != nil {
+		c.hook(ctx)
+	}
+	return c, nil
+}
+
+// report records the outcome of c.
+func (c call) report(failed bool, err error) {
+	if c.adm.bypassed {
+		return
+	}
+	br := c.br
+	br.record(c.adm, !failed, err)
+	if c.start.IsZero() {
+		return
+	}
+	now := br.clock.Now()
+	elapsed := now.Sub(c.start)
+	for p := br.parent; p != nil; p = p.parent {
+		p.rollUp(now, failed, err, elapsed)
+	}
+	br.stats.record(now, failed, elapsed)
+	if br.hasSubscribers() {
+		br.publishCall(c.adm.state, failed, err, elapsed)
+	}
+	if br.hooks != nil {
+		outcome := OutcomeSuccess
+		if failed {
+			outcome = OutcomeFailure
 		}
-		atomic.StoreUint32(&br.counter.failureCount, 0)
-		return res, nil
+		c.afterHook(now, outcome, err)
+	}
+	closed := c.adm.state == StateClosed || c.adm.state == StateThrottled
+	if br.latency != nil && br.latency.record(now, elapsed) && closed && !c.adm.forced {
+		br.trip(CauseSlowCalls)
 	}
-	return zero, nil
 }
 
-func (br *breaker[T]) recordHalfOpenResult(success bool) {
-	if br.getState() != stateHalfOpen {
+// release gives back what begin took, even if the call panicked before reporting.
+func (c call) release() {
+	if c.adm.bypassed {
 		return
 	}
-	if success {
-		atomic.AddUint32(&br.counter.halfOpenSuccessCount, 1)
+	c.br.callFinished()
+	if c.adm.state == StateHalfOpen {
+		c.br.probes.release()
 	} else {
-		atomic.AddUint32(&br.counter.halfOpenFailureCount, 1)
+		c.br.calls.release()
 	}
+}
 
-	fail := atomic.LoadUint32(&br.counter.halfOpenFailureCount)
-	succ := atomic.LoadUint32(&br.counter.halfOpenSuccessCount)
+func (br *CircuitBreaker) publishCall(st State, failed bool, err error, elapsed time.Duration) {
+	kind := EventCallSuccess
+	if failed {
+		kind = EventCallFailure
+	}
+	br.publish(Event{Kind: kind, From: st, To: st, Err: err, Elapsed: elapsed})
+	if st == StateHalfOpen {
+		br.publish(Event{Kind: EventProbeResult, From: st, To: st, Err: err, Elapsed: elapsed})
+	}
+}
 
-	if fail+succ == br.counter.halfOpenMaxProbes {
-		failurePercent := uint32(float64(fail) / float64(br.counter.halfOpenMaxProbes) * 100)
-		if failurePercent >= br.counter.halfOpenMaxFailurePercent {
-			br.setState(stateOpen)
-			br.startRetry()
-		} else {
-			atomic.StoreUint32(&br.counter.failureCount, 0)
-			br.setState(stateClosed)
+// acquireProbe takes a Half-Open probe slot, re-checking the state in case the probe window
+// was decided while the caller was queued.
+func (br *CircuitBreaker) acquireProbe(ctx context.Context) error {
+	if err := br.probes.acquire(ctx); err != nil {
+		if err == ErrProbeQuotaExceeded {
+			return br.reject(ReasonProbeQuotaExceeded, err)
 		}
-		atomic.StoreUint32(&br.counter.halfOpenFailureCount, 0)
-		atomic.StoreUint32(&br.counter.halfOpenSuccessCount, 0)
+		return err
+	}
+	if br.getState() == StateOpen {
+		br.probes.release()
+		return br.reject(ReasonOpen, ErrOpen)
+	}
+	return nil
+}
+
+/*
+admission is the state a call was admitted in, and for Half-Open the probe window. Calls
+admitted under ForceClosed are treated as Closed but with forced set, so they never move the
+state machine; calls admitted while Disabled are bypassed entirely.
+*/
+type admission struct {
+	state    State
+	window   uint64
+	forced   bool
+	bypassed bool
+}
+
+// admit decides whether a call may proceed.
+func (br *CircuitBreaker) admit() (admission, error) {
+	if br.closed.Load() {
+		return admission{}, br.reject(ReasonClosed, ErrClosed)
+	}
+	switch br.Forced() {
+	case ForcedOpen:
+		return admission{}, br.reject(ReasonForcedOpen, ErrOpen)
+	case ForcedClosed:
+		return admission{state: StateClosed, forced: true}, nil
+	case Disabled:
+		return admission{bypassed: true}, nil
+	}
+	if br.ancestorBlocks() {
+		return admission{}, br.reject(ReasonOpen, ErrOpen)
+	}
+	adm := br.currentAdmission()
+	switch {
+	case adm.state == StateOpen:
+		return adm, br.reject(ReasonOpen, ErrOpen)
+	case adm.state == StateThrottled && br.brownOut.throttles():
+		return adm, br.reject(ReasonThrottled, ErrShed)
+	}
+	return adm, nil
+}
+
+func (br *CircuitBreaker) currentAdmission() admission {
+	// Closed calls do not need a probe window, so the common case takes no lock.
+	if br.getState() == StateClosed {
+		return admission{state: StateClosed}
 	}
+	br.mu.RLock()
+	defer br.mu.RUnlock()
+	return admission{state: br.state, window: br.probeWindow}
 }
 
-func (br *breaker[T]) failure() {
-	atomic.AddUint32(&br.counter.failureCount, 1)
-	if atomic.LoadUint32(&br.counter.failureCount) >= br.counter.failureThreshold {
-		br.setState(stateOpen)
-		br.startRetry()
+// record accounts for the outcome of an admitted call.
+func (br *CircuitBreaker) record(adm admission, success bool, err error) {
+	br.totalCalls.Add(1)
+	if !success {
+		br.totalFailures.Add(1)
+	}
+	br.sli.record(br.clock.Now(), success)
+	if adm.forced {
+		return
+	}
+	switch adm.state {
+	case StateHalfOpen:
+		br.recordHalfOpenResult(adm.window, success)
+	case StateClosed, StateThrottled:
+		if br.brownOut != nil {
+			br.observeBrownOut(adm.state, !success)
+		}
+		if !success {
+			br.failure(err)
+			return
+		}
+		br.resetFailures()
 	}
 }
 
 /*
-InitBreaker initializes a new circuit breaker with configurable values via options.
-Defaults are applied if not provided.
-Backward compatible: callers can pass only the name without options.
+SLI returns the availability of the guarded dependency over each configured SLIWindows entry,
+in configuration order. It returns nil when no windows are configured.
 */
-func InitBreaker[T any](name string, cfg *BreakerConfig) *breaker[T] {
+func (br *CircuitBreaker) SLI() []SLI {
+	return br.sli.read(br.clock.Now())
+}
+
+/*
+ProbeNow immediately moves an open breaker to half-open so the next calls probe the dependency,
+instead of waiting for the retry interval to elapse. The usual half-open rules then decide
+whether the breaker closes or re-opens. It reports whether the breaker was open.
+*/
+func (br *CircuitBreaker) ProbeNow() bool {
+	br.mu.Lock()
+	defer br.unlockAndNotify()
+	if br.state != StateOpen {
+		return false
+	}
+	br.cause = CauseManual
+	br.halfOpenLocked()
+	return true
+}
+
+/*
+Trip forces the breaker open as if the failure threshold had been reached. It recovers through
+Half-Open as usual once the retry interval has elapsed.
+*/
+func (br *CircuitBreaker) Trip() {
+	br.mu.Lock()
+	defer br.unlockAndNotify()
+	if br.state != StateOpen {
+		br.cause = CauseManual
+		br.openLocked()
+	}
+}
+
+/*
+Reset forces the breaker back to Closed, clearing all counters and cancelling any pending
+Open → Half-Open transition.
+*/
+func (br *CircuitBreaker) Reset() {
+	br.mu.Lock()
+	defer br.unlockAndNotify()
+	br.cause = CauseManual
+	br.closeLocked()
+}
+
+/*
+Close shuts the breaker down: pending timers are stopped, queued events are delivered, and every
+later Execute call fails with ErrClosed without calling its function, and channels returned by
+Events are closed. Calls already in flight finish normally. Close is idempotent and always returns nil.
+*/
+func (br *CircuitBreaker) Close() error {
+	br.mu.Lock()
+	if br.closed.Swap(true) {
+		br.unlockAndNotify()
+		return nil
+	}
+	br.stopRetryLocked()
+	if br.snooze != nil {
+		br.snooze.Stop()
+	}
+	if br.shared != nil {
+		br.shared.poll.Stop()
+	}
+	if br.parent != nil {
+		br.parent.children.remove(br)
+	}
+	br.unlockAndNotify()
+	br.flushRejections()
+	br.closeEvents()
+	return nil
+}
+
+/*
+recordHalfOpenResult accounts for a probe admitted in the given Half-Open window. Counting and
+deciding happen under br.mu, so however many probes race each other the window is decided
+exactly once, and results that arrive after their window was decided are dropped.
+*/
+func (br *CircuitBreaker) recordHalfOpenResult(window uint64, success bool) {
+	br.mu.Lock()
+	if br.state != StateHalfOpen || br.probeWindow != window {
+		br.mu.Unlock()
+		return
+	}
+	succ, fail := br.countProbe(success)
+	br.decideLocked(success, succ, fail)
+	br.unlockAndNotify()
+	br.logProbe(success, succ, fail)
+}
+
+func (br *CircuitBreaker) decideLocked(success bool, succ, fail uint32) {
+	switch {
+	case br.counter.halfOpenMode == HalfOpenConsecutive:
+		// Close after SuccessThreshold consecutive successful probes, reopen on the first failure.
+		if success && succ < br.counter.successThreshold {
+			return
+		}
+		if success {
+			br.cause = CauseProbesSucceeded
+			br.closeLocked()
+			return
+		}
+	case !success && br.failsEarly(fail):
+	case fail+succ >= br.counter.halfOpenMaxProbes:
+		if uint64(fail)*100 < uint64(br.counter.halfOpenMaxFailurePercent)*uint64(br.counter.halfOpenMaxProbes) {
+			br.cause = CauseProbesSucceeded
+			br.closeLocked()
+			return
+		}
+	default:
+		return
+	}
+	br.cause = CauseProbesFailed
+	if br.holdsTripsLocked() {
+		// Snoozed breakers do not reopen; start another probe window instead.
+		br.halfOpenLocked()
+		return
+	}
+	br.openLocked()
+}
+
+/*
+failsEarly reports whether fail failed probes already decide the window: with HalfOpenFastFail
+once the failure percentage is reached no matter how the remaining probes go, or once
+HalfOpenMaxFailures probes have failed.
+*/
+func (br *CircuitBreaker) failsEarly(fail uint32) bool {
+	if k := br.counter.halfOpenMaxFailures; k > 0 && fail >= k {
+		return true
+	}
+	return br.counter.halfOpenFastFail &&
+		uint64(fail)*100 >= uint64(br.counter.halfOpenMaxFailurePercent)*uint64(br.counter.halfOpenMaxProbes)
+}
+
+func (br *CircuitBreaker) failure(err error) {
+	cat := br.categoryFor(err)
+	var w uint64
+	if cat == nil {
+		br.relaxAdaptive()
+		if br.weigh != nil {
+			w = weightUnits(br.weigh(err))
+		}
+	}
+	if cause := br.countFailure(cat, w); cause != "" {
+		br.trip(cause)
+	}
+}
+
+/*
+NewCircuitBreaker creates an untyped circuit breaker named name from cfg.
+A nil cfg uses the defaults for every field, as do zero-valued fields of a non-nil cfg.
+It returns an error wrapping ErrInvalidConfig if cfg contains values that cannot be satisfied.
+*/
+func NewCircuitBreaker(name string, cfg *BreakerConfig) (*CircuitBreaker, error) {
+	var c BreakerConfig
+	if cfg != nil {
+		c = *cfg
+	}
+	return newCircuitBreaker(name, c, true)
+}
+
+/*
+newCircuitBreaker fills cfg from the fleet defaults and builds the breaker. With validated set
+it first validates the merged config; otherwise out-of-range values are replaced by defaults.
+*/
+func newCircuitBreaker(name string, cfg BreakerConfig, validated bool) (*CircuitBreaker, error) {
 	if name == "" {
 		name = "breaker"
 	}
-	applyDefaults(cfg)
+	mergeFleetDefaults(&cfg)
+	if validated {
+		if err := validate(&cfg); err != nil {
+			return nil, err
+		}
+	}
+	applyDefaults(&cfg)
 
-	return &breaker[T]{
+	br := &CircuitBreaker{
 		name: name,
 		counter: counter{
+			counts:                    cfg.MetricsStore.Counters(name),
 			failureThreshold:          cfg.FailureThreshold,
 			retryInterval:             cfg.RetryInterval,
 			halfOpenMaxProbes:         cfg.HalfOpenMaxProbes,
 			halfOpenMaxFailurePercent: cfg.HalfOpenMaxFailurePercent,
+			halfOpenMode:              cfg.HalfOpenMode,
+			successThreshold:          cfg.SuccessThreshold,
+			halfOpenFastFail:          cfg.HalfOpenFastFail,
+			halfOpenMaxFailures:       cfg.HalfOpenMaxFailures,
 		},
-		timeout: cfg.Timeout,
-		state:   stateClosed,
+		timeout:               cfg.Timeout,
+		contextInfo:           cfg.ContextInfo,
+		coalesce:              cfg.HalfOpenCoalesce,
+		shedPriority:          cfg.ShedPriority,
+		shedFailurePercent:    cfg.ShedFailurePercent,
+		backoff:               cfg.RetryBackoff,
+		jitter:                cfg.RetryJitter,
+		maxOpen:               cfg.MaxOpenDuration,
+		health:                newHealthProbe(&cfg),
+		adaptive:              newAdaptiveState(cfg.Adaptive, cfg.RetryInterval, cfg.Clock.Now()),
+		brownOut:              newBrownOutState(cfg.BrownOut),
+		warmUp:                newWarmUp(&cfg),
+		snoozeSuppressesTrips: cfg.SnoozeSuppressesTrips,
+		sli:                   newSLIWindow(cfg.SLIWindows),
+		latency:               newLatencyWindow(&cfg),
+		stats:                 newStatsWindow(cfg.StatsWindow),
+		deadline:              newDeadlineGuard(cfg.DeadlinePercentile, cfg.StatsWindow),
+		shared:                newStoreSync(cfg.StateStore, cfg.StateSyncInterval),
+		hooks:                 newCallHooks(&cfg),
+		classify:              cfg.IsFailure,
+		countCanceled:         cfg.CountCanceled,
+		categorize:            cfg.ErrorClassifier,
+		weigh:                 cfg.FailureWeight,
+		categories:            newCategoryCounters(cfg.CategoryThresholds),
+		saturationMark:        saturationMark(cfg.SaturationLimit, cfg.SaturationPercent),
+		probes:                newProbeSlots(cfg.HalfOpenMaxConcurrent, cfg.HalfOpenFairness, cfg.HalfOpenQueueSize),
+		calls:                 newCallSlots(cfg.MaxConcurrent, cfg.MaxConcurrentQueue),
+		clock:                 cfg.Clock,
+		state:                 StateClosed,
+		history:               newHistory(cfg.HistorySize),
+		rejectSummary:         newRejectionSummary(cfg.RejectionSummaryInterval),
+		labels:                maps.Clone(cfg.Labels),
+	}
+	br.subs.buffer, br.subs.policy, br.subs.timeout = cfg.EventsBuffer, cfg.EventsPolicy, cfg.EventsBlockTimeout
+	br.logger.Store(cfg.Logger)
+	if rec := cfg.FlightRecorder; rec != nil {
+		br.subscribe(func(ev Event) { _ = rec.Record(ev) }, true)
+	}
+	if cfg.Metrics != nil {
+		m := newMetricsReporter(name, br.labels, cfg.Metrics)
+		m.sink.Gauge("sparkgap.state", float64(StateClosed), m.base)
+		m.withSLI(br.sli)
+		br.subscribe(m.report, true)
+	}
+	if br.shared != nil {
+		br.loadStore(false)
+		br.shared.poll = br.clock.AfterFunc(br.shared.every, br.pollStore)
 	}
+	return br, nil
 }
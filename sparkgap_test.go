@@ -0,0 +1,80 @@
+package sparkgap_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/afk-ankit/sparkgap"
+	"github.com/afk-ankit/sparkgap/sparkgaptest"
+)
+
+var errTest = errors.New("test failure")
+
+func newTestBreaker(t *testing.T, cfg sparkgap.BreakerConfig) (*sparkgap.CircuitBreaker, *sparkgaptest.FakeClock) {
+	t.Helper()
+	clock := sparkgaptest.NewFakeClock(time.Now())
+	cfg.Clock = clock
+	br, err := sparkgap.NewCircuitBreaker(t.Name(), &cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { br.Close() })
+	return br, clock
+}
+
+func fail() error    { return errTest }
+func succeed() error { return nil }
+
+func TestStateMachine(t *testing.T) {
+	cases := []struct {
+		name   string
+		cfg    sparkgap.BreakerConfig
+		probes int
+	}{
+		{"percentage", sparkgap.BreakerConfig{FailureThreshold: 3, RetryInterval: time.Second, HalfOpenMaxProbes: 4}, 4},
+		{"consecutive", sparkgap.BreakerConfig{FailureThreshold: 3, RetryInterval: time.Second, HalfOpenMode: sparkgap.HalfOpenConsecutive, SuccessThreshold: 2}, 2},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			br, clock := newTestBreaker(t, tc.cfg)
+			for range 3 {
+				if err := br.Do(fail); !errors.Is(err, errTest) {
+					t.Fatalf("Do = %v, want the call's error", err)
+				}
+			}
+			sparkgaptest.RequireState(t, br, sparkgap.StateOpen)
+			if err := br.Do(succeed); !errors.Is(err, sparkgap.ErrOpen) {
+				t.Fatalf("Do while open = %v, want ErrOpen", err)
+			}
+
+			clock.Advance(time.Second - time.Millisecond)
+			sparkgaptest.RequireState(t, br, sparkgap.StateOpen)
+			clock.Advance(time.Millisecond)
+			sparkgaptest.RequireState(t, br, sparkgap.StateHalfOpen)
+
+			for i := range tc.probes {
+				if err := br.Do(succeed); err != nil {
+					t.Fatalf("probe %d: %v", i, err)
+				}
+			}
+			sparkgaptest.RequireState(t, br, sparkgap.StateClosed)
+		})
+	}
+}
+
+func TestFailedProbeReopens(t *testing.T) {
+	br, clock := newTestBreaker(t, sparkgap.BreakerConfig{
+		FailureThreshold: 1,
+		RetryInterval:    time.Second,
+		HalfOpenMode:     sparkgap.HalfOpenConsecutive,
+	})
+	_ = br.Do(fail)
+	clock.Advance(time.Second)
+	sparkgaptest.RequireState(t, br, sparkgap.StateHalfOpen)
+	_ = br.Do(fail)
+	sparkgaptest.RequireState(t, br, sparkgap.StateOpen)
+	if got := br.UntilHalfOpen(); got != time.Second {
+		t.Fatalf("UntilHalfOpen = %s, want 1s", got)
+	}
+}
@@ -0,0 +1,53 @@
+package sparkgaptest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/afk-ankit/sparkgap"
+)
+
+// Breaker is what the helpers need from a breaker; *sparkgap.CircuitBreaker and every
+// *sparkgap.Breaker[T] satisfy it.
+type Breaker interface {
+	Name() string
+	State() sparkgap.State
+	Subscribe(fn func(sparkgap.Event)) (unsubscribe func())
+}
+
+/*
+WaitForState waits up to timeout of wall-clock time for br to reach want and fails the test if
+it does not. It returns as soon as the transition is published, so tests driven by a real clock
+need no sleeps; with a FakeClock transitions are synchronous and a short timeout suffices.
+*/
+func WaitForState(t testing.TB, br Breaker, want sparkgap.State, timeout time.Duration) {
+	t.Helper()
+	changed := make(chan struct{}, 1)
+	unsubscribe := br.Subscribe(func(ev sparkgap.Event) {
+		if ev.Kind == sparkgap.EventStateChange {
+			select {
+			case changed <- struct{}{}:
+			default:
+			}
+		}
+	})
+	defer unsubscribe()
+
+	deadline := time.NewTimer(timeout)
+	defer deadline.Stop()
+	for br.State() != want {
+		select {
+		case <-changed:
+		case <-deadline.C:
+			t.Fatalf("breaker %q is %s after %s, want %s", br.Name(), br.State(), timeout, want)
+		}
+	}
+}
+
+// RequireState fails the test unless br is in want right now.
+func RequireState(t testing.TB, br Breaker, want sparkgap.State) {
+	t.Helper()
+	if got := br.State(); got != want {
+		t.Fatalf("breaker %q is %s, want %s", br.Name(), got, want)
+	}
+}
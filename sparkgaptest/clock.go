@@ -0,0 +1,148 @@
+/*
+Package sparkgaptest provides helpers for testing code that uses sparkgap circuit breakers: a
+FakeClock to step through transitions, Scripts of call outcomes, a RecordingListener for
+events, and WaitForState and RequireState assertions.
+*/
+package sparkgaptest
+
+import (
+	"sync"
+	"time"
+
+	"github.com/afk-ankit/sparkgap"
+)
+
+/*
+FakeClock is a sparkgap.Clock whose time only moves when Advance or Set is called.
+Timers that become due are fired synchronously from within Advance/Set, so by the time
+they return every breaker transition scheduled for that instant has happened.
+*/
+type FakeClock struct {
+	mu      sync.Mutex
+	now     time.Time
+	waiters []*fakeTimer
+}
+
+// NewFakeClock returns a FakeClock set to start.
+func NewFakeClock(start time.Time) *FakeClock {
+	return &FakeClock{now: start}
+}
+
+type fakeTimer struct {
+	clock  *FakeClock
+	at     time.Time
+	fn     func()
+	ch     chan time.Time
+	active bool
+}
+
+// Now returns the fake current time.
+func (c *FakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// After returns a channel that receives the fake time once it has advanced by d.
+func (c *FakeClock) After(d time.Duration) <-chan time.Time {
+	ch := make(chan time.Time, 1)
+	c.schedule(d, nil, ch)
+	return ch
+}
+
+// AfterFunc calls f once the fake time has advanced by d.
+func (c *FakeClock) AfterFunc(d time.Duration, f func()) sparkgap.Timer {
+	return c.schedule(d, f, nil)
+}
+
+func (c *FakeClock) schedule(d time.Duration, fn func(), ch chan time.Time) *fakeTimer {
+	c.mu.Lock()
+	t := &fakeTimer{clock: c, at: c.now.Add(d), fn: fn, ch: ch, active: true}
+	c.waiters = append(c.waiters, t)
+	c.mu.Unlock()
+	if d <= 0 {
+		c.Advance(0)
+	}
+	return t
+}
+
+// Advance moves the fake time forward by d and fires every timer that has become due.
+func (c *FakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	target := c.now.Add(d)
+	c.mu.Unlock()
+	c.Set(target)
+}
+
+// Set moves the fake time to t and fires every timer that has become due, in deadline order.
+func (c *FakeClock) Set(t time.Time) {
+	for {
+		c.mu.Lock()
+		var next *fakeTimer
+		idx := -1
+		for i, w := range c.waiters {
+			if !w.at.After(t) && (next == nil || w.at.Before(next.at)) {
+				next, idx = w, i
+			}
+		}
+		if next == nil {
+			if t.After(c.now) {
+				c.now = t
+			}
+			c.mu.Unlock()
+			return
+		}
+		c.waiters = append(c.waiters[:idx], c.waiters[idx+1:]...)
+		next.active = false
+		if next.at.After(c.now) {
+			c.now = next.at
+		}
+		now := c.now
+		c.mu.Unlock()
+
+		if next.ch != nil {
+			next.ch <- now
+		}
+		if next.fn != nil {
+			next.fn()
+		}
+	}
+}
+
+// Pending reports how many timers are waiting to fire.
+func (c *FakeClock) Pending() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.waiters)
+}
+
+func (t *fakeTimer) Stop() bool {
+	c := t.clock
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !t.active {
+		return false
+	}
+	t.active = false
+	for i, w := range c.waiters {
+		if w == t {
+			c.waiters = append(c.waiters[:i], c.waiters[i+1:]...)
+			break
+		}
+	}
+	return true
+}
+
+func (t *fakeTimer) Reset(d time.Duration) bool {
+	wasActive := t.Stop()
+	c := t.clock
+	c.mu.Lock()
+	t.at = c.now.Add(d)
+	t.active = true
+	c.waiters = append(c.waiters, t)
+	c.mu.Unlock()
+	if d <= 0 {
+		c.Advance(0)
+	}
+	return wasActive
+}
@@ -0,0 +1,89 @@
+package sparkgaptest_test
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/afk-ankit/sparkgap/sparkgaptest"
+)
+
+var epoch = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func TestFakeClockFiresInOrder(t *testing.T) {
+	c := sparkgaptest.NewFakeClock(epoch)
+	var fired []string
+	var at []time.Time
+	for _, tm := range []struct {
+		name string
+		d    time.Duration
+	}{{"c", 3 * time.Second}, {"a", time.Second}, {"b", 2 * time.Second}} {
+		c.AfterFunc(tm.d, func() {
+			fired = append(fired, tm.name)
+			at = append(at, c.Now())
+		})
+	}
+	c.Advance(1500 * time.Millisecond)
+	if !slices.Equal(fired, []string{"a"}) || !c.Now().Equal(epoch.Add(1500*time.Millisecond)) {
+		t.Fatalf("after 1.5s fired %v at %s", fired, c.Now())
+	}
+	c.Advance(time.Hour)
+	if !slices.Equal(fired, []string{"a", "b", "c"}) {
+		t.Fatalf("fired %v, want deadline order", fired)
+	}
+	for i, want := range []time.Duration{time.Second, 2 * time.Second, 3 * time.Second} {
+		if !at[i].Equal(epoch.Add(want)) {
+			t.Errorf("timer %s saw %s, want its own deadline", fired[i], at[i].Sub(epoch))
+		}
+	}
+	if c.Pending() != 0 || !c.Now().Equal(epoch.Add(time.Hour+1500*time.Millisecond)) {
+		t.Fatalf("%d timers pending at %s", c.Pending(), c.Now())
+	}
+}
+
+func TestFakeClockAfter(t *testing.T) {
+	c := sparkgaptest.NewFakeClock(epoch)
+	ch := c.After(time.Minute)
+	select {
+	case <-ch:
+		t.Fatal("After fired before its time")
+	default:
+	}
+	c.Set(epoch.Add(time.Minute))
+	if got := <-ch; !got.Equal(epoch.Add(time.Minute)) {
+		t.Fatalf("After sent %s", got)
+	}
+	select {
+	case <-c.After(0):
+	default:
+		t.Fatal("After(0) did not fire at once")
+	}
+	c.Set(epoch)
+	if !c.Now().Equal(epoch.Add(time.Minute)) {
+		t.Fatal("Set moved the clock backwards")
+	}
+}
+
+func TestFakeClockStopReset(t *testing.T) {
+	c := sparkgaptest.NewFakeClock(epoch)
+	fired := 0
+	tm := c.AfterFunc(time.Second, func() { fired++ })
+	if !tm.Stop() || tm.Stop() {
+		t.Fatal("Stop should report true for an active timer only")
+	}
+	c.Advance(time.Second)
+	if fired != 0 {
+		t.Fatal("stopped timer fired")
+	}
+	if tm.Reset(time.Second) {
+		t.Fatal("Reset of a stopped timer reported it active")
+	}
+	c.Advance(time.Second - time.Nanosecond)
+	if fired != 0 {
+		t.Fatal("reset timer fired early")
+	}
+	c.Advance(time.Nanosecond)
+	if fired != 1 || c.Pending() != 0 {
+		t.Fatalf("fired %d times with %d pending, want once and none", fired, c.Pending())
+	}
+}
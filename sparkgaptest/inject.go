@@ -0,0 +1,100 @@
+package sparkgaptest
+
+import (
+	"errors"
+	"sync"
+)
+
+// ErrInjected is the error injected by Fail when no error is given.
+var ErrInjected = errors.New("sparkgaptest: injected failure")
+
+// Step is a run of identical outcomes in a Script.
+type Step struct {
+	N   int
+	Err error
+}
+
+// Fail is a step of n calls failing with err, or ErrInjected if err is nil.
+func Fail(n int, err error) Step {
+	if err == nil {
+		err = ErrInjected
+	}
+	return Step{N: n, Err: err}
+}
+
+// Succeed is a step of n successful calls.
+func Succeed(n int) Step {
+	return Step{N: n}
+}
+
+/*
+Script is a scripted dependency for driving a breaker through a known sequence of outcomes:
+
+	dep := sparkgaptest.NewScript(sparkgaptest.Fail(5, nil), sparkgaptest.Succeed(3))
+	err := br.Do(dep.Call)
+
+Calls past the end of the script succeed, unless Loop was set. A Script is safe for
+concurrent use; concurrent callers consume outcomes in the order they reach it.
+*/
+type Script struct {
+	mu    sync.Mutex
+	steps []Step
+	total int
+	calls int
+	loop  bool
+}
+
+// NewScript returns a Script playing steps in order.
+func NewScript(steps ...Step) *Script {
+	s := &Script{steps: steps}
+	for _, st := range steps {
+		s.total += max(st.N, 0)
+	}
+	return s
+}
+
+// Loop makes the script start over once it has played every step.
+func (s *Script) Loop() *Script {
+	s.mu.Lock()
+	s.loop = true
+	s.mu.Unlock()
+	return s
+}
+
+// Call returns the next scripted outcome.
+func (s *Script) Call() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	i := s.calls
+	s.calls++
+	if s.total == 0 {
+		return nil
+	}
+	if i >= s.total {
+		if !s.loop {
+			return nil
+		}
+		i %= s.total
+	}
+	for _, st := range s.steps {
+		if i < max(st.N, 0) {
+			return st.Err
+		}
+		i -= max(st.N, 0)
+	}
+	return nil
+}
+
+// Calls returns how many outcomes have been handed out.
+func (s *Script) Calls() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.calls
+}
+
+// Remaining returns how many scripted outcomes are left, zero once the script has played through.
+func (s *Script) Remaining() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return max(s.total-s.calls, 0)
+}
@@ -0,0 +1,53 @@
+package sparkgaptest_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/afk-ankit/sparkgap/sparkgaptest"
+)
+
+var errBoom = errors.New("boom")
+
+func TestScript(t *testing.T) {
+	cases := []struct {
+		name   string
+		script *sparkgaptest.Script
+		want   []error
+	}{
+		{"empty", sparkgaptest.NewScript(), []error{nil, nil}},
+		{
+			"steps then success",
+			sparkgaptest.NewScript(sparkgaptest.Fail(2, nil), sparkgaptest.Succeed(1), sparkgaptest.Fail(1, errBoom)),
+			[]error{sparkgaptest.ErrInjected, sparkgaptest.ErrInjected, nil, errBoom, nil, nil},
+		},
+		{
+			"loop",
+			sparkgaptest.NewScript(sparkgaptest.Succeed(1), sparkgaptest.Fail(1, errBoom)).Loop(),
+			[]error{nil, errBoom, nil, errBoom, nil},
+		},
+		{"negative steps are empty", sparkgaptest.NewScript(sparkgaptest.Fail(-3, nil), sparkgaptest.Fail(1, errBoom)), []error{errBoom, nil}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			for i, want := range tc.want {
+				if err := tc.script.Call(); err != want {
+					t.Fatalf("call %d = %v, want %v", i, err, want)
+				}
+			}
+			if got := tc.script.Calls(); got != len(tc.want) {
+				t.Fatalf("Calls = %d, want %d", got, len(tc.want))
+			}
+		})
+	}
+}
+
+func TestScriptRemaining(t *testing.T) {
+	s := sparkgaptest.NewScript(sparkgaptest.Fail(2, nil), sparkgaptest.Succeed(1))
+	for _, want := range []int{3, 2, 1, 0, 0} {
+		if got := s.Remaining(); got != want {
+			t.Fatalf("Remaining = %d, want %d", got, want)
+		}
+		_ = s.Call()
+	}
+}
@@ -0,0 +1,76 @@
+package sparkgaptest
+
+import (
+	"slices"
+	"sync"
+
+	"github.com/afk-ankit/sparkgap"
+)
+
+/*
+RecordingListener subscribes to a breaker and keeps every event it emits, so tests can assert
+on transitions and call outcomes after the fact. It is safe for concurrent use.
+*/
+type RecordingListener struct {
+	mu          sync.Mutex
+	events      []sparkgap.Event
+	unsubscribe func()
+}
+
+// NewRecordingListener starts recording the events of br until Stop is called.
+func NewRecordingListener(br Breaker) *RecordingListener {
+	l := &RecordingListener{}
+	l.unsubscribe = br.Subscribe(l.record)
+	return l
+}
+
+func (l *RecordingListener) record(ev sparkgap.Event) {
+	l.mu.Lock()
+	l.events = append(l.events, ev)
+	l.mu.Unlock()
+}
+
+// Stop stops recording; the events recorded so far are kept.
+func (l *RecordingListener) Stop() {
+	l.unsubscribe()
+}
+
+// Events returns the recorded events, oldest first.
+func (l *RecordingListener) Events() []sparkgap.Event {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return slices.Clone(l.events)
+}
+
+// Transitions returns the states the breaker moved to, in order.
+func (l *RecordingListener) Transitions() []sparkgap.State {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	var out []sparkgap.State
+	for _, ev := range l.events {
+		if ev.Kind == sparkgap.EventStateChange {
+			out = append(out, ev.To)
+		}
+	}
+	return out
+}
+
+// Count returns how many events of kind have been recorded.
+func (l *RecordingListener) Count(kind sparkgap.EventKind) int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	n := 0
+	for _, ev := range l.events {
+		if ev.Kind == kind {
+			n++
+		}
+	}
+	return n
+}
+
+// Clear drops the events recorded so far.
+func (l *RecordingListener) Clear() {
+	l.mu.Lock()
+	l.events = nil
+	l.mu.Unlock()
+}
@@ -0,0 +1,64 @@
+package sparkgaptest_test
+
+import (
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/afk-ankit/sparkgap"
+	"github.com/afk-ankit/sparkgap/sparkgaptest"
+)
+
+func TestRecordingListener(t *testing.T) {
+	clock := sparkgaptest.NewFakeClock(epoch)
+	br, err := sparkgap.NewCircuitBreaker("db", &sparkgap.BreakerConfig{
+		FailureThreshold: 2,
+		RetryInterval:    time.Second,
+		HalfOpenMode:     sparkgap.HalfOpenConsecutive,
+		SuccessThreshold: 1,
+		Clock:            clock,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer br.Close()
+	l := sparkgaptest.NewRecordingListener(br)
+	dep := sparkgaptest.NewScript(sparkgaptest.Fail(2, nil))
+
+	for range 3 {
+		_ = br.Do(dep.Call)
+	}
+	clock.Advance(time.Second)
+	sparkgaptest.WaitForState(t, br, sparkgap.StateHalfOpen, time.Second)
+	_ = br.Do(dep.Call)
+	sparkgaptest.RequireState(t, br, sparkgap.StateClosed)
+
+	want := []sparkgap.State{sparkgap.StateOpen, sparkgap.StateHalfOpen, sparkgap.StateClosed}
+	if got := l.Transitions(); !slices.Equal(got, want) {
+		t.Fatalf("Transitions = %v, want %v", got, want)
+	}
+	if got := l.Count(sparkgap.EventStateChange); got != 3 {
+		t.Fatalf("Count(StateChange) = %d, want 3", got)
+	}
+	if len(l.Events()) <= 3 {
+		t.Fatalf("recorded %d events, want call events besides the transitions", len(l.Events()))
+	}
+
+	l.Clear()
+	l.Stop()
+	br.Trip()
+	if n := len(l.Events()); n != 0 {
+		t.Fatalf("recorded %d events after Clear and Stop", n)
+	}
+}
+
+func TestWaitForStateRealClock(t *testing.T) {
+	br, err := sparkgap.NewCircuitBreaker("db", &sparkgap.BreakerConfig{FailureThreshold: 1, RetryInterval: 10 * time.Millisecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer br.Close()
+	_ = br.Do(func() error { return errors.New("down") })
+	sparkgaptest.WaitForState(t, br, sparkgap.StateHalfOpen, 5*time.Second)
+}
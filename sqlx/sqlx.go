@@ -0,0 +1,294 @@
+/*
+Package sqlx protects a database/sql connection pool with a sparkgap breaker by wrapping its
+driver.Connector, so every connect, query, exec, prepare and transaction goes through the
+breaker without changing call sites:
+
+	db := sql.OpenDB(sqlx.Wrap(connector, br))
+
+The breaker's Timeout bounds connects, execs, prepares, pings and commits. Queries and
+transactions return rows and transactions that outlive the guarded call, so they run on the
+caller's ctx instead: the Timeout bounds neither them nor row iteration. Bound them with the
+ctx passed to QueryContext and BeginTx.
+*/
+package sqlx
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"strings"
+
+	"github.com/afk-ankit/sparkgap"
+)
+
+// Option configures Wrap.
+type Option func(*connector)
+
+/*
+WithClassifier replaces the default failure classification. c is called with every non-nil
+error a database operation returns and reports whether it counts against the breaker.
+*/
+func WithClassifier(c sparkgap.Classifier) Option {
+	return func(cn *connector) { cn.isFailure = c }
+}
+
+/*
+IsFailure is the default classifier. Connection errors, driver.ErrBadConn and timeouts count
+as failures; sql.ErrNoRows, cancellations by the caller and errors reporting an SQLSTATE of
+class 23 (integrity constraint violation, via a SQLState() string method as implemented by
+common Postgres drivers) do not, since they say nothing about the database's health.
+*/
+func IsFailure(err error) bool {
+	switch {
+	case errors.Is(err, sql.ErrNoRows), errors.Is(err, context.Canceled), errors.Is(err, driver.ErrSkip):
+		return false
+	}
+	var state interface{ SQLState() string }
+	if errors.As(err, &state) && strings.HasPrefix(state.SQLState(), "23") {
+		return false
+	}
+	return true
+}
+
+// Wrap returns a connector whose connections run every operation through br.
+func Wrap(c driver.Connector, br *sparkgap.CircuitBreaker, opts ...Option) driver.Connector {
+	cn := &connector{Connector: c, br: br, isFailure: IsFailure}
+	for _, opt := range opts {
+		opt(cn)
+	}
+	return cn
+}
+
+type connector struct {
+	driver.Connector
+	br        *sparkgap.CircuitBreaker
+	isFailure sparkgap.Classifier
+}
+
+/*
+guard runs fn through the breaker. Errors the classifier accepts are hidden from the breaker
+so its own IsFailure sees a success, but are still returned to the caller.
+*/
+func (cn *connector) guard(ctx context.Context, fn func(ctx context.Context) error) error {
+	var callErr error
+	err := cn.br.DoContext(ctx, func(ctx context.Context) error {
+		callErr = fn(ctx)
+		if callErr != nil && !cn.isFailure(callErr) {
+			return nil
+		}
+		return callErr
+	})
+	if callErr != nil {
+		return callErr
+	}
+	return err
+}
+
+/*
+guardLasting is guard for operations returning driver.Rows or a driver.Tx. The breaker cancels
+its ctx when the guarded call returns, which would break them, so fn gets the caller's ctx.
+*/
+func (cn *connector) guardLasting(ctx context.Context, fn func(ctx context.Context) error) error {
+	return cn.guard(ctx, func(context.Context) error { return fn(ctx) })
+}
+
+func (cn *connector) Connect(ctx context.Context) (driver.Conn, error) {
+	var c driver.Conn
+	err := cn.guard(ctx, func(ctx context.Context) error {
+		var err error
+		c, err = cn.Connector.Connect(ctx)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &conn{Conn: c, cn: cn}, nil
+}
+
+type conn struct {
+	driver.Conn
+	cn *connector
+}
+
+func (c *conn) Prepare(query string) (driver.Stmt, error) {
+	return c.PrepareContext(context.Background(), query)
+}
+
+func (c *conn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	var s driver.Stmt
+	err := c.cn.guard(ctx, func(ctx context.Context) error {
+		var err error
+		if p, ok := c.Conn.(driver.ConnPrepareContext); ok {
+			s, err = p.PrepareContext(ctx, query)
+		} else {
+			s, err = c.Conn.Prepare(query)
+		}
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &stmt{Stmt: s, cn: c.cn}, nil
+}
+
+func (c *conn) Begin() (driver.Tx, error) {
+	return c.BeginTx(context.Background(), driver.TxOptions{})
+}
+
+func (c *conn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	var t driver.Tx
+	err := c.cn.guardLasting(ctx, func(ctx context.Context) error {
+		var err error
+		if b, ok := c.Conn.(driver.ConnBeginTx); ok {
+			t, err = b.BeginTx(ctx, opts)
+		} else {
+			t, err = c.Conn.Begin()
+		}
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &tx{Tx: t, cn: c.cn}, nil
+}
+
+func (c *conn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	e, ok := c.Conn.(driver.ExecerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	var res driver.Result
+	err := c.cn.guard(ctx, func(ctx context.Context) error {
+		var err error
+		res, err = e.ExecContext(ctx, query, args)
+		return err
+	})
+	return res, err
+}
+
+func (c *conn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	q, ok := c.Conn.(driver.QueryerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	var rows driver.Rows
+	err := c.cn.guardLasting(ctx, func(ctx context.Context) error {
+		var err error
+		rows, err = q.QueryContext(ctx, query, args)
+		return err
+	})
+	return rows, err
+}
+
+func (c *conn) Ping(ctx context.Context) error {
+	p, ok := c.Conn.(driver.Pinger)
+	if !ok {
+		return nil
+	}
+	return c.cn.guard(ctx, p.Ping)
+}
+
+func (c *conn) ResetSession(ctx context.Context) error {
+	if r, ok := c.Conn.(driver.SessionResetter); ok {
+		return r.ResetSession(ctx)
+	}
+	return nil
+}
+
+func (c *conn) IsValid() bool {
+	if v, ok := c.Conn.(driver.Validator); ok {
+		return v.IsValid()
+	}
+	return true
+}
+
+func (c *conn) CheckNamedValue(nv *driver.NamedValue) error {
+	if n, ok := c.Conn.(driver.NamedValueChecker); ok {
+		return n.CheckNamedValue(nv)
+	}
+	return driver.ErrSkip
+}
+
+type stmt struct {
+	driver.Stmt
+	cn *connector
+}
+
+func (s *stmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	e, ok := s.Stmt.(driver.StmtExecContext)
+	var vals []driver.Value
+	if !ok {
+		var err error
+		if vals, err = values(args); err != nil {
+			return nil, err
+		}
+	}
+	var res driver.Result
+	err := s.cn.guard(ctx, func(ctx context.Context) error {
+		var err error
+		if ok {
+			res, err = e.ExecContext(ctx, args)
+		} else {
+			res, err = s.Stmt.Exec(vals)
+		}
+		return err
+	})
+	return res, err
+}
+
+func (s *stmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	q, ok := s.Stmt.(driver.StmtQueryContext)
+	var vals []driver.Value
+	if !ok {
+		var err error
+		if vals, err = values(args); err != nil {
+			return nil, err
+		}
+	}
+	var rows driver.Rows
+	err := s.cn.guardLasting(ctx, func(ctx context.Context) error {
+		var err error
+		if ok {
+			rows, err = q.QueryContext(ctx, args)
+		} else {
+			rows, err = s.Stmt.Query(vals)
+		}
+		return err
+	})
+	return rows, err
+}
+
+func values(args []driver.NamedValue) ([]driver.Value, error) {
+	vals := make([]driver.Value, len(args))
+	for i, a := range args {
+		if a.Name != "" {
+			return nil, errors.New("sqlx: driver does not support named parameters")
+		}
+		vals[i] = a.Value
+	}
+	return vals, nil
+}
+
+type tx struct {
+	driver.Tx
+	cn *connector
+}
+
+func (t *tx) Commit() error {
+	return t.cn.guard(context.Background(), func(context.Context) error { return t.Tx.Commit() })
+}
+
+var (
+	_ driver.Connector          = (*connector)(nil)
+	_ driver.ConnPrepareContext = (*conn)(nil)
+	_ driver.ConnBeginTx        = (*conn)(nil)
+	_ driver.ExecerContext      = (*conn)(nil)
+	_ driver.QueryerContext     = (*conn)(nil)
+	_ driver.Pinger             = (*conn)(nil)
+	_ driver.SessionResetter    = (*conn)(nil)
+	_ driver.Validator          = (*conn)(nil)
+	_ driver.NamedValueChecker  = (*conn)(nil)
+	_ driver.StmtExecContext    = (*stmt)(nil)
+	_ driver.StmtQueryContext   = (*stmt)(nil)
+)
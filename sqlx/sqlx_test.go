@@ -0,0 +1,194 @@
+package sqlx_test
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/afk-ankit/sparkgap"
+	"github.com/afk-ankit/sparkgap/sqlx"
+)
+
+// fakeDriver serves one-column rows and fails every operation with err, if set. Rows and
+// transactions check the ctx they were opened with, as real drivers do.
+type fakeDriver struct {
+	rows int
+	err  error
+}
+
+func (d *fakeDriver) Connect(context.Context) (driver.Conn, error) { return &fakeConn{d}, nil }
+func (d *fakeDriver) Driver() driver.Driver                        { return nil }
+
+type fakeConn struct{ d *fakeDriver }
+
+func (c *fakeConn) Prepare(string) (driver.Stmt, error) { return nil, errors.New("not supported") }
+func (c *fakeConn) Close() error                        { return nil }
+func (c *fakeConn) Begin() (driver.Tx, error)           { return nil, errors.New("not supported") }
+
+func (c *fakeConn) BeginTx(ctx context.Context, _ driver.TxOptions) (driver.Tx, error) {
+	if c.d.err != nil {
+		return nil, c.d.err
+	}
+	return &fakeTx{ctx}, nil
+}
+
+func (c *fakeConn) ExecContext(context.Context, string, []driver.NamedValue) (driver.Result, error) {
+	if c.d.err != nil {
+		return nil, c.d.err
+	}
+	return driver.RowsAffected(1), nil
+}
+
+func (c *fakeConn) QueryContext(ctx context.Context, _ string, _ []driver.NamedValue) (driver.Rows, error) {
+	if c.d.err != nil {
+		return nil, c.d.err
+	}
+	return &fakeRows{ctx: ctx, left: c.d.rows}, nil
+}
+
+type fakeRows struct {
+	ctx  context.Context
+	left int
+}
+
+func (r *fakeRows) Columns() []string { return []string{"n"} }
+func (r *fakeRows) Close() error      { return nil }
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if err := r.ctx.Err(); err != nil {
+		return err
+	}
+	if r.left == 0 {
+		return io.EOF
+	}
+	dest[0] = int64(r.left)
+	r.left--
+	return nil
+}
+
+type fakeTx struct{ ctx context.Context }
+
+func (t *fakeTx) Commit() error   { return t.ctx.Err() }
+func (t *fakeTx) Rollback() error { return nil }
+
+type sqlStateError string
+
+func (e sqlStateError) Error() string    { return "sqlstate " + string(e) }
+func (e sqlStateError) SQLState() string { return string(e) }
+
+func open(t *testing.T, d *fakeDriver, cfg *sparkgap.BreakerConfig) (*sql.DB, *sparkgap.CircuitBreaker) {
+	t.Helper()
+	br, err := sparkgap.NewCircuitBreaker("db", cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	db := sql.OpenDB(sqlx.Wrap(d, br))
+	t.Cleanup(func() {
+		db.Close()
+		br.Close()
+	})
+	return db, br
+}
+
+func countRows(db *sql.DB) (int, error) {
+	rows, err := db.QueryContext(context.Background(), "SELECT n")
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+	n := 0
+	for rows.Next() {
+		n++
+	}
+	return n, rows.Err()
+}
+
+func TestQueryOutlivesTimeout(t *testing.T) {
+	db, _ := open(t, &fakeDriver{rows: 3}, &sparkgap.BreakerConfig{Timeout: time.Second})
+	n, err := countRows(db)
+	if err != nil || n != 3 {
+		t.Fatalf("read %d rows, err %v; want 3 rows", n, err)
+	}
+}
+
+func TestTxOutlivesTimeout(t *testing.T) {
+	db, _ := open(t, &fakeDriver{}, &sparkgap.BreakerConfig{Timeout: time.Second})
+	tx, err := db.BeginTx(context.Background(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatalf("Commit = %v", err)
+	}
+}
+
+func TestExecThroughBreaker(t *testing.T) {
+	d := &fakeDriver{}
+	db, br := open(t, d, &sparkgap.BreakerConfig{FailureThreshold: 2})
+	if _, err := db.Exec("UPDATE t SET n = 1"); err != nil {
+		t.Fatal(err)
+	}
+	d.err = errors.New("connection reset")
+	for range 2 {
+		if _, err := db.Exec("UPDATE t SET n = 1"); !errors.Is(err, d.err) {
+			t.Fatalf("Exec = %v, want the driver's error", err)
+		}
+	}
+	if st := br.State(); st != sparkgap.StateOpen {
+		t.Fatalf("state = %s, want Open", st)
+	}
+	d.err = nil
+	if _, err := db.Exec("UPDATE t SET n = 1"); !errors.Is(err, sparkgap.ErrOpen) {
+		t.Fatalf("Exec while open = %v, want ErrOpen", err)
+	}
+}
+
+func TestIsFailure(t *testing.T) {
+	cases := []struct {
+		err  error
+		want bool
+	}{
+		{errors.New("connection refused"), true},
+		{driver.ErrBadConn, true},
+		{context.DeadlineExceeded, true},
+		{sqlStateError("08006"), true},
+		{sql.ErrNoRows, false},
+		{context.Canceled, false},
+		{sqlStateError("23505"), false},
+	}
+	for _, tc := range cases {
+		if got := sqlx.IsFailure(tc.err); got != tc.want {
+			t.Errorf("IsFailure(%v) = %t, want %t", tc.err, got, tc.want)
+		}
+	}
+}
+
+func TestClassifiedErrorsKeepBreakerClosed(t *testing.T) {
+	d := &fakeDriver{err: sqlStateError("23505")}
+	db, br := open(t, d, &sparkgap.BreakerConfig{FailureThreshold: 1})
+	if _, err := db.Exec("INSERT INTO t VALUES (1)"); !errors.Is(err, d.err) {
+		t.Fatalf("Exec = %v, want the constraint violation", err)
+	}
+	if st := br.State(); st != sparkgap.StateClosed {
+		t.Fatalf("state after a constraint violation = %s, want Closed", st)
+	}
+}
+
+func TestWithClassifier(t *testing.T) {
+	d := &fakeDriver{err: sqlStateError("23505")}
+	br, err := sparkgap.NewCircuitBreaker("db", &sparkgap.BreakerConfig{FailureThreshold: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer br.Close()
+	db := sql.OpenDB(sqlx.Wrap(d, br, sqlx.WithClassifier(func(error) bool { return true })))
+	defer db.Close()
+	_, _ = db.Exec("INSERT INTO t VALUES (1)")
+	if st := br.State(); st != sparkgap.StateOpen {
+		t.Fatalf("state = %s, want Open", st)
+	}
+}
@@ -0,0 +1,59 @@
+package sparkgap
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// State is the position of a breaker in the Closed → Open → Half-Open cycle.
+type State int32
+
+const (
+	StateClosed State = iota
+	StateOpen
+	StateHalfOpen
+	// StateThrottled sits between Closed and Open and only admits part of the calls; see BrownOut.
+	StateThrottled
+)
+
+func (s State) String() string {
+	switch s {
+	case StateClosed:
+		return "Closed"
+	case StateOpen:
+		return "Open"
+	case StateHalfOpen:
+		return "Half-Open"
+	case StateThrottled:
+		return "Throttled"
+	default:
+		return fmt.Sprintf("Unknown(%d)", int32(s))
+	}
+}
+
+// MarshalText renders the state by name, so it reads naturally in JSON and logs.
+func (s State) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// UnmarshalText parses a name produced by MarshalText.
+func (s *State) UnmarshalText(text []byte) error {
+	switch v := string(text); v {
+	case "Closed":
+		*s = StateClosed
+	case "Open":
+		*s = StateOpen
+	case "Half-Open":
+		*s = StateHalfOpen
+	case "Throttled":
+		*s = StateThrottled
+	default:
+		n, err := strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(v, "Unknown("), ")"))
+		if err != nil {
+			return fmt.Errorf("unknown breaker state %q", v)
+		}
+		*s = State(n)
+	}
+	return nil
+}
@@ -0,0 +1,139 @@
+package sparkgap
+
+import (
+	"sync"
+	"time"
+)
+
+const statsBuckets = 60
+
+/*
+CallStats summarizes the traffic through a breaker over the trailing StatsWindow: how many calls
+succeeded, failed or were rejected, the same as per-second rates, and percentiles of the
+durations of admitted calls. Percentiles are upper bounds of power-of-two histogram bins.
+*/
+type CallStats struct {
+	Window     time.Duration `json:"window"`
+	Successes  uint64        `json:"successes"`
+	Failures   uint64        `json:"failures"`
+	Rejections uint64        `json:"rejections"`
+
+	SuccessRate   float64 `json:"success_rate"`
+	FailureRate   float64 `json:"failure_rate"`
+	RejectionRate float64 `json:"rejection_rate"`
+	// FailurePercent is Failures as a percentage of admitted calls, or 0 without traffic.
+	FailurePercent float64 `json:"failure_percent"`
+
+	P50 time.Duration `json:"p50"`
+	P90 time.Duration `json:"p90"`
+	P99 time.Duration `json:"p99"`
+}
+
+type statsBucket struct {
+	start      int64
+	successes  uint64
+	failures   uint64
+	rejections uint64
+	hist       [histogramBins]uint64
+}
+
+// statsWindow counts outcomes and call durations per fixed-width time bucket across StatsWindow.
+type statsWindow struct {
+	mu         sync.Mutex
+	window     time.Duration
+	resolution time.Duration
+	buckets    [statsBuckets]statsBucket
+}
+
+func newStatsWindow(window time.Duration) *statsWindow {
+	if window <= 0 {
+		return nil
+	}
+	return &statsWindow{
+		window:     window,
+		resolution: max(window/statsBuckets, time.Millisecond),
+	}
+}
+
+// bucketLocked returns the bucket for now, clearing it if it still holds an older slot.
+func (w *statsWindow) bucketLocked(now time.Time) *statsBucket {
+	slot := now.UnixNano() / int64(w.resolution)
+	b := &w.buckets[slot%statsBuckets]
+	if b.start != slot {
+		*b = statsBucket{start: slot}
+	}
+	return b
+}
+
+func (w *statsWindow) record(now time.Time, failed bool, d time.Duration) {
+	if w == nil {
+		return
+	}
+	w.mu.Lock()
+	b := w.bucketLocked(now)
+	if failed {
+		b.failures++
+	} else {
+		b.successes++
+	}
+	b.hist[histogramBin(d)]++
+	w.mu.Unlock()
+}
+
+func (w *statsWindow) reject(now time.Time) {
+	if w == nil {
+		return
+	}
+	w.mu.Lock()
+	w.bucketLocked(now).rejections++
+	w.mu.Unlock()
+}
+
+// sum adds up the buckets within the window ending at now.
+func (w *statsWindow) sum(now time.Time) (CallStats, *[histogramBins]uint64) {
+	slot := now.UnixNano() / int64(w.resolution)
+	s := CallStats{Window: w.window}
+	var hist [histogramBins]uint64
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for i := range w.buckets {
+		b := &w.buckets[i]
+		if b.start <= slot-statsBuckets || b.start > slot {
+			continue
+		}
+		s.Successes += b.successes
+		s.Failures += b.failures
+		s.Rejections += b.rejections
+		for j, n := range b.hist {
+			hist[j] += n
+		}
+	}
+	return s, &hist
+}
+
+func (w *statsWindow) read(now time.Time) CallStats {
+	if w == nil {
+		return CallStats{}
+	}
+	s, hist := w.sum(now)
+	secs := w.window.Seconds()
+	s.SuccessRate = float64(s.Successes) / secs
+	s.FailureRate = float64(s.Failures) / secs
+	s.RejectionRate = float64(s.Rejections) / secs
+	if calls := s.Successes + s.Failures; calls > 0 {
+		s.FailurePercent = float64(s.Failures) * 100 / float64(calls)
+		s.P50 = percentile(hist, calls, 50)
+		s.P90 = percentile(hist, calls, 90)
+		s.P99 = percentile(hist, calls, 99)
+	}
+	return s
+}
+
+/*
+Stats returns success, failure and rejection counts and rates and latency percentiles over the
+trailing StatsWindow, for exposing request-level SLIs per dependency. It returns the zero
+CallStats unless StatsWindow is set.
+*/
+func (br *CircuitBreaker) Stats() CallStats {
+	return br.stats.read(br.clock.Now())
+}
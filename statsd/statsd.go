@@ -0,0 +1,201 @@
+/*
+Package statsd sends sparkgap breaker metrics to a StatsD or DogStatsD agent over UDP:
+
+	sink, err := statsd.New(statsd.Config{Addr: "127.0.0.1:8125", Datadog: true, Tags: []string{"env:prod"}})
+	if err != nil {
+		return err
+	}
+	defer sink.Close()
+	br, err := sparkgap.NewCircuitBreaker("payments", &sparkgap.BreakerConfig{Metrics: sink})
+
+Metrics are buffered and sent in packets of up to MaxPacketSize bytes, at least every
+FlushInterval. Sending never blocks the breaker; a failed write drops the packet.
+*/
+package statsd
+
+import (
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/afk-ankit/sparkgap"
+)
+
+const (
+	defaultFlushInterval = time.Second
+	defaultMaxPacketSize = 1432
+)
+
+// Config configures a Sink.
+type Config struct {
+	// Addr is the host:port of the agent.
+	Addr string
+	// Prefix is prepended to every metric name, e.g. "myapp." for "myapp.sparkgap.calls".
+	Prefix string
+	// Datadog sends tags in the DogStatsD format. Plain StatsD has no tags, so without it
+	// tags are dropped.
+	Datadog bool
+	// Tags are added to every metric, as "key:value" strings.
+	Tags []string
+	// FlushInterval bounds how long metrics are buffered. Zero means one second.
+	FlushInterval time.Duration
+	// MaxPacketSize is the largest UDP payload sent. Zero means 1432 bytes, which fits an
+	// Ethernet frame.
+	MaxPacketSize int
+}
+
+// Sink is a sparkgap.MetricsSink writing to a StatsD agent. It is safe for concurrent use.
+type Sink struct {
+	conn     net.Conn
+	prefix   string
+	datadog  bool
+	tags     []string
+	maxBytes int
+
+	mu  sync.Mutex
+	buf []byte
+
+	// dropped counts packets that could not be written.
+	dropped atomic.Uint64
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+var _ sparkgap.MetricsSink = (*Sink)(nil)
+
+// New dials the agent at cfg.Addr and starts flushing in the background.
+func New(cfg Config) (*Sink, error) {
+	conn, err := net.Dial("udp", cfg.Addr)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.FlushInterval <= 0 {
+		cfg.FlushInterval = defaultFlushInterval
+	}
+	if cfg.MaxPacketSize <= 0 {
+		cfg.MaxPacketSize = defaultMaxPacketSize
+	}
+	s := &Sink{
+		conn:     conn,
+		prefix:   cfg.Prefix,
+		datadog:  cfg.Datadog,
+		tags:     cfg.Tags,
+		maxBytes: cfg.MaxPacketSize,
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	go s.run(cfg.FlushInterval)
+	return s, nil
+}
+
+// Count sends a counter increment.
+func (s *Sink) Count(name string, delta int64, tags []string) {
+	s.add(name, strconv.AppendInt(nil, delta, 10), "c", tags)
+}
+
+// Gauge sends a gauge value.
+func (s *Sink) Gauge(name string, value float64, tags []string) {
+	s.add(name, strconv.AppendFloat(nil, value, 'f', -1, 64), "g", tags)
+}
+
+// Timing sends a duration in milliseconds.
+func (s *Sink) Timing(name string, d time.Duration, tags []string) {
+	s.add(name, strconv.AppendFloat(nil, float64(d)/float64(time.Millisecond), 'f', -1, 64), "ms", tags)
+}
+
+// Dropped returns how many packets could not be sent.
+func (s *Sink) Dropped() uint64 {
+	return s.dropped.Load()
+}
+
+// add appends one line in the form name:value|type|#tags.
+func (s *Sink) add(name string, value []byte, typ string, tags []string) {
+	var line strings.Builder
+	line.WriteString(s.prefix)
+	line.WriteString(sanitize(name))
+	line.WriteByte(':')
+	line.Write(value)
+	line.WriteByte('|')
+	line.WriteString(typ)
+	if s.datadog && len(s.tags)+len(tags) > 0 {
+		line.WriteString("|#")
+		for i, t := range append(s.tags[:len(s.tags):len(s.tags)], tags...) {
+			if i > 0 {
+				line.WriteByte(',')
+			}
+			line.WriteString(sanitize(t))
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.buf) > 0 && len(s.buf)+1+line.Len() > s.maxBytes {
+		s.flushLocked()
+	}
+	if len(s.buf) > 0 {
+		s.buf = append(s.buf, '\n')
+	}
+	s.buf = append(s.buf, line.String()...)
+}
+
+// sanitize replaces the characters that delimit StatsD fields.
+func sanitize(s string) string {
+	if !strings.ContainsAny(s, "|,#\n") {
+		return s
+	}
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case '|', ',', '#', '\n':
+			return '_'
+		}
+		return r
+	}, s)
+}
+
+func (s *Sink) flushLocked() {
+	if len(s.buf) == 0 {
+		return
+	}
+	if _, err := s.conn.Write(s.buf); err != nil {
+		s.dropped.Add(1)
+	}
+	s.buf = s.buf[:0]
+}
+
+// Flush sends the buffered metrics now.
+func (s *Sink) Flush() {
+	s.mu.Lock()
+	s.flushLocked()
+	s.mu.Unlock()
+}
+
+func (s *Sink) run(every time.Duration) {
+	defer close(s.done)
+	tick := time.NewTicker(every)
+	defer tick.Stop()
+	for {
+		select {
+		case <-s.stop:
+			return
+		case <-tick.C:
+			s.Flush()
+		}
+	}
+}
+
+// Close flushes the buffered metrics and closes the connection.
+func (s *Sink) Close() error {
+	select {
+	case <-s.stop:
+		return nil
+	default:
+	}
+	close(s.stop)
+	<-s.done
+	s.Flush()
+	return s.conn.Close()
+}
@@ -0,0 +1,119 @@
+package statsd_test
+
+import (
+	"errors"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/afk-ankit/sparkgap"
+	"github.com/afk-ankit/sparkgap/statsd"
+)
+
+// listen returns a UDP agent and a function reading the next packet it receives.
+func listen(t *testing.T) (addr string, next func() string) {
+	t.Helper()
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Skipf("no UDP: %v", err)
+	}
+	t.Cleanup(func() { pc.Close() })
+	return pc.LocalAddr().String(), func() string {
+		t.Helper()
+		buf := make([]byte, 64<<10)
+		pc.SetReadDeadline(time.Now().Add(5 * time.Second))
+		n, _, err := pc.ReadFrom(buf)
+		if err != nil {
+			t.Fatalf("reading packet: %v", err)
+		}
+		return string(buf[:n])
+	}
+}
+
+func newSink(t *testing.T, cfg statsd.Config) *statsd.Sink {
+	t.Helper()
+	if cfg.FlushInterval == 0 {
+		cfg.FlushInterval = time.Hour
+	}
+	s, err := statsd.New(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { s.Close() })
+	return s
+}
+
+func TestFormat(t *testing.T) {
+	tags := []string{"breaker:db", "reason:a|b"}
+	cases := []struct {
+		name string
+		cfg  statsd.Config
+		want string
+	}{
+		{"plain StatsD drops tags", statsd.Config{Prefix: "app."}, "app.sparkgap.calls:3|c\napp.sparkgap.state:1|g\napp.sparkgap.call.duration:1.5|ms"},
+		{
+			"DogStatsD",
+			statsd.Config{Datadog: true, Tags: []string{"env:prod"}},
+			"sparkgap.calls:3|c|#env:prod,breaker:db,reason:a_b\nsparkgap.state:1|g|#env:prod,breaker:db,reason:a_b\nsparkgap.call.duration:1.5|ms|#env:prod,breaker:db,reason:a_b",
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			addr, next := listen(t)
+			tc.cfg.Addr = addr
+			s := newSink(t, tc.cfg)
+			s.Count("sparkgap.calls", 3, tags)
+			s.Gauge("sparkgap.state", 1, tags)
+			s.Timing("sparkgap.call.duration", 1500*time.Microsecond, tags)
+			s.Flush()
+			if got := next(); got != tc.want {
+				t.Fatalf("packet =\n%s\nwant\n%s", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestPacketSize(t *testing.T) {
+	addr, next := listen(t)
+	s := newSink(t, statsd.Config{Addr: addr, MaxPacketSize: 40})
+	for range 3 {
+		s.Count("sparkgap.calls", 1, nil) // 18 bytes a line
+	}
+	s.Flush()
+	if first, second := next(), next(); first != "sparkgap.calls:1|c\nsparkgap.calls:1|c" || second != "sparkgap.calls:1|c" {
+		t.Fatalf("packets %q and %q, want two lines then one", first, second)
+	}
+}
+
+func TestFlushInterval(t *testing.T) {
+	addr, next := listen(t)
+	s := newSink(t, statsd.Config{Addr: addr, FlushInterval: 5 * time.Millisecond})
+	s.Gauge("sparkgap.inflight", 2, nil)
+	if got := next(); got != "sparkgap.inflight:2|g" {
+		t.Fatalf("packet = %q, want the buffered gauge", got)
+	}
+}
+
+func TestBreakerMetrics(t *testing.T) {
+	addr, next := listen(t)
+	s := newSink(t, statsd.Config{Addr: addr, Datadog: true})
+	br, err := sparkgap.NewCircuitBreaker("db", &sparkgap.BreakerConfig{FailureThreshold: 1, Metrics: s})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer br.Close()
+	_ = br.Do(func() error { return errors.New("down") })
+	_ = br.Do(func() error { return nil })
+	s.Flush()
+	got := next()
+	for _, want := range []string{
+		"sparkgap.calls:1|c|#breaker:db,outcome:failure",
+		"sparkgap.transitions:1|c|#breaker:db",
+		"sparkgap.rejections:1|c|#breaker:db,reason:open",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("packet lacks %q:\n%s", want, got)
+		}
+	}
+}
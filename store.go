@@ -0,0 +1,131 @@
+package sparkgap
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+const defaultStateSyncInterval = time.Second
+
+// SharedState is the trip state a breaker shares with its peers through a StateStore.
+type SharedState struct {
+	State State `json:"state"`
+	// RetryAt is when an open breaker starts probing; peers that adopt the trip wait until then too.
+	RetryAt   time.Time `json:"retry_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+/*
+StateStore shares trip state between breakers of the same name running in different processes,
+so that when one replica opens the circuit for a dependency the others open too. Counters stay
+local to each replica; only trips and recoveries are shared. A new breaker loads the store once
+before it is returned, so it also picks up trips saved before a restart. See FileStore and the
+redisstore package.
+*/
+type StateStore interface {
+	// Load returns the last state saved under name, or false if there is none.
+	Load(ctx context.Context, name string) (SharedState, bool, error)
+	Save(ctx context.Context, name string, s SharedState) error
+}
+
+// storeSync pushes local trips and recoveries to a StateStore and polls it for peers' trips.
+type storeSync struct {
+	store StateStore
+	every time.Duration
+	poll  Timer
+
+	mu       sync.Mutex
+	pending  *SharedState
+	flushing bool
+}
+
+func newStoreSync(store StateStore, every time.Duration) *storeSync {
+	if store == nil {
+		return nil
+	}
+	return &storeSync{store: store, every: every}
+}
+
+// shareLocked queues the breaker's current state for saving. Saves run in order on a single
+// goroutine, and only the latest pending state is kept, so slow stores never block callers.
+func (br *CircuitBreaker) shareLocked() {
+	s := br.shared
+	if s == nil || br.closed.Load() {
+		return
+	}
+	st := SharedState{State: br.state, UpdatedAt: br.clock.Now()}
+	if br.state == StateOpen {
+		st.RetryAt = br.retryAt
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.pending = &st
+	if !s.flushing {
+		s.flushing = true
+		go br.flushStore()
+	}
+}
+
+func (br *CircuitBreaker) flushStore() {
+	s := br.shared
+	for {
+		s.mu.Lock()
+		st := s.pending
+		s.pending = nil
+		if st == nil {
+			s.flushing = false
+			s.mu.Unlock()
+			return
+		}
+		s.mu.Unlock()
+		ctx, cancel := context.WithTimeout(context.Background(), s.every)
+		if err := s.store.Save(ctx, br.name, *st); err != nil {
+			br.logStoreError("save", err)
+		}
+		cancel()
+	}
+}
+
+// pollStore runs on the sync timer and adopts a peer's trip while the breaker is Closed.
+func (br *CircuitBreaker) pollStore() {
+	br.loadStore(true)
+}
+
+/*
+loadStore adopts a trip saved in the store, whether by a peer or by this breaker before a
+restart, then re-arms the poll timer if rearm is set.
+*/
+func (br *CircuitBreaker) loadStore(rearm bool) {
+	s := br.shared
+	ctx, cancel := context.WithTimeout(context.Background(), s.every)
+	remote, ok, err := s.store.Load(ctx, br.name)
+	cancel()
+	if err != nil {
+		br.logStoreError("load", err)
+	}
+
+	br.mu.Lock()
+	defer br.unlockAndNotify()
+	if br.closed.Load() {
+		return
+	}
+	now := br.clock.Now()
+	if ok && remote.State == StateOpen && (br.state == StateClosed || br.state == StateThrottled) && remote.RetryAt.After(now) && !br.holdsTripsLocked() {
+		br.cause = CausePeer
+		br.adoptOpenLocked(remote.RetryAt.Sub(now))
+	}
+	if rearm {
+		s.poll.Reset(s.every)
+	}
+}
+
+func (br *CircuitBreaker) logStoreError(op string, err error) {
+	if l := br.logger.Load(); l != nil {
+		l.LogAttrs(context.Background(), slog.LevelWarn, "circuit breaker state store "+op+" failed",
+			slog.String("breaker", br.name),
+			slog.String("error", err.Error()),
+		)
+	}
+}
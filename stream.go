@@ -0,0 +1,86 @@
+package sparkgap
+
+import (
+	"context"
+	"iter"
+)
+
+/*
+ExecuteStream protects a streaming call, such as a server-streaming RPC or a paginated scan,
+as one breaker call spanning the whole stream rather than just opening it. open starts the
+stream and returns its items; a rejection or an error from open is yielded as the only item.
+The first item error the breaker's Classifier counts as a failure is reported as soon as it is
+seen, so a stream that breaks halfway trips the breaker like a failed call would. A stream that
+ends without such an error, or that the caller stops ranging over early, counts as a success,
+and one cancelled with context.Canceled counts neither way unless CountCanceled is set.
+
+The call is admitted when ranging starts and holds its slot (and, when Half-Open, its probe)
+until ranging ends, so the sequence is meant to be ranged over once. The breaker's Timeout is
+not applied: bound the stream through ctx instead.
+*/
+func ExecuteStream[T any](ctx context.Context, br *CircuitBreaker, open func(ctx context.Context) (iter.Seq2[T, error], error)) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		var zero T
+		c, err := br.begin(ctx)
+		if err != nil {
+			yield(zero, err)
+			return
+		}
+		defer c.release()
+		if br.contextInfo {
+			ctx = ContextWithCallInfo(ctx, CallInfo{Breaker: br.name, State: c.adm.state, Forced: br.Forced()})
+		}
+		seq, err := open(ctx)
+		if err != nil {
+			if br.neutral(err) {
+				c.ignore(err)
+			} else {
+				c.report(br.isFailure(err), err)
+			}
+			yield(zero, err)
+			return
+		}
+		reported := false
+		defer func() {
+			if !reported {
+				c.report(false, nil)
+			}
+		}()
+		for v, err := range seq {
+			if !reported && br.neutral(err) {
+				// The caller gave up on the stream; count it neither way.
+				reported = true
+				c.ignore(err)
+			}
+			if !reported && br.isFailure(err) {
+				reported = true
+				c.report(true, err)
+			}
+			if !yield(v, err) {
+				return
+			}
+		}
+	}
+}
+
+/*
+ChanStream adapts a stream delivered over a channel to the sequence ExecuteStream expects. It
+yields the items of items until it is closed, then waits on errc, if it is not nil, and yields
+the error it receives unless that is nil. errc must then be sent an error or closed.
+*/
+func ChanStream[T any](items <-chan T, errc <-chan error) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		for v := range items {
+			if !yield(v, nil) {
+				return
+			}
+		}
+		if errc == nil {
+			return
+		}
+		if err := <-errc; err != nil {
+			var zero T
+			yield(zero, err)
+		}
+	}
+}
@@ -0,0 +1,224 @@
+package sparkgap_test
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/afk-ankit/sparkgap"
+)
+
+// The stress tests are meant to be run with -race. They hammer one breaker from many
+// goroutines while operators trip, reset and probe it, then check invariants that must
+// hold however the calls interleaved.
+
+const maxConcurrentProbes = 4
+
+var errStress = errors.New("stress failure")
+
+func stressSize(t *testing.T) (goroutines, calls int) {
+	if testing.Short() {
+		return 50, 100
+	}
+	return 300, 400
+}
+
+type stressTally struct {
+	calls, rejected        atomic.Uint64
+	probing, maxProbing    atomic.Int64
+	probeCountViolation    atomic.Value // string
+	halfOpenCountViolation atomic.Value // string
+}
+
+// call runs one call through br, failing with probability failPct percent.
+func (s *stressTally) call(br *sparkgap.CircuitBreaker, failPct int) {
+	err := br.DoContext(context.Background(), func(ctx context.Context) error {
+		s.calls.Add(1)
+		if info, ok := sparkgap.CallInfoFrom(ctx); ok && info.State == sparkgap.StateHalfOpen {
+			n := s.probing.Add(1)
+			defer s.probing.Add(-1)
+			for {
+				m := s.maxProbing.Load()
+				if n <= m || s.maxProbing.CompareAndSwap(m, n) {
+					break
+				}
+			}
+		}
+		if rand.IntN(100) < failPct {
+			return errStress
+		}
+		return nil
+	})
+	var rej *sparkgap.RejectionError
+	if errors.As(err, &rej) {
+		s.rejected.Add(1)
+	} else if err != nil && !errors.Is(err, errStress) {
+		panic("unexpected error: " + err.Error())
+	}
+}
+
+// watch checks snapshot invariants until stop is closed.
+func (s *stressTally) watch(br *sparkgap.CircuitBreaker, stop <-chan struct{}) {
+	for {
+		select {
+		case <-stop:
+			return
+		default:
+		}
+		snap := br.Snapshot()
+		probes := snap.HalfOpenSuccessCount + snap.HalfOpenFailureCount
+		switch snap.State {
+		case sparkgap.StateHalfOpen:
+			if snap.HalfOpenMode == sparkgap.HalfOpenPercentage && probes >= snap.HalfOpenMaxProbes {
+				s.probeCountViolation.CompareAndSwap(nil, "undecided window counted every probe")
+			}
+		default:
+			if probes != 0 {
+				s.halfOpenCountViolation.CompareAndSwap(nil, snap.State.String()+" breaker kept Half-Open counts")
+			}
+		}
+	}
+}
+
+// operate trips, resets and probes br at random until stop is closed.
+func operate(br *sparkgap.CircuitBreaker, stop <-chan struct{}) {
+	for {
+		select {
+		case <-stop:
+			return
+		case <-time.After(time.Duration(rand.IntN(500)) * time.Microsecond):
+		}
+		switch rand.IntN(3) {
+		case 0:
+			br.Trip()
+		case 1:
+			br.Reset()
+		case 2:
+			br.ProbeNow()
+		}
+	}
+}
+
+func stressBreaker(t *testing.T, cfg *sparkgap.BreakerConfig) *sparkgap.CircuitBreaker {
+	t.Helper()
+	cfg.RetryInterval = time.Millisecond
+	cfg.HalfOpenMaxConcurrent = maxConcurrentProbes
+	cfg.ContextInfo = true
+	cfg.HistorySize = 4096
+	br, err := sparkgap.NewCircuitBreaker(t.Name(), cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = br.Close() })
+	return br
+}
+
+func runStress(t *testing.T, br *sparkgap.CircuitBreaker, failPct int) *stressTally {
+	t.Helper()
+	goroutines, calls := stressSize(t)
+	var s stressTally
+	stop := make(chan struct{})
+	var bg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		bg.Add(2)
+		go func() { defer bg.Done(); operate(br, stop) }()
+		go func() { defer bg.Done(); s.watch(br, stop) }()
+	}
+	var wg sync.WaitGroup
+	for i := 0; i < goroutines; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < calls; j++ {
+				s.call(br, failPct)
+			}
+		}()
+	}
+	wg.Wait()
+	close(stop)
+	bg.Wait()
+	return &s
+}
+
+func checkStress(t *testing.T, br *sparkgap.CircuitBreaker, s *stressTally) {
+	t.Helper()
+	snap := br.Snapshot()
+	if snap.TotalCalls != s.calls.Load() {
+		t.Errorf("TotalCalls = %d, want %d calls run", snap.TotalCalls, s.calls.Load())
+	}
+	var rejected uint64
+	for _, n := range snap.Rejections {
+		rejected += n
+	}
+	if rejected != s.rejected.Load() {
+		t.Errorf("Rejections add up to %d, want %d rejected calls", rejected, s.rejected.Load())
+	}
+	if snap.InFlight != 0 {
+		t.Errorf("InFlight = %d after every call returned", snap.InFlight)
+	}
+	if s.maxProbing.Load() == 0 && !testing.Short() {
+		t.Error("no Half-Open probes ran")
+	}
+	if m := s.maxProbing.Load(); m > maxConcurrentProbes {
+		t.Errorf("%d probes ran at once, want at most %d", m, maxConcurrentProbes)
+	}
+	for _, v := range []*atomic.Value{&s.probeCountViolation, &s.halfOpenCountViolation} {
+		if msg := v.Load(); msg != nil {
+			t.Errorf("snapshot: %s", msg)
+		}
+	}
+	if snap.State == sparkgap.StateClosed && snap.FailureCount >= snap.FailureThreshold {
+		t.Errorf("Closed with %d failures, threshold %d", snap.FailureCount, snap.FailureThreshold)
+	}
+
+	hist := br.History()
+	if len(hist) == 0 {
+		t.Fatal("no transitions recorded")
+	}
+	for i, tr := range hist {
+		if tr.From == tr.To {
+			t.Errorf("transition %d: %s → %s", i, tr.From, tr.To)
+		}
+		if tr.From == sparkgap.StateClosed && tr.To == sparkgap.StateHalfOpen {
+			t.Errorf("transition %d: Closed → Half-Open", i)
+		}
+		if i > 0 && tr.From != hist[i-1].To {
+			t.Errorf("transition %d starts in %s, previous ended in %s", i, tr.From, hist[i-1].To)
+		}
+	}
+	if last := hist[len(hist)-1]; last.To != snap.State {
+		t.Errorf("last transition ended in %s, breaker is %s", last.To, snap.State)
+	}
+}
+
+func TestStressPercentageProbes(t *testing.T) {
+	br := stressBreaker(t, &sparkgap.BreakerConfig{
+		FailureThreshold:          5,
+		HalfOpenMaxProbes:         8,
+		HalfOpenMaxFailurePercent: 50,
+	})
+	checkStress(t, br, runStress(t, br, 30))
+}
+
+func TestStressConsecutiveProbes(t *testing.T) {
+	br := stressBreaker(t, &sparkgap.BreakerConfig{
+		FailureThreshold: 3,
+		HalfOpenMode:     sparkgap.HalfOpenConsecutive,
+		SuccessThreshold: 5,
+	})
+	checkStress(t, br, runStress(t, br, 20))
+}
+
+func TestStressFastFail(t *testing.T) {
+	br := stressBreaker(t, &sparkgap.BreakerConfig{
+		FailureThreshold:          10,
+		HalfOpenMaxProbes:         20,
+		HalfOpenMaxFailurePercent: 20,
+		HalfOpenFastFail:          true,
+	})
+	checkStress(t, br, runStress(t, br, 50))
+}
@@ -0,0 +1,212 @@
+/*
+Package tui provides a tview dashboard showing the live state of every breaker in a
+sparkgap.Registry, so tools can embed the same view the repository's example uses.
+*/
+package tui
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+
+	"github.com/afk-ankit/sparkgap"
+)
+
+const defaultRefresh = time.Second
+
+// View is the dashboard widget: a table of breakers above a log of state transitions.
+type View struct {
+	*tview.Flex
+
+	app     *tview.Application
+	reg     *sparkgap.Registry
+	table   *tview.Table
+	log     *tview.TextView
+	refresh time.Duration
+
+	mu     sync.Mutex
+	counts map[string]*callCounts
+	prev   map[string]callTotals
+	last   time.Time
+
+	unsubscribe func()
+	stop        chan struct{}
+	stopOnce    sync.Once
+}
+
+type callCounts struct {
+	calls    atomic.Uint64
+	failures atomic.Uint64
+	rejected atomic.Uint64
+}
+
+type callTotals struct {
+	calls, failures, rejected uint64
+}
+
+// Option customises a View.
+type Option func(*View)
+
+// WithRefresh sets how often the table is redrawn. The default is one second.
+func WithRefresh(d time.Duration) Option {
+	return func(v *View) {
+		if d > 0 {
+			v.refresh = d
+		}
+	}
+}
+
+/*
+Dashboard returns a View rendering reg inside app. It starts refreshing immediately; call
+Stop when the view is no longer shown.
+*/
+func Dashboard(app *tview.Application, reg *sparkgap.Registry, opts ...Option) *View {
+	v := &View{
+		Flex:    tview.NewFlex().SetDirection(tview.FlexRow),
+		app:     app,
+		reg:     reg,
+		table:   tview.NewTable().SetFixed(1, 1),
+		log:     tview.NewTextView().SetDynamicColors(true).SetScrollable(true),
+		refresh: defaultRefresh,
+		counts:  make(map[string]*callCounts),
+		prev:    make(map[string]callTotals),
+		stop:    make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(v)
+	}
+	v.table.SetBorder(true).SetTitle(" Breakers ")
+	v.log.SetBorder(true).SetTitle(" Transitions ")
+	v.log.SetChangedFunc(func() { v.log.ScrollToEnd() })
+	v.AddItem(v.table, 0, 2, false).AddItem(v.log, 0, 1, false)
+
+	v.unsubscribe = reg.Subscribe(v.onEvent)
+	v.last = time.Now()
+	go v.loop()
+	return v
+}
+
+// Stop stops refreshing the view and unsubscribes it from the registry.
+func (v *View) Stop() {
+	v.stopOnce.Do(func() {
+		close(v.stop)
+		v.unsubscribe()
+	})
+}
+
+func (v *View) countsFor(name string) *callCounts {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	c, ok := v.counts[name]
+	if !ok {
+		c = &callCounts{}
+		v.counts[name] = c
+	}
+	return c
+}
+
+func (v *View) onEvent(ev sparkgap.Event) {
+	switch ev.Kind {
+	case sparkgap.EventCallSuccess:
+		v.countsFor(ev.Breaker).calls.Add(1)
+	case sparkgap.EventCallFailure:
+		c := v.countsFor(ev.Breaker)
+		c.calls.Add(1)
+		c.failures.Add(1)
+	case sparkgap.EventShortCircuit:
+		v.countsFor(ev.Breaker).rejected.Add(1)
+	case sparkgap.EventRejectionSummary:
+		v.countsFor(ev.Breaker).rejected.Add(ev.Rejected)
+	case sparkgap.EventStateChange:
+		line := fmt.Sprintf("%s [%s]%s[-] %s → [%s]%s[-]\n",
+			ev.Time.Format(time.TimeOnly), "white", ev.Breaker,
+			ev.From, stateColor(ev.To), ev.To)
+		v.app.QueueUpdateDraw(func() { fmt.Fprint(v.log, line) })
+	}
+}
+
+func (v *View) loop() {
+	t := time.NewTicker(v.refresh)
+	defer t.Stop()
+	v.app.QueueUpdateDraw(v.render)
+	for {
+		select {
+		case <-v.stop:
+			return
+		case <-t.C:
+			v.app.QueueUpdateDraw(v.render)
+		}
+	}
+}
+
+var headers = []string{"Breaker", "State", "Failures", "In-flight", "Calls/s", "Fail/s", "Rejected/s", "Probe in"}
+
+func (v *View) render() {
+	now := time.Now()
+	v.mu.Lock()
+	elapsed := now.Sub(v.last).Seconds()
+	v.last = now
+	v.mu.Unlock()
+
+	v.table.Clear()
+	for col, h := range headers {
+		v.table.SetCell(0, col, tview.NewTableCell(h).SetTextColor(tcell.ColorYellow).SetSelectable(false))
+	}
+	for row, snap := range v.reg.Snapshots() {
+		c := v.countsFor(snap.Name)
+		cur := callTotals{c.calls.Load(), c.failures.Load(), c.rejected.Load()}
+		v.mu.Lock()
+		prev := v.prev[snap.Name]
+		v.prev[snap.Name] = cur
+		v.mu.Unlock()
+
+		rate := func(now, before uint64) string {
+			if elapsed <= 0 {
+				return "-"
+			}
+			return fmt.Sprintf("%.1f", float64(now-before)/elapsed)
+		}
+		probeIn := "-"
+		if snap.State == sparkgap.StateOpen {
+			probeIn = snap.UntilHalfOpen.Round(100 * time.Millisecond).String()
+		}
+		state := snap.State.String()
+		if snap.Forced != sparkgap.NotForced {
+			state += " (forced " + snap.Forced.String() + ")"
+		}
+		cells := []string{
+			snap.Name,
+			state,
+			fmt.Sprintf("%d/%d", snap.FailureCount, snap.FailureThreshold),
+			fmt.Sprintf("%d", snap.InFlight),
+			rate(cur.calls, prev.calls),
+			rate(cur.failures, prev.failures),
+			rate(cur.rejected, prev.rejected),
+			probeIn,
+		}
+		for col, text := range cells {
+			cell := tview.NewTableCell(text)
+			if col == 1 {
+				cell.SetTextColor(tcell.GetColor(stateColor(snap.State)))
+			}
+			v.table.SetCell(row+1, col, cell)
+		}
+	}
+}
+
+func stateColor(s sparkgap.State) string {
+	switch s {
+	case sparkgap.StateOpen:
+		return "red"
+	case sparkgap.StateHalfOpen:
+		return "yellow"
+	case sparkgap.StateThrottled:
+		return "orange"
+	default:
+		return "green"
+	}
+}
@@ -0,0 +1,187 @@
+package sparkgap
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync/atomic"
+	"time"
+)
+
+/*
+Breaker is a circuit breaker guarding calls that return a value of type T. It is a thin typed
+wrapper around a *CircuitBreaker, whose methods it exposes.
+Create one with NewBreaker or InitBreaker, or wrap an existing core with Typed.
+*/
+type Breaker[T any] struct {
+	*CircuitBreaker
+	isSuccessful atomic.Pointer[func(result T, err error) bool]
+	intercept    atomic.Pointer[Interceptor[T]]
+	onOpen       atomic.Pointer[func() (T, error)]
+	// flights collapses Half-Open calls made with ExecuteKeyed.
+	flights flightGroup[T]
+}
+
+// CallFunc is a protected call as seen by an Interceptor.
+type CallFunc[T any] func(ctx context.Context) (T, error)
+
+/*
+Interceptor wraps the calls made through a Breaker[T], for cross-cutting concerns such as
+logging, metrics, tracing or refreshing an auth token. next runs the call through the breaker,
+so an interceptor also sees rejections and may call next again, e.g. after a refresh.
+*/
+type Interceptor[T any] func(next CallFunc[T]) CallFunc[T]
+
+// Typed wraps cb for calls returning T. Every wrapper of the same core shares its state.
+func Typed[T any](cb *CircuitBreaker) *Breaker[T] {
+	return &Breaker[T]{CircuitBreaker: cb}
+}
+
+/*
+Execute wraps the provided function call with circuit breaker logic.
+It returns an error if the breaker is open, tracks failures and successes in half-open state,
+and resets failure count on successful calls in closed state.
+*/
+func (br *Breaker[T]) Execute(fn func() (T, error)) (T, error) {
+	if br.intercept.Load() == nil {
+		// Without interceptors the adapter does not escape, so the call does not allocate.
+		return br.finish(execute(br.CircuitBreaker, context.Background(), 0, func(context.Context) (T, error) { return fn() }, br.successful()))
+	}
+	return br.ExecuteContext(context.Background(), func(context.Context) (T, error) { return fn() })
+}
+
+/*
+ExecuteContext is like Execute but passes ctx to fn. ctx also bounds how long the call may
+wait for a Half-Open probe slot when HalfOpenFairness is enabled.
+*/
+func (br *Breaker[T]) ExecuteContext(ctx context.Context, fn func(ctx context.Context) (T, error)) (T, error) {
+	return br.ExecuteTimeout(ctx, 0, fn)
+}
+
+/*
+ExecuteTimeout is like ExecuteContext but bounds the call by d instead of the breaker's
+Timeout, since one dependency often has fast and slow endpoints with different latency
+budgets. d <= 0 falls back to Timeout. Interceptors run outside the deadline.
+*/
+func (br *Breaker[T]) ExecuteTimeout(ctx context.Context, d time.Duration, fn func(ctx context.Context) (T, error)) (T, error) {
+	successful := br.successful()
+	ic := br.intercept.Load()
+	if ic == nil {
+		return br.finish(execute(br.CircuitBreaker, ctx, d, fn, successful))
+	}
+	call := (*ic)(func(ctx context.Context) (T, error) {
+		return execute(br.CircuitBreaker, ctx, d, fn, successful)
+	})
+	return br.finish(call(ctx))
+}
+
+func (br *Breaker[T]) successful() func(T, error) bool {
+	if p := br.isSuccessful.Load(); p != nil {
+		return *p
+	}
+	return nil
+}
+
+// finish applies WithOnOpen to the outcome of a call.
+func (br *Breaker[T]) finish(res T, err error) (T, error) {
+	if err == nil {
+		return res, nil
+	}
+	if p := br.onOpen.Load(); p != nil && br.rejectedOpen(err) {
+		return (*p)()
+	}
+	return res, err
+}
+
+// rejectedOpen reports whether err is this breaker refusing a call because it is open or forced open.
+func (br *Breaker[T]) rejectedOpen(err error) bool {
+	var rej *RejectionError
+	return errors.As(err, &rej) && rej.Breaker == br.name && errors.Is(rej.Err, ErrOpen)
+}
+
+/*
+WithOnOpen sets the response returned in place of the rejection while the breaker is open or
+forced open, such as an empty list, a cached config or a feature-flag default. It runs after
+the interceptors, so WithCache still gets to serve a cached result first. Like WithIsSuccessful
+it applies to this wrapper only; a nil onOpen restores returning the rejection.
+*/
+func (br *Breaker[T]) WithOnOpen(onOpen func() (T, error)) *Breaker[T] {
+	if onOpen == nil {
+		br.onOpen.Store(nil)
+	} else {
+		br.onOpen.Store(&onOpen)
+	}
+	return br
+}
+
+/*
+Use appends interceptors to the chain around every Execute and ExecuteContext call through br.
+The first interceptor ever added is the outermost. Like WithIsSuccessful, the chain belongs to
+this wrapper, not to the shared core.
+*/
+func (br *Breaker[T]) Use(interceptors ...Interceptor[T]) *Breaker[T] {
+	for {
+		prev := br.intercept.Load()
+		chain := Interceptor[T](func(next CallFunc[T]) CallFunc[T] {
+			for i := len(interceptors) - 1; i >= 0; i-- {
+				next = interceptors[i](next)
+			}
+			if prev != nil {
+				next = (*prev)(next)
+			}
+			return next
+		})
+		if br.intercept.CompareAndSwap(prev, &chain) {
+			return br
+		}
+	}
+}
+
+/*
+WithIsSuccessful sets the predicate deciding whether a call through this wrapper succeeded, so
+results such as an HTTP response with status 503 count as failures even when err is nil.
+It takes the place of the IsFailure classifier for calls made through br, but not through other
+wrappers of the same core. A nil isSuccessful restores the classifier.
+*/
+func (br *Breaker[T]) WithIsSuccessful(isSuccessful func(result T, err error) bool) *Breaker[T] {
+	if isSuccessful == nil {
+		br.isSuccessful.Store(nil)
+	} else {
+		br.isSuccessful.Store(&isSuccessful)
+	}
+	return br
+}
+
+// WithLogger is CircuitBreaker.WithLogger returning the typed wrapper for chaining.
+func (br *Breaker[T]) WithLogger(l *slog.Logger) *Breaker[T] {
+	br.CircuitBreaker.WithLogger(l)
+	return br
+}
+
+/*
+NewBreaker creates a circuit breaker named name from cfg.
+A nil cfg uses the defaults for every field, as do zero-valued fields of a non-nil cfg.
+It returns an error wrapping ErrInvalidConfig if cfg contains values that cannot be satisfied.
+*/
+func NewBreaker[T any](name string, cfg *BreakerConfig) (*Breaker[T], error) {
+	cb, err := NewCircuitBreaker(name, cfg)
+	if err != nil {
+		return nil, err
+	}
+	return Typed[T](cb), nil
+}
+
+/*
+InitBreaker initializes a new circuit breaker with configurable values via cfg.
+Defaults are applied if not provided, and a nil cfg means all defaults.
+Fields left zero take the values set with SetDefaultConfig first. Unlike NewBreaker it does
+not validate cfg: out-of-range values are replaced by defaults.
+*/
+func InitBreaker[T any](name string, cfg *BreakerConfig) *Breaker[T] {
+	var c BreakerConfig
+	if cfg != nil {
+		c = *cfg
+	}
+	cb, _ := newCircuitBreaker(name, c, false)
+	return Typed[T](cb)
+}
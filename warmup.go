@@ -0,0 +1,74 @@
+package sparkgap
+
+import (
+	"context"
+	"math/rand/v2"
+	"sync/atomic"
+	"time"
+)
+
+const defaultWarmUpStartPercent uint32 = 10
+
+// warmUp ramps admitted traffic back up after the breaker recovers.
+type warmUp struct {
+	duration     time.Duration
+	startPercent uint32
+	// since is when the current warm-up began, in Unix nanoseconds, or zero when idle.
+	since atomic.Int64
+}
+
+func newWarmUp(c *BreakerConfig) *warmUp {
+	if c.WarmUpDuration <= 0 {
+		return nil
+	}
+	return &warmUp{duration: c.WarmUpDuration, startPercent: c.WarmUpStartPercent}
+}
+
+func (w *warmUp) start(now time.Time) {
+	if w != nil {
+		w.since.Store(now.UnixNano())
+	}
+}
+
+func (w *warmUp) stop() {
+	if w != nil {
+		w.since.Store(0)
+	}
+}
+
+// percent returns the share of calls to admit at now, or 100 once the warm-up is over.
+func (w *warmUp) percent(now time.Time) uint32 {
+	since := w.since.Load()
+	if since == 0 {
+		return 100
+	}
+	elapsed := now.UnixNano() - since
+	if elapsed >= int64(w.duration) {
+		w.since.CompareAndSwap(since, 0)
+		return 100
+	}
+	return w.startPercent + uint32(int64(100-w.startPercent)*max(elapsed, 0)/int64(w.duration))
+}
+
+/*
+warmingUp reports whether a Closed call with the priority of ctx should be turned away because
+the breaker closed less than WarmUpDuration ago. PriorityCritical calls are always admitted.
+*/
+func (br *CircuitBreaker) warmingUp(ctx context.Context, adm admission) bool {
+	w := br.warmUp
+	if w == nil || w.since.Load() == 0 || adm.state != StateClosed || adm.forced {
+		return false
+	}
+	if PriorityFrom(ctx) == PriorityCritical {
+		return false
+	}
+	return rand.Uint32N(100) >= w.percent(br.clock.Now())
+}
+
+// WarmUpPercent returns the share of calls the breaker currently admits while warming up, 100 if it is not.
+func (br *CircuitBreaker) WarmUpPercent() uint32 {
+	if br.warmUp == nil || br.getState() != StateClosed {
+		return 100
+	}
+	return br.warmUp.percent(br.clock.Now())
+}
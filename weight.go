@@ -0,0 +1,28 @@
+package sparkgap
+
+import (
+	"math"
+	"sync/atomic"
+)
+
+// weightScale stores failure weights as fixed-point thousandths so they can be added atomically.
+const weightScale = 1000
+
+/*
+FailureWeigher scores a failure by how strongly it points to an outage, for example 2 for a
+timeout, 1 for a 500 and 0.5 for a 429. With one configured, FailureThreshold is compared
+against the accumulated weight of consecutive failures instead of their count. Weights at or
+below zero add nothing.
+*/
+type FailureWeigher func(err error) float64
+
+func weightUnits(w float64) uint64 {
+	if !(w > 0) {
+		return 0
+	}
+	return uint64(math.Round(min(w, math.MaxUint32) * weightScale))
+}
+
+func (br *CircuitBreaker) weightReached(total uint64) bool {
+	return total >= uint64(atomic.LoadUint32(&br.counter.failureThreshold))*weightScale
+}